@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tflynn3/gcdiff/internal/compare"
+	"github.com/tflynn3/gcdiff/internal/gcp"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit [resource-type] [name]",
+	Short: "Audit a GCP resource against a policy template",
+	Long: `Audit a live GCP resource against a policy template.
+
+The template is a YAML or JSON file of expected field values. Only fields
+present in the template are checked; any other live fields are ignored.
+Mismatched and missing fields are reported as violations.
+
+Examples:
+  # Check an instance against a compliance template
+  gcdiff audit "compute instances" web-1 --project1=proj --zone=us-central1-a --template=policy.yaml
+
+  # Check a bucket
+  gcdiff audit "storage buckets" my-bucket --project1=proj --template=bucket-policy.yaml`,
+	Args: cobra.ExactArgs(2),
+	RunE: runAudit,
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().String("template", "", "Policy template file (YAML or JSON) with expected values (required)")
+	addLocationFlags(auditCmd, "resource")
+	addSourceFlag(auditCmd)
+	_ = auditCmd.MarkFlagRequired("template")
+}
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	resourceTypeStr := args[0]
+	name := args[1]
+
+	// Violations are reported as errors; don't follow them with usage text
+	cmd.SilenceUsage = true
+
+	flags, err := checkedLocationFlags(cmd, resourceTypeStr)
+	if err != nil {
+		return err
+	}
+	project := viper.GetString("project1")
+	if needsProject(cmd, flags) {
+		if project, err = resolveProject1(cmd, flags["configuration"]); err != nil {
+			return err
+		}
+	}
+
+	fetcher, err := sourceFetcher(cmd)
+	if err != nil {
+		return err
+	}
+
+	colors, err := compare.ParseColorMode(viper.GetString("color"))
+	if err != nil {
+		return err
+	}
+
+	templatePath, _ := cmd.Flags().GetString("template")
+	template, err := compare.LoadTemplate(templatePath)
+	if err != nil {
+		return fmt.Errorf("failed to load template: %w", err)
+	}
+
+	spec := gcp.ResourceSpec{Type: resourceTypeStr, Name: name, Project: project, Flags: flags}
+
+	if viper.GetBool("dry-run") {
+		fmt.Fprintln(cmd.OutOrStdout(), fetcher.Describe(spec))
+		return nil
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "Fetching resource with: %s...\n", fetcher.Describe(spec))
+	resource, err := fetcher.Fetch(cmd.Context(), spec)
+	if err != nil {
+		return describeFetchError(err)
+	}
+
+	diff := compare.Audit(template, resource)
+
+	switch viper.GetString("format") {
+	case "json":
+		output, _ := json.MarshalIndent(compare.GetAllDiffs(diff), "", "  ")
+		fmt.Fprintln(cmd.OutOrStdout(), string(output))
+	default:
+		compare.PrintAuditReportWithOptions(cmd.OutOrStdout(), diff, name, templatePath, compare.OutputOptions{Color: colors})
+	}
+
+	if violations := diff.Count(); violations > 0 {
+		return fmt.Errorf("%d policy violation(s) found", violations)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTemplate(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	return path
+}
+
+func TestRunAudit_Compliant(t *testing.T) {
+	runner := &fakeRunner{responses: map[string]string{
+		"compute instances describe web-1": `{"name": "web-1", "machineType": "n1-standard-2", "status": "RUNNING"}`,
+	}}
+	useFakeRunner(t, runner)
+	template := writeTemplate(t, "machineType: n1-standard-2\n")
+
+	output, err := executeCommand(t, "audit", "compute instances", "web-1",
+		"--project1=proj", "--zone=us-central1-a", "--template="+template)
+	if err != nil {
+		t.Fatalf("Expected compliant resource to pass, got: %v\n%s", err, output)
+	}
+
+	if !strings.Contains(output, "complies with template") {
+		t.Errorf("Expected compliance message, got:\n%s", output)
+	}
+}
+
+func TestRunAudit_Violation(t *testing.T) {
+	runner := &fakeRunner{responses: map[string]string{
+		"compute instances describe web-1": `{"name": "web-1", "machineType": "n1-standard-2"}`,
+	}}
+	useFakeRunner(t, runner)
+	template := writeTemplate(t, "machineType: e2-medium\ndeletionProtection: true\n")
+
+	output, err := executeCommand(t, "audit", "compute instances", "web-1",
+		"--project1=proj", "--zone=us-central1-a", "--template="+template)
+	if err == nil {
+		t.Fatal("Expected an error for a violating resource")
+	}
+
+	if !strings.Contains(err.Error(), "2 policy violation(s)") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "machineType") || !strings.Contains(output, "deletionProtection") {
+		t.Errorf("Expected both violations in output, got:\n%s", output)
+	}
+}
+
+func TestRunAudit_LocationFlags(t *testing.T) {
+	template := writeTemplate(t, "machineType: n1-standard-2\n")
+
+	_, err := executeCommand(t, "audit", "compute instances", "web-1",
+		"--project1=proj", "--template="+template)
+	if err == nil || !strings.Contains(err.Error(), "compute instances is zonal; please provide --zone") {
+		t.Errorf("Expected a missing zone error, got %v", err)
+	}
+}
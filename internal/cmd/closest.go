@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tflynn3/gcdiff/internal/compare"
+	"github.com/tflynn3/gcdiff/internal/gcp"
+	"golang.org/x/sync/errgroup"
+)
+
+var closestCmd = &cobra.Command{
+	Use:   "closest [resource-type] [name]",
+	Short: "Find which of several resources is most like a given one",
+	Long: `Compare a GCP resource against each of several candidates and rank them by
+number of differences, fewest first. The diff against the closest candidate
+is shown after the ranking.
+
+Candidates are read from --project2 (defaults to --project1) and share the
+location flags with the resource.
+
+Examples:
+  # Which staging instance most resembles prod's web-1?
+  gcdiff closest "compute instances" web-1 --project1=prod --project2=staging \
+    --zone=us-central1-a --candidates=web-a,web-b,web-c`,
+	Args: cobra.ExactArgs(2),
+	RunE: runClosest,
+}
+
+func init() {
+	rootCmd.AddCommand(closestCmd)
+
+	closestCmd.Flags().StringSlice("candidates", nil, "Resources to compare against (comma-separated, required)")
+	addLocationFlags(closestCmd, "resources")
+	_ = closestCmd.MarkFlagRequired("candidates")
+}
+
+func runClosest(cmd *cobra.Command, args []string) error {
+	resourceTypeStr := args[0]
+	name := args[1]
+
+	// Fetch errors are reported as errors; don't follow them with usage text
+	cmd.SilenceUsage = true
+
+	flags := locationFlags(cmd)
+	project, err := resolveProject1(cmd, flags["configuration"])
+	if err != nil {
+		return err
+	}
+	candidateProject := viper.GetString("project2")
+	if candidateProject == "" {
+		candidateProject = project
+	}
+
+	colors, err := compare.ParseColorMode(viper.GetString("color"))
+	if err != nil {
+		return err
+	}
+
+	maxConcurrency, err := resolveMaxConcurrency()
+	if err != nil {
+		return err
+	}
+
+	candidates, _ := cmd.Flags().GetStringSlice("candidates")
+	if len(candidates) == 0 {
+		return fmt.Errorf("--candidates must name at least one resource")
+	}
+
+	target := gcp.ResourceSpec{Type: resourceTypeStr, Name: name, Project: project, Flags: flags}
+	specs := make([]gcp.ResourceSpec, len(candidates))
+	for i, candidate := range candidates {
+		specs[i] = gcp.ResourceSpec{Type: resourceTypeStr, Name: candidate, Project: candidateProject, Flags: flags}
+	}
+
+	fetcher := newGcloudFetcher()
+	if viper.GetBool("dry-run") {
+		fmt.Fprintln(cmd.OutOrStdout(), fetcher.Describe(target))
+		for _, spec := range specs {
+			fmt.Fprintln(cmd.OutOrStdout(), fetcher.Describe(spec))
+		}
+		return nil
+	}
+
+	cfg, err := loadCompareConfig(cmd)
+	if err != nil {
+		return err
+	}
+	// Every candidate shares one scope, so the first stands for all of them
+	ignoreResourceNames(cfg, target, specs[0])
+
+	// Log lines are written up front so goroutines never share the writer
+	fmt.Fprintf(cmd.ErrOrStderr(), "Fetching resource with: %s...\n", fetcher.Describe(target))
+	for _, spec := range specs {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Fetching candidate with: %s...\n", fetcher.Describe(spec))
+	}
+
+	var resource map[string]interface{}
+	resources := make([]map[string]interface{}, len(specs))
+	g, gctx := errgroup.WithContext(cmd.Context())
+	g.SetLimit(maxConcurrency)
+	g.Go(func() error {
+		var err error
+		if resource, err = fetcher.Fetch(gctx, target); err != nil {
+			return describeFetchError(err)
+		}
+		return nil
+	})
+	for i, spec := range specs {
+		g.Go(func() error {
+			var err error
+			if resources[i], err = fetcher.Fetch(gctx, spec); err != nil {
+				return fmt.Errorf("candidate %s: %w", spec.Name, describeFetchError(err))
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	ranked := compare.NewDiffer(cfg, viper.GetBool("show-all")).RankCandidates(resource, candidates, resources)
+
+	switch viper.GetString("format") {
+	case "json":
+		output, _ := json.MarshalIndent(ranked, "", "  ")
+		fmt.Fprintln(cmd.OutOrStdout(), string(output))
+	default:
+		compare.PrintCandidateRanking(cmd.OutOrStdout(), ranked, name, compare.OutputOptions{
+			UnitFields:       cfg.UnitFields,
+			DeprecatedFields: cfg.DeprecatedFields,
+			Width:            outputWidth(cmd.OutOrStdout()),
+			Color:            colors,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/compare"
+)
+
+func TestRunClosest(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe prod-web": `{"name": "prod-web", "machineType": "n1-standard-4", "disk": 100, "zone": "a"}`,
+		"compute instances describe stg-far":  `{"name": "stg-far", "machineType": "n1-standard-2", "disk": 50, "zone": "b"}`,
+		"compute instances describe stg-near": `{"name": "stg-near", "machineType": "n1-standard-4", "disk": 50, "zone": "a"}`,
+		"compute instances describe stg-mid":  `{"name": "stg-mid", "machineType": "n1-standard-2", "disk": 50, "zone": "a"}`,
+	}})
+
+	output, err := executeCommand(t, "closest", "compute instances", "prod-web",
+		"--project1=proj", "--zone=us-central1-a", "--candidates=stg-far,stg-near,stg-mid", "--format=json")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	var ranked []compare.Candidate
+	if err := json.Unmarshal([]byte(output), &ranked); err != nil {
+		t.Fatalf("Failed to parse json output: %v\n%s", err, output)
+	}
+	var got []string
+	for _, c := range ranked {
+		got = append(got, c.Name)
+	}
+	// Names differ for every candidate in the same project, so they are ignored
+	if strings.Join(got, ",") != "stg-near,stg-mid,stg-far" || ranked[0].Differences != 1 {
+		t.Errorf("Expected candidates ranked by differences, got:\n%s", output)
+	}
+
+	output, err = executeCommand(t, "closest", "compute instances", "prod-web",
+		"--project1=proj", "--zone=us-central1-a", "--candidates=stg-far,stg-near")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	for _, want := range []string{"1. stg-near  1 difference(s)", "2. stg-far   3 difference(s)", "disk"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestRunClosest_FetchError(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{
+		responses: map[string]string{"compute instances describe prod-web": `{"disk": 100}`},
+		failures:  map[string]string{"compute instances describe stg-gone": "ERROR: not found"},
+	})
+
+	_, err := executeCommand(t, "closest", "compute instances", "prod-web",
+		"--project1=proj", "--zone=us-central1-a", "--candidates=stg-gone")
+	if err == nil || !strings.Contains(err.Error(), "candidate stg-gone") {
+		t.Errorf("Expected the failing candidate to be named, got %v", err)
+	}
+}
+
+func TestRunClosest_ValidatesConfig(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe prod-web": `{"disk": 100}`,
+		"compute instances describe stg-web":  `{"disk": 50}`,
+	}})
+
+	_, err := executeCommand(t, "closest", "compute instances", "prod-web",
+		"--project1=proj", "--zone=us-central1-a", "--candidates=stg-web", "--array-mode=sideways")
+	if err == nil || !strings.Contains(err.Error(), `unknown array mode "sideways"`) {
+		t.Errorf("Expected the array mode to be validated, got %v", err)
+	}
+}
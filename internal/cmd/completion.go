@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate a shell completion script",
+	Long: `Generate a shell completion script for gcdiff.
+
+Examples:
+  # Bash (current session)
+  source <(gcdiff completion bash)
+
+  # Zsh (install permanently)
+  gcdiff completion zsh > "${fpath[1]}/_gcdiff"
+
+  # Fish
+  gcdiff completion fish > ~/.config/fish/completions/gcdiff.fish
+
+  # PowerShell
+  gcdiff completion powershell | Out-String | Invoke-Expression`,
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(out, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(out)
+		case "fish":
+			return rootCmd.GenFishCompletion(out, true)
+		case "powershell":
+			return rootCmd.GenPowerShellCompletionWithDesc(out)
+		default:
+			return fmt.Errorf("unsupported shell %q", args[0])
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompletion_Bash(t *testing.T) {
+	output, err := executeCommand(t, "completion", "bash")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if output == "" {
+		t.Fatal("Expected non-empty bash completion script")
+	}
+	if !strings.Contains(output, "gcdiff") {
+		t.Error("Expected completion script to reference gcdiff")
+	}
+}
+
+func TestCompletion_UnsupportedShell(t *testing.T) {
+	if _, err := executeCommand(t, "completion", "tcsh"); err == nil {
+		t.Error("Expected error for unsupported shell")
+	}
+}
+
+func TestCompletion_ResourceTypes(t *testing.T) {
+	output, err := executeCommand(t, "__complete", "resource", "")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if !strings.Contains(output, "compute instances") || !strings.Contains(output, "storage buckets") {
+		t.Errorf("Expected resource type suggestions, got:\n%s", output)
+	}
+}
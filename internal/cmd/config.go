@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect gcdiff configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check a config file for invalid or redundant rules",
+	Long: `Check a config file for rules that are invalid or have no effect:
+regex patterns and globs that don't compile, duplicate entries, and
+ignore_fields entries already covered by ignore_under_path.
+
+The file is taken from --config, then $GCDIFF_CONFIG, then the default
+search paths. Exits nonzero if any problems are found.
+
+Examples:
+  gcdiff config validate
+  gcdiff config validate --config=./team.gcdiff.yaml`,
+	Args: cobra.NoArgs,
+	RunE: runConfigValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	// Problems are reported as errors; don't follow them with usage text
+	cmd.SilenceUsage = true
+
+	path := resolveConfigFile(cfgFile)
+	if path == "" {
+		path = viper.ConfigFileUsed()
+	}
+	if path == "" {
+		return fmt.Errorf("no config file found (use --config or %s)", configEnvVar)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	problems, err := config.Validate(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	out := cmd.OutOrStdout()
+	if len(problems) == 0 {
+		fmt.Fprintf(out, "✓ %s is valid\n", path)
+		return nil
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for _, problem := range problems {
+		if problem.Line > 0 && problem.Line <= len(lines) {
+			fmt.Fprintf(out, "✗ %s:%d: %s\n", path, problem.Line, problem.Message)
+			fmt.Fprintf(out, "    %d | %s\n", problem.Line, lines[problem.Line-1])
+		} else {
+			fmt.Fprintf(out, "✗ %s: %s\n", path, problem.Message)
+		}
+	}
+
+	return fmt.Errorf("%d problem(s) found in %s", len(problems), path)
+}
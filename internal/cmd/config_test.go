@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "gcdiff.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestConfigValidate_ReportsProblems(t *testing.T) {
+	path := writeConfig(t, `ignore_patterns:
+  - "(unclosed"
+`)
+
+	output, err := executeCommand(t, "config", "validate", "--config="+path)
+	if err == nil || !strings.Contains(err.Error(), "1 problem(s) found") {
+		t.Errorf("Expected problem count error, got %v", err)
+	}
+	if !strings.Contains(output, path+":2: ignore_patterns entry") {
+		t.Errorf("Expected problem with line number, got:\n%s", output)
+	}
+	if !strings.Contains(output, `2 |   - "(unclosed"`) {
+		t.Errorf("Expected offending source line, got:\n%s", output)
+	}
+}
+
+func TestConfigValidate_Clean(t *testing.T) {
+	path := writeConfig(t, `ignore_fields:
+  - id
+`)
+
+	output, err := executeCommand(t, "config", "validate", "--config="+path)
+	if err != nil {
+		t.Fatalf("Expected clean config to validate, got %v", err)
+	}
+	if !strings.Contains(output, "is valid") {
+		t.Errorf("Expected success message, got:\n%s", output)
+	}
+}
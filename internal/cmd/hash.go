@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tflynn3/gcdiff/internal/compare"
+	"github.com/tflynn3/gcdiff/internal/config"
+	"github.com/tflynn3/gcdiff/internal/gcp"
+)
+
+var hashCmd = &cobra.Command{
+	Use:   "hash [resource-type] [name]",
+	Short: "Print a stable hash of a GCP resource",
+	Long: `Print a SHA-256 hash of a live GCP resource's canonical JSON.
+
+Fields the config ignores are left out, as in comparison, so the hash only
+changes when a field a diff would report changes. Pass a hash printed earlier
+to --expect-hash to fail if the resource has changed since.
+
+Examples:
+  # Record the hash of an instance
+  gcdiff hash "compute instances" web-1 --project1=proj --zone=us-central1-a > web-1.sha256
+
+  # Later, fail if anything meaningful changed
+  gcdiff hash "compute instances" web-1 --project1=proj --zone=us-central1-a --expect-hash="$(cat web-1.sha256)"`,
+	Args: cobra.ExactArgs(2),
+	RunE: runHash,
+}
+
+func init() {
+	rootCmd.AddCommand(hashCmd)
+
+	hashCmd.Flags().String("expect-hash", "", "Fail if the resource's hash differs from this one")
+	addLocationFlags(hashCmd, "resource")
+	addSourceFlag(hashCmd)
+}
+
+func runHash(cmd *cobra.Command, args []string) error {
+	resourceTypeStr := args[0]
+	name := args[1]
+
+	// A changed hash is reported as an error; don't follow it with usage text
+	cmd.SilenceUsage = true
+
+	flags, err := checkedLocationFlags(cmd, resourceTypeStr)
+	if err != nil {
+		return err
+	}
+	project := viper.GetString("project1")
+	if needsProject(cmd, flags) {
+		if project, err = resolveProject1(cmd, flags["configuration"]); err != nil {
+			return err
+		}
+	}
+
+	fetcher, err := sourceFetcher(cmd)
+	if err != nil {
+		return err
+	}
+
+	spec := gcp.ResourceSpec{Type: resourceTypeStr, Name: name, Project: project, Flags: flags}
+
+	if viper.GetBool("dry-run") {
+		fmt.Fprintln(cmd.OutOrStdout(), fetcher.Describe(spec))
+		return nil
+	}
+
+	cfg, err := config.Load(viper.ConfigFileUsed())
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: could not load config: %v\n", err)
+		cfg = config.Default()
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "Fetching resource with: %s...\n", fetcher.Describe(spec))
+	resource, err := fetcher.Fetch(cmd.Context(), spec)
+	if err != nil {
+		return describeFetchError(err)
+	}
+
+	hash, err := compare.HashResource(resource, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to hash resource: %w", err)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), hash)
+
+	if expected, _ := cmd.Flags().GetString("expect-hash"); expected != "" && !strings.EqualFold(strings.TrimSpace(expected), hash) {
+		return fmt.Errorf("resource has changed: hash %s does not match expected %s", hash, strings.TrimSpace(expected))
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunHash(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe web-1": `{"id": "1", "machineType": "n1-standard-2"}`,
+		"compute instances describe web-2": `{"id": "2", "machineType": "n1-standard-2"}`,
+		"compute instances describe web-3": `{"id": "3", "machineType": "n1-standard-4"}`,
+	}})
+
+	hash := func(t *testing.T, name string, args ...string) (string, error) {
+		t.Helper()
+		output, err := executeCommand(t, append([]string{"hash", "compute instances", name, "--project1=proj", "--zone=us-central1-a"}, args...)...)
+		return strings.TrimSpace(output), err
+	}
+
+	hash1, err := hash(t, "web-1")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	// web-2 differs only in an ignored field
+	if _, err := hash(t, "web-2", "--expect-hash="+hash1); err != nil {
+		t.Errorf("Expected the hash to match, got %v", err)
+	}
+	if _, err := hash(t, "web-3", "--expect-hash="+hash1); err == nil || !strings.Contains(err.Error(), "resource has changed") {
+		t.Errorf("Expected a changed hash error, got %v", err)
+	}
+}
+
+func TestRunHash_LocationFlags(t *testing.T) {
+	runner := &fakeRunner{responses: map[string]string{"config get-value project": "work-default\n"}}
+	useFakeRunner(t, runner)
+
+	_, err := executeCommand(t, "hash", "compute instances", "web-1", "--project1=proj")
+	if err == nil || !strings.Contains(err.Error(), "compute instances is zonal; please provide --zone") {
+		t.Errorf("Expected a missing zone error, got %v", err)
+	}
+
+	output, err := executeCommand(t, "hash", "compute instances", "web-1",
+		"--zone=us-central1-a", "--configuration=work", "--dry-run")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if len(runner.calls) != 1 || strings.Join(runner.calls[0][1:], " ") != "config get-value project --configuration=work" {
+		t.Errorf("Expected the project to be read from --configuration, got calls %v", runner.calls)
+	}
+	if !strings.Contains(output, "--configuration=work") || !strings.Contains(output, "--project=work-default") {
+		t.Errorf("Expected the configuration and its project in the command, got:\n%s", output)
+	}
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+
+	"github.com/spf13/cobra"
+	"github.com/tflynn3/gcdiff/internal/compare"
+)
+
+// hookRunner runs a shell command with stdin as its input
+type hookRunner func(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) error
+
+// runHook runs the --on-diff command; tests replace it to avoid executing
+// commands
+var runHook hookRunner = execHook
+
+// execHook runs command through the system shell
+func execHook(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	c := exec.CommandContext(ctx, shell, flag, command)
+	c.Stdin = stdin
+	c.Stdout = stdout
+	c.Stderr = stderr
+	return c.Run()
+}
+
+// runDiffHook runs command with diff as JSON on its stdin if the resources
+// differ. The hook's output goes to stderr so it can't corrupt the diff on
+// stdout.
+func runDiffHook(cmd *cobra.Command, command string, diff *compare.Diff) error {
+	if diff.Type == compare.DiffTypeEqual {
+		return nil
+	}
+	data, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode diff for --on-diff: %w", err)
+	}
+	if err := runHook(cmd.Context(), command, bytes.NewReader(data), cmd.ErrOrStderr(), cmd.ErrOrStderr()); err != nil {
+		return fmt.Errorf("--on-diff command failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/compare"
+)
+
+// fakeHook records the --on-diff invocations instead of running them
+type fakeHook struct {
+	commands []string
+	inputs   []string
+}
+
+func useFakeHook(t *testing.T) *fakeHook {
+	t.Helper()
+	hook := &fakeHook{}
+	original := runHook
+	runHook = func(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+		input, _ := io.ReadAll(stdin)
+		hook.commands = append(hook.commands, command)
+		hook.inputs = append(hook.inputs, string(input))
+		return nil
+	}
+	t.Cleanup(func() { runHook = original })
+	return hook
+}
+
+func TestRunResource_OnDiff(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2"}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-4"}`,
+		"compute instances describe vm-3": `{"machineType": "n1-standard-2"}`,
+	}})
+	hook := useFakeHook(t)
+
+	if _, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--on-diff=./alert.sh"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if len(hook.commands) != 1 || hook.commands[0] != "./alert.sh" {
+		t.Fatalf("Expected the hook to run once, got %v", hook.commands)
+	}
+	var diff compare.Diff
+	if err := json.Unmarshal([]byte(hook.inputs[0]), &diff); err != nil {
+		t.Fatalf("Expected the diff as JSON on stdin: %v\n%s", err, hook.inputs[0])
+	}
+	if child := diff.Children["machineType"]; child == nil || child.Value2 != "n1-standard-4" {
+		t.Errorf("Expected the machineType change in the hook input, got:\n%s", hook.inputs[0])
+	}
+
+	// Equal resources don't fire the hook
+	if _, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-3",
+		"--project1=proj", "--zone1=us-central1-a", "--on-diff=./alert.sh"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if len(hook.commands) != 1 {
+		t.Errorf("Expected no hook for equal resources, got %v", hook.commands)
+	}
+}
+
+func TestExecHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	var stdout, stderr bytes.Buffer
+	err := execHook(context.Background(), "cat; echo done >&2", strings.NewReader("diff"), &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("execHook failed: %v", err)
+	}
+	if stdout.String() != "diff" || stderr.String() != "done\n" {
+		t.Errorf("Expected stdin piped through, got stdout %q, stderr %q", stdout.String(), stderr.String())
+	}
+
+	if err := execHook(context.Background(), "exit 3", strings.NewReader(""), &stdout, &stderr); err == nil {
+		t.Error("Expected a failing command to return an error")
+	}
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"github.com/tflynn3/gcdiff/internal/compare"
+)
+
+// browseHelp is the key reference shown on the last line of the browser
+const browseHelp = "↑/↓ move  ←/→ collapse/expand  enter toggle  n/N next/prev change  e/c expand/collapse all  q quit"
+
+var browseTitle = color.New(color.Bold).SprintFunc()
+
+// browseDiff shows diff in the interactive tree browser when stdin and stdout
+// are a terminal, and reports whether it did. Otherwise, or if the terminal
+// can't be set up, it warns and the caller prints the diff normally.
+func browseDiff(cmd *cobra.Command, diff *compare.Diff, name1, name2 string, opts compare.OutputOptions) bool {
+	in, inOK := cmd.InOrStdin().(*os.File)
+	out, outOK := cmd.OutOrStdout().(*os.File)
+	if !inOK || !outOK || !isatty.IsTerminal(in.Fd()) || !isatty.IsTerminal(out.Fd()) {
+		fmt.Fprintln(cmd.ErrOrStderr(), "Warning: --interactive needs a terminal; printing the diff instead")
+		return false
+	}
+
+	// Use the alternate screen so the shell's scrollback is left as it was
+	title := fmt.Sprintf("Comparing: %s <-> %s", name1, name2)
+	program := tea.NewProgram(newTreeModel(compare.NewTreeView(diff), title, opts),
+		tea.WithInput(in), tea.WithOutput(out), tea.WithAltScreen())
+	if _, err := program.Run(); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: interactive mode failed: %v\n", err)
+	}
+	return true
+}
+
+// treeModel is the bubbletea model of the browser. The tree state lives in
+// the TreeView; the model maps keys to its methods and tracks the screen size.
+type treeModel struct {
+	view   *compare.TreeView
+	title  string
+	opts   compare.OutputOptions
+	width  int
+	height int
+}
+
+// newTreeModel creates a browser for view, assuming an 80x24 screen until
+// the terminal reports its size
+func newTreeModel(view *compare.TreeView, title string, opts compare.OutputOptions) treeModel {
+	return treeModel{view: view, title: title, opts: opts, width: 80, height: 24}
+}
+
+func (m treeModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update resizes the screen or applies a keypress to the tree, quitting on
+// q or Ctrl+C
+func (m treeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		page := max(1, m.height-2)
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			m.view.Move(-1)
+		case "down", "j":
+			m.view.Move(1)
+		case "pgup":
+			m.view.Move(-page)
+		case "pgdown":
+			m.view.Move(page)
+		case "home", "g":
+			m.view.Move(-len(m.view.Rows()))
+		case "end", "G":
+			m.view.Move(len(m.view.Rows()))
+		case "left", "h":
+			m.view.Collapse()
+		case "right", "l":
+			m.view.Expand()
+		case "enter", " ":
+			m.view.Toggle()
+		case "n":
+			m.view.NextChange()
+		case "N", "p":
+			m.view.PrevChange()
+		case "e":
+			m.view.SetExpanded(true)
+		case "c":
+			m.view.SetExpanded(false)
+		}
+	}
+	return m, nil
+}
+
+// View draws the title, the visible rows and the key reference
+func (m treeModel) View() string {
+	lines := []string{browseTitle(m.title)}
+	lines = append(lines, m.view.Render(m.width, m.height-2, m.opts)...)
+	for len(lines) < m.height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, browseTitle(browseHelp))
+	return strings.Join(lines, "\n")
+}
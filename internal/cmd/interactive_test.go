@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tflynn3/gcdiff/internal/compare"
+)
+
+func TestTreeModel(t *testing.T) {
+	diff := &compare.Diff{
+		Type: compare.DiffTypeModified,
+		Children: map[string]*compare.Diff{
+			"labels": {Path: "labels", Type: compare.DiffTypeModified, Children: map[string]*compare.Diff{
+				"env": {Path: "labels.env", Type: compare.DiffTypeModified, Value1: "dev", Value2: "prod"},
+			}},
+			"machineType": {Path: "machineType", Type: compare.DiffTypeModified, Value1: "n1", Value2: "n2"},
+		},
+	}
+	var model tea.Model = newTreeModel(compare.NewTreeView(diff), "Comparing: a <-> b", compare.OutputOptions{Color: compare.ColorNever})
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 80, Height: 10})
+
+	// Jump to the first change, which expands labels, then quit
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if _, quit := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); quit == nil {
+		t.Error("Expected q to quit")
+	}
+
+	view := model.(treeModel).view
+	if view.Selected().Diff.Path != "labels.env" {
+		t.Errorf("Expected labels.env selected, got %s", view.Selected().Diff.Path)
+	}
+	screen := model.View()
+	if lines := strings.Count(screen, "\n") + 1; lines != 10 {
+		t.Errorf("Expected the screen to fill 10 lines, got %d", lines)
+	}
+	for _, want := range []string{"Comparing: a <-> b", `~ env: "dev" -> "prod"`, "q quit"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("Expected the screen to contain %q, got:\n%s", want, screen)
+		}
+	}
+}
+
+func TestRunResource_InteractiveFallsBack(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2"}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-4"}`,
+	}})
+
+	stdout, stderr, err := executeCommandOutput(t, context.Background(), "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--interactive")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(stderr, "--interactive needs a terminal") {
+		t.Errorf("Expected a fallback warning, got:\n%s", stderr)
+	}
+	if !strings.Contains(stdout, "n1-standard-4") {
+		t.Errorf("Expected the diff to be printed normally, got:\n%s", stdout)
+	}
+}
+
+func TestRunResource_InteractiveRejectsOtherFormats(t *testing.T) {
+	_, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--interactive", "--format=json")
+	if err == nil || !strings.Contains(err.Error(), "--interactive cannot be used with --format=json") {
+		t.Errorf("Expected a format error, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tflynn3/gcdiff/internal/compare"
+	"github.com/tflynn3/gcdiff/internal/gcp"
+)
+
+var labelsCheckCmd = &cobra.Command{
+	Use:   "labels-check [resource-type] [name]",
+	Short: "Check a GCP resource for required labels",
+	Long: `Check that a live GCP resource has every required label set.
+
+Labels that are missing or have an empty value are reported as violations.
+
+Examples:
+  # Require owner and cost-center labels on an instance
+  gcdiff labels-check "compute instances" web-1 --project1=proj --zone=us-central1-a --required=owner,cost-center
+
+  # Cloud SQL keeps its labels under settings.userLabels
+  gcdiff labels-check "sql instances" db-1 --project1=proj --required=owner --labels-path=settings.userLabels`,
+	Args: cobra.ExactArgs(2),
+	RunE: runLabelsCheck,
+}
+
+func init() {
+	rootCmd.AddCommand(labelsCheckCmd)
+
+	labelsCheckCmd.Flags().StringSlice("required", nil, "Labels the resource must have (comma-separated, required)")
+	labelsCheckCmd.Flags().String("labels-path", "labels", "Dotted path of the resource's labels map")
+	addLocationFlags(labelsCheckCmd, "resource")
+	addSourceFlag(labelsCheckCmd)
+	_ = labelsCheckCmd.MarkFlagRequired("required")
+}
+
+func runLabelsCheck(cmd *cobra.Command, args []string) error {
+	resourceTypeStr := args[0]
+	name := args[1]
+
+	// Violations are reported as errors; don't follow them with usage text
+	cmd.SilenceUsage = true
+
+	flags, err := checkedLocationFlags(cmd, resourceTypeStr)
+	if err != nil {
+		return err
+	}
+	project := viper.GetString("project1")
+	if needsProject(cmd, flags) {
+		if project, err = resolveProject1(cmd, flags["configuration"]); err != nil {
+			return err
+		}
+	}
+
+	fetcher, err := sourceFetcher(cmd)
+	if err != nil {
+		return err
+	}
+
+	colors, err := compare.ParseColorMode(viper.GetString("color"))
+	if err != nil {
+		return err
+	}
+
+	required, _ := cmd.Flags().GetStringSlice("required")
+	labelsPath, _ := cmd.Flags().GetString("labels-path")
+
+	spec := gcp.ResourceSpec{Type: resourceTypeStr, Name: name, Project: project, Flags: flags}
+
+	if viper.GetBool("dry-run") {
+		fmt.Fprintln(cmd.OutOrStdout(), fetcher.Describe(spec))
+		return nil
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "Fetching resource with: %s...\n", fetcher.Describe(spec))
+	resource, err := fetcher.Fetch(cmd.Context(), spec)
+	if err != nil {
+		return describeFetchError(err)
+	}
+
+	report, err := compare.CheckLabels(resource, labelsPath, required)
+	if err != nil {
+		return err
+	}
+
+	switch viper.GetString("format") {
+	case "json":
+		output, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Fprintln(cmd.OutOrStdout(), string(output))
+	default:
+		compare.PrintLabelReport(cmd.OutOrStdout(), report, name, compare.OutputOptions{Color: colors})
+	}
+
+	if violations := report.Violations(); violations > 0 {
+		return fmt.Errorf("%d required label(s) missing or empty", violations)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunLabelsCheck_Compliant(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe web-1": `{"name": "web-1", "labels": {"owner": "web-team", "cost-center": "1234"}}`,
+	}})
+
+	output, err := executeCommand(t, "labels-check", "compute instances", "web-1",
+		"--project1=proj", "--zone=us-central1-a", "--required=owner,cost-center")
+	if err != nil {
+		t.Fatalf("Expected compliant resource to pass, got: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "All required labels are set") {
+		t.Errorf("Expected compliance message, got:\n%s", output)
+	}
+}
+
+func TestRunLabelsCheck_MissingLabel(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe web-1": `{"name": "web-1", "labels": {"owner": "web-team"}}`,
+	}})
+
+	output, err := executeCommand(t, "labels-check", "compute instances", "web-1",
+		"--project1=proj", "--zone=us-central1-a", "--required=owner,cost-center")
+	if err == nil || !strings.Contains(err.Error(), "1 required label(s) missing or empty") {
+		t.Fatalf("Expected a missing label error, got %v", err)
+	}
+	if !strings.Contains(output, "cost-center <missing>") {
+		t.Errorf("Expected cost-center reported missing, got:\n%s", output)
+	}
+}
+
+func TestRunLabelsCheck_LocationFlags(t *testing.T) {
+	_, err := executeCommand(t, "labels-check", "storage buckets", "logs",
+		"--project1=proj", "--zone=us-central1-a", "--required=owner")
+	if err == nil || !strings.Contains(err.Error(), "storage buckets is not zonal or regional; remove --zone") {
+		t.Errorf("Expected an extra zone error, got %v", err)
+	}
+}
+
+func TestRunLabelsCheck_EmptyLabel(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"sql instances describe db-1": `{"name": "db-1", "settings": {"userLabels": {"owner": ""}}}`,
+	}})
+
+	output, err := executeCommand(t, "labels-check", "sql instances", "db-1",
+		"--project1=proj", "--required=owner", "--labels-path=settings.userLabels", "--format=json")
+	if err == nil {
+		t.Fatal("Expected an error for an empty label")
+	}
+	if !strings.Contains(output, `"empty": [`) || !strings.Contains(output, `"owner"`) {
+		t.Errorf("Expected owner reported empty, got:\n%s", output)
+	}
+}
+
+func TestRunLabelsCheck_FileSource(t *testing.T) {
+	runner := &fakeRunner{responses: map[string]string{}}
+	useFakeRunner(t, runner)
+
+	path := filepath.Join(t.TempDir(), "web-1.json")
+	if err := os.WriteFile(path, []byte(`{"labels": {"owner": "web-team"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := executeCommand(t, "labels-check", "compute instances", path, "--source=file", "--required=owner")
+	if err != nil {
+		t.Fatalf("Expected the file's labels to pass, got: %v\n%s", err, output)
+	}
+	if runner.callCount() != 0 {
+		t.Errorf("Expected no gcloud calls, got %d", runner.callCount())
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// locationKind describes which location flag a resource type is addressed by
+type locationKind string
+
+const (
+	locationZonal           locationKind = "zonal"
+	locationRegional        locationKind = "regional"
+	locationZonalOrRegional locationKind = "zonal or regional"
+	locationGlobal          locationKind = "global"
+	locationNone            locationKind = "none"
+)
+
+// resourceLocations maps common resource types to their location kind.
+// Types not listed here are not validated.
+var resourceLocations = map[string]locationKind{
+	"compute instances":      locationZonal,
+	"compute disks":          locationZonalOrRegional,
+	"compute subnetworks":    locationRegional,
+	"compute firewall-rules": locationGlobal,
+	"compute networks":       locationGlobal,
+	"container clusters":     locationZonalOrRegional,
+	"run services":           locationRegional,
+	"functions":              locationRegional,
+	"storage buckets":        locationNone,
+	"pubsub topics":          locationNone,
+	"pubsub subscriptions":   locationNone,
+	"sql instances":          locationNone,
+	"iam service-accounts":   locationNone,
+}
+
+// checkLocationFlags reports a helpful error when the location flags built for
+// resource <suffix> don't fit the resource type, e.g. a zonal type without a
+// zone. Unknown resource types are not checked.
+func checkLocationFlags(resourceType string, flags map[string]string, suffix string) error {
+	kind, ok := resourceLocations[resourceType]
+	if !ok {
+		return nil
+	}
+
+	switch kind {
+	case locationZonal:
+		if flags["zone"] == "" {
+			return fmt.Errorf("%s is zonal; please provide --zone%s", resourceType, suffix)
+		}
+	case locationRegional:
+		if flags["region"] == "" {
+			return fmt.Errorf("%s is regional; please provide --region%s", resourceType, suffix)
+		}
+	case locationZonalOrRegional:
+		if flags["zone"] == "" && flags["region"] == "" && flags["location"] == "" {
+			return fmt.Errorf("%s is zonal or regional; please provide --zone%s, --region%s or --location%s",
+				resourceType, suffix, suffix, suffix)
+		}
+	case locationGlobal, locationNone:
+		var extra []string
+		for _, key := range []string{"zone", "region", "location"} {
+			if flags[key] != "" {
+				extra = append(extra, "--"+key+suffix)
+			}
+		}
+		if len(extra) > 0 {
+			description := "global"
+			if kind == locationNone {
+				description = "not zonal or regional"
+			}
+			return fmt.Errorf("%s is %s; remove %s", resourceType, description, strings.Join(extra, ", "))
+		}
+	}
+
+	return nil
+}
+
+// addLocationFlags registers the --zone, --region, --location and
+// --configuration flags of commands that address resources in a single
+// location. what names the resources in the help text, e.g. "resource".
+func addLocationFlags(cmd *cobra.Command, what string) {
+	cmd.Flags().String("zone", "", fmt.Sprintf("Zone of the %s (for zonal resources)", what))
+	cmd.Flags().String("region", "", fmt.Sprintf("Region of the %s (for regional resources)", what))
+	cmd.Flags().String("location", "", fmt.Sprintf("Location of the %s (alternative to zone/region)", what))
+	cmd.Flags().String("configuration", "", fmt.Sprintf("gcloud configuration to fetch the %s with", what))
+}
+
+// locationFlags returns the values of the flags added by addLocationFlags,
+// keyed by gcloud flag name
+func locationFlags(cmd *cobra.Command) map[string]string {
+	flags := make(map[string]string)
+	for _, key := range []string{"zone", "region", "location", "configuration"} {
+		if value, _ := cmd.Flags().GetString(key); value != "" {
+			flags[key] = value
+		}
+	}
+	return flags
+}
+
+// checkedLocationFlags returns locationFlags after checking, when the
+// resource is fetched with gcloud, that they fit resourceType
+func checkedLocationFlags(cmd *cobra.Command, resourceType string) (map[string]string, error) {
+	flags := locationFlags(cmd)
+	if resourceSource(cmd) == sourceGcloud {
+		if err := checkLocationFlags(resourceType, flags, ""); err != nil {
+			return nil, err
+		}
+	}
+	return flags, nil
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import "testing"
+
+func TestCheckLocationFlags(t *testing.T) {
+	tests := []struct {
+		name         string
+		resourceType string
+		flags        map[string]string
+		wantErr      string
+	}{
+		{"zonal without zone", "compute instances", map[string]string{}, "compute instances is zonal; please provide --zone1"},
+		{"zonal with zone", "compute instances", map[string]string{"zone": "us-central1-a"}, ""},
+		{"regional without region", "run services", map[string]string{"zone": "us-central1-a"}, "run services is regional; please provide --region1"},
+		{"zonal or regional with location", "container clusters", map[string]string{"location": "us-central1"}, ""},
+		{"zonal or regional without any", "container clusters", map[string]string{}, "container clusters is zonal or regional; please provide --zone1, --region1 or --location1"},
+		{"global with zone", "compute firewall-rules", map[string]string{"zone": "us-central1-a"}, "compute firewall-rules is global; remove --zone1"},
+		{"no location with region", "storage buckets", map[string]string{"region": "us-central1"}, "storage buckets is not zonal or regional; remove --region1"},
+		{"no location without flags", "storage buckets", map[string]string{}, ""},
+		{"unknown type skips validation", "compute routers", map[string]string{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkLocationFlags(tt.resourceType, tt.flags, "1")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Pair is one row of a pairs file: two resources of the same type to compare.
+// Empty fields fall back to the matching command-line flags.
+type Pair struct {
+	Type           string `yaml:"type"`
+	Name1          string `yaml:"name1"`
+	Name2          string `yaml:"name2"`
+	Project1       string `yaml:"project1"`
+	Project2       string `yaml:"project2"`
+	Zone1          string `yaml:"zone1"`
+	Zone2          string `yaml:"zone2"`
+	Region1        string `yaml:"region1"`
+	Region2        string `yaml:"region2"`
+	Location1      string `yaml:"location1"`
+	Location2      string `yaml:"location2"`
+	Configuration1 string `yaml:"configuration1"`
+	Configuration2 string `yaml:"configuration2"`
+	Organization1  string `yaml:"organization1"`
+	Organization2  string `yaml:"organization2"`
+	Folder1        string `yaml:"folder1"`
+	Folder2        string `yaml:"folder2"`
+}
+
+// columns maps each pairs file column name to its field
+func (p *Pair) columns() map[string]*string {
+	return map[string]*string{
+		"type":           &p.Type,
+		"name1":          &p.Name1,
+		"name2":          &p.Name2,
+		"project1":       &p.Project1,
+		"project2":       &p.Project2,
+		"zone1":          &p.Zone1,
+		"zone2":          &p.Zone2,
+		"region1":        &p.Region1,
+		"region2":        &p.Region2,
+		"location1":      &p.Location1,
+		"location2":      &p.Location2,
+		"configuration1": &p.Configuration1,
+		"configuration2": &p.Configuration2,
+		"organization1":  &p.Organization1,
+		"organization2":  &p.Organization2,
+		"folder1":        &p.Folder1,
+		"folder2":        &p.Folder2,
+	}
+}
+
+// validate checks that the pair names a type and both resources
+func (p *Pair) validate() error {
+	columns := p.columns()
+	for _, required := range []string{"type", "name1", "name2"} {
+		if *columns[required] == "" {
+			return fmt.Errorf("%s is required", required)
+		}
+	}
+	return nil
+}
+
+// LoadPairs reads resource pairs from a CSV file (by .csv extension, with a
+// header row of column names) or a YAML list, and validates every row
+func LoadPairs(path string) ([]Pair, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var pairs []Pair
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		pairs, err = parsePairsCSV(data)
+	} else {
+		pairs, err = parsePairsYAML(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no pairs found in %s", path)
+	}
+	for i := range pairs {
+		if err := pairs[i].validate(); err != nil {
+			return nil, fmt.Errorf("pair %d: %w", i+1, err)
+		}
+	}
+	return pairs, nil
+}
+
+func parsePairsYAML(data []byte) ([]Pair, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var pairs []Pair
+	if err := decoder.Decode(&pairs); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid pairs file: %w", err)
+	}
+	return pairs, nil
+}
+
+func parsePairsCSV(data []byte) ([]Pair, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid pairs file: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	known := (&Pair{}).columns()
+	for _, column := range header {
+		if _, ok := known[strings.TrimSpace(column)]; !ok {
+			return nil, fmt.Errorf("unknown column %q in pairs file header", column)
+		}
+	}
+
+	pairs := make([]Pair, 0, len(records)-1)
+	for _, record := range records[1:] {
+		var pair Pair
+		columns := pair.columns()
+		for i, column := range header {
+			*columns[strings.TrimSpace(column)] = strings.TrimSpace(record[i])
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
+
+// spec resolves the pair into the resources to fetch, filling empty fields
+// from the command-line flags and defaultProject
+func (p *Pair) spec(cmd *cobra.Command, defaultProject string) resourceSpec {
+	project1 := p.Project1
+	if project1 == "" {
+		project1 = defaultProject
+	}
+	project2 := p.Project2
+	if project2 == "" {
+		project2 = viper.GetString("project2")
+	}
+	if project2 == "" {
+		project2 = project1
+	}
+
+	lookup := p.lookup(cmd)
+	return resourceSpec{
+		resourceType: p.Type,
+		name1:        p.Name1,
+		name2:        p.Name2,
+		project1:     project1,
+		project2:     project2,
+		flags1:       buildResourceFlags(lookup, "1"),
+		flags2:       buildResourceFlags(lookup, "2"),
+	}
+}
+
+// lookup returns a flag lookup that reads the pair's fields, falling back to
+// the command-line flags for empty ones
+func (p *Pair) lookup(cmd *cobra.Command) func(name string) string {
+	columns := p.columns()
+	fallback := flagLookup(cmd)
+	return func(name string) string {
+		if value := *columns[name]; value != "" {
+			return value
+		}
+		return fallback(name)
+	}
+}
+
+// needsProject reports whether the first resource of the pair is fetched from
+// a project but names none, so the default project must be resolved
+func (p *Pair) needsProject(cmd *cobra.Command) bool {
+	return p.Project1 == "" && needsProject(cmd, buildResourceFlags(p.lookup(cmd), "1"))
+}
+
+// runPairs compares every pair in the pairs file, printing a labeled section
+// per pair followed by an overall summary
+func runPairs(cmd *cobra.Command, path string) error {
+	for _, flag := range []string{"watch", "save-baseline", "dump-resources", "output-patch", "expect-diff", "url1", "url2", "interactive", "metrics-file", "changed-fields-file", "label1", "label2"} {
+		if cmd.Flags().Changed(flag) {
+			return fmt.Errorf("--%s cannot be used with --pairs-file", flag)
+		}
+	}
+
+	pairs, err := LoadPairs(path)
+	if err != nil {
+		return fmt.Errorf("failed to load pairs file: %w", err)
+	}
+
+	// Only look up a default project for pairs that need one, once per
+	// gcloud configuration they are fetched with
+	defaultProjects := make(map[string]string)
+	specs := make([]resourceSpec, len(pairs))
+	for i := range pairs {
+		var defaultProject string
+		if pairs[i].needsProject(cmd) {
+			configuration := buildResourceFlags(pairs[i].lookup(cmd), "1")["configuration"]
+			project, ok := defaultProjects[configuration]
+			if !ok {
+				if project, err = resolveProject1(cmd, configuration); err != nil {
+					return fmt.Errorf("pair %d: %w", i+1, err)
+				}
+				defaultProjects[configuration] = project
+			}
+			defaultProject = project
+		}
+		specs[i] = pairs[i].spec(cmd, defaultProject)
+	}
+
+	out := cmd.OutOrStdout()
+	var differing, identical, failed int
+	for i, spec := range specs {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "=== [%d/%d] %s: %s <-> %s ===\n",
+			i+1, len(specs), spec.resourceType, spec.name1, spec.name2)
+
+		diff, err := compareResources(cmd, spec)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Fprintf(cmd.ErrOrStderr(), "Error: pair %d: %v\n", i+1, err)
+		case diff == nil:
+			// Dry run: nothing was compared
+		case diff.IsEmpty():
+			identical++
+		default:
+			differing++
+		}
+	}
+
+	if viper.GetBool("dry-run") {
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Summary: %d pair(s) compared, %d with differences, %d identical, %d failed\n",
+		len(specs), differing, identical, failed)
+
+	if failed > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d of %d pair(s) failed", failed, len(specs))
+	}
+	return nil
+}
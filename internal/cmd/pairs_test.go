@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePairsFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write pairs file: %v", err)
+	}
+	return path
+}
+
+func TestLoadPairs_YAML(t *testing.T) {
+	path := writePairsFile(t, "pairs.yaml", `
+- type: compute instances
+  name1: vm-1
+  name2: vm-2
+  zone1: us-central1-a
+- type: storage buckets
+  name1: bucket-dev
+  name2: bucket-prod
+  project1: dev
+  project2: prod
+`)
+
+	pairs, err := LoadPairs(path)
+	if err != nil {
+		t.Fatalf("LoadPairs failed: %v", err)
+	}
+	if len(pairs) != 2 {
+		t.Fatalf("Expected 2 pairs, got %d", len(pairs))
+	}
+	if pairs[0].Zone1 != "us-central1-a" || pairs[1].Project2 != "prod" {
+		t.Errorf("Unexpected pairs: %+v", pairs)
+	}
+}
+
+func TestLoadPairs_CSV(t *testing.T) {
+	path := writePairsFile(t, "pairs.csv", `type,name1,name2,region1
+run services,svc-a,svc-b,us-central1
+storage buckets,bucket-1,bucket-2,
+`)
+
+	pairs, err := LoadPairs(path)
+	if err != nil {
+		t.Fatalf("LoadPairs failed: %v", err)
+	}
+	if len(pairs) != 2 {
+		t.Fatalf("Expected 2 pairs, got %d", len(pairs))
+	}
+	if pairs[0].Type != "run services" || pairs[0].Region1 != "us-central1" || pairs[1].Region1 != "" {
+		t.Errorf("Unexpected pairs: %+v", pairs)
+	}
+}
+
+func TestLoadPairs_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{"missing name", "pairs.yaml", "- type: storage buckets\n  name1: a\n", "pair 1: name2 is required"},
+		{"unknown yaml field", "pairs.yaml", "- type: storage buckets\n  name1: a\n  name2: b\n  zone: x\n", "zone"},
+		{"unknown csv column", "pairs.csv", "type,name1,name2,zone\n", `unknown column "zone"`},
+		{"empty", "pairs.yaml", "", "no pairs found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadPairs(writePairsFile(t, tt.file, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRunResource_PairsFile(t *testing.T) {
+	runner := &fakeRunner{responses: map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2"}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-4"}`,
+	}}
+	useFakeRunner(t, runner)
+
+	path := writePairsFile(t, "pairs.yaml", `
+- type: compute instances
+  name1: vm-1
+  name2: vm-2
+  zone1: us-central1-a
+  zone2: us-east1-b
+- type: storage buckets
+  name1: bucket-dev
+  name2: bucket-prod
+  project2: prod
+`)
+
+	output, err := executeCommand(t, "resource", "--pairs-file="+path, "--project1=proj")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if runner.callCount() != 4 {
+		t.Errorf("Expected 4 gcloud invocations for 2 pairs, got %d", runner.callCount())
+	}
+
+	expectedCalls := []string{
+		"compute instances describe vm-1 --project=proj --zone=us-central1-a",
+		"compute instances describe vm-2 --project=proj --zone=us-east1-b",
+		"storage buckets describe bucket-dev --project=proj",
+		"storage buckets describe bucket-prod --project=prod",
+	}
+	var calls []string
+	for _, call := range runner.calls {
+		calls = append(calls, strings.Join(call[1:], " "))
+	}
+	for _, expected := range expectedCalls {
+		found := false
+		for _, call := range calls {
+			if strings.HasPrefix(call, expected) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected a gcloud call %q, got %v", expected, calls)
+		}
+	}
+
+	for _, label := range []string{
+		"=== [1/2] compute instances: vm-1 <-> vm-2 ===",
+		"=== [2/2] storage buckets: bucket-dev <-> bucket-prod ===",
+		"Summary: 2 pair(s) compared, 1 with differences, 1 identical, 0 failed",
+	} {
+		if !strings.Contains(output, label) {
+			t.Errorf("Expected output to contain %q, got:\n%s", label, output)
+		}
+	}
+}
+
+func TestRunResource_PairsFileRejectsArgs(t *testing.T) {
+	path := writePairsFile(t, "pairs.yaml", "- type: storage buckets\n  name1: a\n  name2: b\n")
+
+	_, err := executeCommand(t, "resource", "storage buckets", "a", "b", "--pairs-file="+path, "--project1=proj")
+	if err == nil {
+		t.Fatal("Expected an error when passing arguments with --pairs-file")
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+// provenanceEnvelope is the --with-provenance json output: the diff together
+// with a record of how it was produced
+type provenanceEnvelope struct {
+	Provenance provenance  `json:"provenance"`
+	Diff       interface{} `json:"diff"`
+}
+
+// provenance records what produced a diff, so a saved diff can be reviewed
+// later without knowing the command line that made it
+type provenance struct {
+	Version string `json:"version"`
+
+	// Commands are the fetch commands run, in the form --dry-run prints
+	Commands []string `json:"commands"`
+
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+
+	Config provenanceConfig `json:"config"`
+}
+
+// provenanceConfig is the part of the effective config that decides which
+// fields were compared
+type provenanceConfig struct {
+	IgnoreFields    []string `json:"ignore_fields"`
+	IgnorePatterns  []string `json:"ignore_patterns"`
+	IgnoreUnderPath []string `json:"ignore_under_path,omitempty"`
+	Unignored       []string `json:"unignored,omitempty"`
+	OnlyFields      []string `json:"only_fields,omitempty"`
+}
+
+func newProvenanceConfig(cfg *config.Config) provenanceConfig {
+	return provenanceConfig{
+		IgnoreFields:    cfg.IgnoreFields,
+		IgnorePatterns:  cfg.IgnorePatterns,
+		IgnoreUnderPath: cfg.IgnoreUnderPath,
+		Unignored:       cfg.Unignored,
+		OnlyFields:      cfg.OnlyFields,
+	}
+}
@@ -3,14 +3,19 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/tflynn3/gcdiff/internal/compare"
 	"github.com/tflynn3/gcdiff/internal/config"
 	"github.com/tflynn3/gcdiff/internal/gcp"
+	"golang.org/x/sync/errgroup"
 )
 
 var resourceCmd = &cobra.Command{
@@ -41,9 +46,114 @@ Examples:
   gcdiff resource "pubsub subscriptions" sub-1 sub-2 --project1=proj --iam
 
   # GKE clusters (from: gcloud container clusters describe)
-  gcdiff resource "container clusters" cluster-1 cluster-2 --project1=proj --zone1=us-central1-a`,
-	Args: cobra.ExactArgs(3),
-	RunE: runResource,
+  gcdiff resource "container clusters" cluster-1 cluster-2 --project1=proj --zone1=us-central1-a
+
+  # Org policies of two folders (from: gcloud resource-manager org-policies describe)
+  gcdiff resource "resource-manager org-policies" compute.vmExternalIpAccess compute.vmExternalIpAccess --folder1=123 --folder2=456
+
+  # Compare every pair listed in a YAML or CSV file
+  gcdiff resource --pairs-file=pairs.yaml --project1=proj`,
+	Args:              resourceArgs,
+	ValidArgsFunction: completeResourceType,
+	RunE:              runResource,
+}
+
+// commonResourceTypes are suggested when completing the resource type argument
+var commonResourceTypes = []string{
+	"compute instances",
+	"compute disks",
+	"compute firewall-rules",
+	"compute networks",
+	"compute subnetworks",
+	"compute instance-templates",
+	"storage buckets",
+	"run services",
+	"container clusters",
+	"sql instances",
+	"pubsub topics",
+	"pubsub subscriptions",
+	"iam service-accounts",
+	"functions",
+}
+
+// completeResourceType suggests common resource types for the first argument
+func completeResourceType(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return commonResourceTypes, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// resourceArgs requires the type and both names unless they come from a
+// pairs file
+func resourceArgs(cmd *cobra.Command, args []string) error {
+	if pairsFile, _ := cmd.Flags().GetString("pairs-file"); pairsFile != "" {
+		return cobra.NoArgs(cmd, args)
+	}
+	return cobra.ExactArgs(3)(cmd, args)
+}
+
+// newFetcher creates the fetcher used to retrieve resources; tests replace it
+// to avoid shelling out to gcloud
+var newFetcher = gcp.NewResourceFetcher
+
+// newGcloudFetcher creates a fetcher that runs the gcloud executable chosen by
+// --gcloud-path
+func newGcloudFetcher() *gcp.GcloudFetcher {
+	fetcher := newFetcher()
+	fetcher.SetBinary(resolveGcloudPath(gcloudPath))
+	return &gcp.GcloudFetcher{ResourceFetcher: fetcher}
+}
+
+// Values of --source
+const (
+	sourceGcloud = "gcloud"
+	sourceFile   = "file"
+)
+
+// sourceFetcher returns the fetch backend selected by --source; tests
+// replace it
+var sourceFetcher = func(cmd *cobra.Command) (gcp.Fetcher, error) {
+	switch source := resourceSource(cmd); source {
+	case sourceGcloud:
+		return newGcloudFetcher(), nil
+	case sourceFile:
+		return &gcp.FileFetcher{Stdin: cmd.InOrStdin()}, nil
+	default:
+		return nil, fmt.Errorf("unknown --source %q (expected: gcloud, file)", source)
+	}
+}
+
+// sideFetchers returns the fetcher of each side: a URLFetcher for a side
+// given --url1 or --url2, and fetcher otherwise
+func sideFetchers(cmd *cobra.Command, fetcher gcp.Fetcher, specs [2]gcp.ResourceSpec) [2]gcp.Fetcher {
+	timeout, _ := cmd.Flags().GetDuration("url-timeout")
+	fetchers := [2]gcp.Fetcher{fetcher, fetcher}
+	for i, spec := range specs {
+		if spec.URL != "" {
+			fetchers[i] = &gcp.URLFetcher{Timeout: timeout}
+		}
+	}
+	return fetchers
+}
+
+// addSourceFlag registers --source on a command that fetches resources
+// through sourceFetcher
+func addSourceFlag(cmd *cobra.Command) {
+	cmd.Flags().String("source", sourceGcloud, "Where to read resources from: gcloud, or file (the names are JSON or YAML file paths, - for stdin)")
+}
+
+// resourceSource returns the --source value
+func resourceSource(cmd *cobra.Command) string {
+	source, _ := cmd.Flags().GetString("source")
+	return source
+}
+
+// needsProject reports whether a resource with flags is fetched from a
+// project, so one must be resolved. Files and organization or folder scoped
+// resources have none.
+func needsProject(cmd *cobra.Command, flags map[string]string) bool {
+	return resourceSource(cmd) == sourceGcloud && !gcp.HasParentScope(flags)
 }
 
 func init() {
@@ -57,164 +167,853 @@ func init() {
 	resourceCmd.Flags().String("location1", "", "Location for first resource (alternative to zone/region)")
 	resourceCmd.Flags().String("location2", "", "Location for second resource (defaults to location1)")
 
+	// Parent scope flags, for resources scoped by organization or folder
+	// instead of project (e.g. org policies)
+	resourceCmd.Flags().String("organization1", "", "Organization ID for first resource (replaces --project1)")
+	resourceCmd.Flags().String("organization2", "", "Organization ID for second resource (defaults to organization1)")
+	resourceCmd.Flags().String("folder1", "", "Folder ID for first resource (replaces --project1)")
+	resourceCmd.Flags().String("folder2", "", "Folder ID for second resource (defaults to folder1)")
+
+	// gcloud configuration flags, for comparing across accounts
+	resourceCmd.Flags().String("configuration1", "", "gcloud configuration for first resource")
+	resourceCmd.Flags().String("configuration2", "", "gcloud configuration for second resource (defaults to configuration1)")
+
+	// Display name flags, for telling resources with the same name apart
+	resourceCmd.Flags().String("label1", "", "Name shown for the first resource in diff output (e.g. \"prod (us-central1)\"; defaults to its name)")
+	resourceCmd.Flags().String("label2", "", "Name shown for the second resource in diff output (defaults to its name)")
+
 	// IAM policy flag
 	resourceCmd.Flags().Bool("iam", false, "Include IAM policy bindings in comparison (fetches both resource and IAM policy)")
+	resourceCmd.Flags().Bool("iam-strict", false, "Fail if an IAM policy can't be fetched instead of leaving IAM out of the comparison")
+
+	// Debugging flag
+	resourceCmd.Flags().String("dump-resources", "", "Directory to write the fetched resource1.json and resource2.json to")
+	resourceCmd.Flags().String("output-patch", "", "Write a unified diff that turns the fetched resource1.json into resource2.json (the other way with --reverse), for patch -p1 or git apply")
+
+	// Reference resource flags
+	resourceCmd.Flags().String("reference", "", "Name of a third, known-good resource whose value of each changed field is shown for context")
+	resourceCmd.Flags().String("reference-type", "", "Resource type of --reference (defaults to the compared type)")
+	resourceCmd.Flags().String("reference-project", "", "Project of --reference (defaults to the first resource's project; location flags are shared with it)")
+
+	// Audit flag
+	resourceCmd.Flags().Bool("with-provenance", false, "With --format=json, wrap the diff in a record of the commands run, timestamps, gcdiff version and effective ignore rules")
+
+	// Automation flag
+	resourceCmd.Flags().String("on-diff", "", "Run this shell command with the diff as JSON on stdin when the resources differ")
+
+	// Monitoring flag
+	resourceCmd.Flags().String("metrics-file", "", "Write difference counts to this file in Prometheus text format (e.g. for a Pushgateway)")
+
+	// Baseline flags
+	resourceCmd.Flags().String("save-baseline", "", "Record the current differences as accepted in this file")
+	resourceCmd.Flags().String("baseline", "", "Suppress differences recorded as accepted in this file (see --save-baseline)")
+	resourceCmd.Flags().String("expect-diff", "", "Fail unless the differences exactly match this diff saved with --format=json (or a baseline file)")
+	resourceCmd.Flags().String("changed-fields-file", "", "Fail if a field not listed in this file (one path per line) changed, or a listed field did not")
+
+	// Ignore override flag
+	resourceCmd.Flags().StringArray("unignore", nil, "Compare this field path despite the ignore rules that hide it, keeping every other rule (repeatable)")
+
+	// Strict mode flag
+	resourceCmd.Flags().Bool("strict", false, "Report how many differences ignore rules hide")
+	resourceCmd.Flags().Bool("fail-on-suppressed", false, "With --strict, fail if ignore rules hide any differences")
+	resourceCmd.Flags().Bool("parallel-compare", false, "Compare the top-level fields of large resources concurrently")
+
+	// Presence check flag
+	resourceCmd.Flags().StringArray("require-field", nil, "Fail if this field path (e.g. scheduling.preemptible or disks[0].type) is missing from either resource (repeatable)")
+
+	// Field list flag
+	resourceCmd.Flags().String("fields-file", "", "Compare only the field paths listed in this file (one per line, e.g. disks[0].type), ignoring everything else")
+
+	// Value rendering flag
+	resourceCmd.Flags().Int("context-lines", -1, "Show modified object and array values as a diff with this many unchanged lines of context (-1 shows both values in full)")
+	resourceCmd.Flags().Int("limit", 0, "Only render the first N differences, followed by a count of the rest (0 renders all)")
+	resourceCmd.Flags().StringSlice("show", nil, "Only render these kinds of change (comma-separated: added, removed, modified); the summary still counts all")
+	resourceCmd.Flags().Bool("compact-arrays", false, "Mark the runs of unchanged elements between an array's changed elements")
+	resourceCmd.Flags().Bool("interactive", false, "Browse the diff as a collapsible tree (falls back to normal output when not in a terminal)")
+	resourceCmd.Flags().Bool("quiet-equal", false, "Print nothing when the resources are identical, e.g. for cron drift checks")
+	resourceCmd.Flags().Int("json-max-depth", 0, "With --format=json, replace subtrees nested deeper than this with a count of their changes (0 keeps the whole tree)")
+
+	// Derived value flag
+	resourceCmd.Flags().StringArray("select", nil, "Add a computed field to both resources before diffing, e.g. diskCount=len(disks) (functions: len, sum, keys; repeatable)")
+
+	// Watch mode flag
+	resourceCmd.Flags().Duration("watch", 0, "Re-fetch and re-diff on this interval (e.g. 30s) until interrupted")
+
+	// Fetch backend flag
+	addSourceFlag(resourceCmd)
+	resourceCmd.Flags().String("url1", "", "Download the first resource's JSON from this URL instead of running gcloud")
+	resourceCmd.Flags().String("url2", "", "Download the second resource's JSON from this URL instead of running gcloud")
+	resourceCmd.Flags().Duration("url-timeout", gcp.DefaultURLTimeout, "How long to wait for each --url1/--url2 download")
+
+	// Batch flag
+	resourceCmd.Flags().String("pairs-file", "", "YAML or CSV file listing resource pairs to compare instead of the arguments")
+}
+
+// resourceSpec identifies the two resources a comparison fetches
+type resourceSpec struct {
+	resourceType       string
+	name1, name2       string
+	project1, project2 string
+	flags1, flags2     map[string]string
+	url1, url2         string
+}
+
+// sides returns the fetch specs of the two resources
+func (s resourceSpec) sides() [2]gcp.ResourceSpec {
+	return [2]gcp.ResourceSpec{
+		{Type: s.resourceType, Name: s.name1, Project: s.project1, Flags: s.flags1, URL: s.url1},
+		{Type: s.resourceType, Name: s.name2, Project: s.project2, Flags: s.flags2, URL: s.url2},
+	}
 }
 
 func runResource(cmd *cobra.Command, args []string) error {
-	resourceTypeStr := args[0]
-	name1 := args[1]
-	name2 := args[2]
+	if pairsFile, _ := cmd.Flags().GetString("pairs-file"); pairsFile != "" {
+		return runPairs(cmd, pairsFile)
+	}
 
+	lookup := flagLookup(cmd)
+	flags1 := buildResourceFlags(lookup, "1")
+	flags2 := buildResourceFlags(lookup, "2")
+	url1, _ := cmd.Flags().GetString("url1")
+	url2, _ := cmd.Flags().GetString("url2")
+
+	// A downloaded resource needs no project
 	project1 := viper.GetString("project1")
+	if url1 == "" && needsProject(cmd, flags1) {
+		var err error
+		if project1, err = resolveProject1(cmd, flags1["configuration"]); err != nil {
+			return err
+		}
+	}
 	project2 := viper.GetString("project2")
 	if project2 == "" {
 		project2 = project1
 	}
 
-	if project1 == "" {
-		return fmt.Errorf("--project1 is required")
+	_, err := compareResources(cmd, resourceSpec{
+		resourceType: args[0],
+		name1:        args[1],
+		name2:        args[2],
+		project1:     project1,
+		project2:     project2,
+		flags1:       flags1,
+		flags2:       flags2,
+		url1:         url1,
+		url2:         url2,
+	})
+	return err
+}
+
+// resolveProject1 returns --project1, falling back to the default project of
+// the gcloud configuration the first resource is fetched with: configuration,
+// or the active one when it is ""
+func resolveProject1(cmd *cobra.Command, configuration string) (string, error) {
+	if project := viper.GetString("project1"); project != "" {
+		return project, nil
 	}
 
-	includeIAM, _ := cmd.Flags().GetBool("iam")
+	described := "the active gcloud configuration"
+	if configuration != "" {
+		described = fmt.Sprintf("gcloud configuration %s", configuration)
+	}
+	project, err := newGcloudFetcher().DefaultProject(cmd.Context(), configuration)
+	if err != nil {
+		return "", fmt.Errorf("--project1 is required and the default project of %s could not be read: %w", described, err)
+	}
+	if project == "" {
+		return "", fmt.Errorf("--project1 is required (no default project is set in %s)", described)
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Using project %s from %s\n", project, described)
+	return project, nil
+}
 
-	ctx := context.Background()
-	fetcher := gcp.NewResourceFetcher()
+// resolveMaxConcurrency returns --max-concurrency, which must be at least 1
+func resolveMaxConcurrency() (int, error) {
+	maxConcurrency := viper.GetInt("max-concurrency")
+	if maxConcurrency < 1 {
+		return 0, fmt.Errorf("--max-concurrency must be at least 1, got %d", maxConcurrency)
+	}
+	return maxConcurrency, nil
+}
 
-	// Build flags for resource 1
-	flags1 := buildResourceFlags(cmd, "1")
+// loadCompareConfig loads the config used to diff resources, falling back to
+// the defaults with a warning, and applies the global flags that override it
+func loadCompareConfig(cmd *cobra.Command) (*config.Config, error) {
+	cfg, err := config.Load(viper.ConfigFileUsed())
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: could not load config: %v\n", err)
+		cfg = config.Default()
+	}
 
-	// Build flags for resource 2
-	flags2 := buildResourceFlags(cmd, "2")
+	if mode := viper.GetString("array-mode"); mode != "" {
+		cfg.ArrayMode = mode
+	}
+	if cfg.ArrayMode != "" && cfg.ArrayMode != config.ArrayModeGranular && cfg.ArrayMode != config.ArrayModeWhole {
+		return nil, fmt.Errorf("unknown array mode %q (expected: granular, whole)", cfg.ArrayMode)
+	}
+	if err := compare.CheckTransforms(cfg.Transforms); err != nil {
+		return nil, err
+	}
+	if viper.GetBool("structure-only") {
+		cfg.StructureOnly = true
+	}
+	if viper.GetBool("show-context") {
+		cfg.ShowContext = true
+	}
+	if schemaPath := viper.GetString("schema"); schemaPath != "" {
+		schema, err := config.LoadSchema(schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load schema: %w", err)
+		}
+		if cfg.Schema == nil {
+			cfg.Schema = make(map[string]string)
+		}
+		for fieldPath, fieldType := range schema {
+			cfg.Schema[fieldPath] = fieldType
+		}
+	}
+	return cfg, nil
+}
+
+// resourceNameFields returns the resource-specific identifiers of a and b
+// when they live in the same project or parent, where they always differ
+func resourceNameFields(a, b gcp.ResourceSpec) []string {
+	if a.Scope() != b.Scope() {
+		return nil
+	}
+	return []string{"name", "self_link", "selfLink"}
+}
+
+// ignoreResourceNames ignores the fields resourceNameFields returns for a and b
+func ignoreResourceNames(cfg *config.Config, a, b gcp.ResourceSpec) {
+	cfg.IgnoreFields = append(cfg.IgnoreFields, resourceNameFields(a, b)...)
+}
 
-	// Build gcloud commands
-	gcloudCmd1 := buildGcloudCommand(resourceTypeStr, name1, project1, flags1)
-	gcloudCmd2 := buildGcloudCommand(resourceTypeStr, name2, project2, flags2)
+// compareResources fetches, diffs and prints the resources named by spec. It
+// returns the diff that was printed, or nil in dry-run and watch mode.
+func compareResources(cmd *cobra.Command, spec resourceSpec) (*compare.Diff, error) {
+	resourceTypeStr := spec.resourceType
+	name1, name2 := spec.name1, spec.name2
+	flags1, flags2 := spec.flags1, spec.flags2
 
-	// Fetch resources
-	fmt.Fprintf(cmd.OutOrStderr(), "Fetching resource with: gcloud %s...\n", gcloudCmd1)
-	resource1, err := fetcher.FetchResourceGeneric(ctx, gcloudCmd1)
+	groupBy := viper.GetString("group-by")
+	if groupBy != "" && groupBy != "section" {
+		return nil, fmt.Errorf("unknown --group-by value %q (expected: section)", groupBy)
+	}
+
+	showNames, _ := cmd.Flags().GetStringSlice("show")
+	showTypes, err := compare.ParseChangeTypes(showNames)
 	if err != nil {
-		return fmt.Errorf("failed to fetch resource: %w", err)
+		return nil, fmt.Errorf("invalid --show: %w", err)
 	}
 
-	fmt.Fprintf(cmd.OutOrStderr(), "Fetching resource with: gcloud %s...\n", gcloudCmd2)
-	resource2, err := fetcher.FetchResourceGeneric(ctx, gcloudCmd2)
+	colors, err := compare.ParseColorMode(viper.GetString("color"))
 	if err != nil {
-		return fmt.Errorf("failed to fetch resource: %w", err)
+		return nil, err
 	}
 
-	// If --iam flag is set, also fetch IAM policies and merge them
-	if includeIAM {
-		iamCmd1 := buildGcloudIAMCommand(resourceTypeStr, name1, project1, flags1)
-		iamCmd2 := buildGcloudIAMCommand(resourceTypeStr, name2, project2, flags2)
+	maxConcurrency, err := resolveMaxConcurrency()
+	if err != nil {
+		return nil, err
+	}
 
-		fmt.Fprintf(cmd.OutOrStderr(), "Fetching IAM policy with: gcloud %s...\n", iamCmd1)
-		iamPolicy1, err := fetcher.FetchResourceGeneric(ctx, iamCmd1)
-		if err != nil {
-			fmt.Fprintf(cmd.OutOrStderr(), "Warning: could not fetch IAM policy for %s: %v\n", name1, err)
-		} else {
-			resource1["iamPolicy"] = iamPolicy1
+	includeIAM, _ := cmd.Flags().GetBool("iam")
+
+	interactive, _ := cmd.Flags().GetBool("interactive")
+	if format := viper.GetString("format"); interactive && format != "diff" {
+		return nil, fmt.Errorf("--interactive cannot be used with --format=%s", format)
+	}
+	withProvenance, _ := cmd.Flags().GetBool("with-provenance")
+	if format := viper.GetString("format"); withProvenance && format != "json" && format != "json-full" {
+		return nil, fmt.Errorf("--with-provenance requires --format=json or --format=json-full")
+	}
+
+	fetcher, err := sourceFetcher(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if source := resourceSource(cmd); source == sourceFile {
+		if includeIAM {
+			return nil, fmt.Errorf("--iam cannot be used with --source=%s", source)
+		}
+		if name1 == gcp.StdinName && name2 == gcp.StdinName {
+			return nil, fmt.Errorf("only one resource can be read from stdin")
 		}
+		if spec.url1 != "" || spec.url2 != "" {
+			return nil, fmt.Errorf("--url1 and --url2 cannot be used with --source=%s", source)
+		}
+	}
+	if includeIAM && (spec.url1 != "" || spec.url2 != "") {
+		return nil, fmt.Errorf("--iam cannot be used with --url1 or --url2")
+	}
 
-		fmt.Fprintf(cmd.OutOrStderr(), "Fetching IAM policy with: gcloud %s...\n", iamCmd2)
-		iamPolicy2, err := fetcher.FetchResourceGeneric(ctx, iamCmd2)
+	selectExprs, _ := cmd.Flags().GetStringArray("select")
+	selections := make([]compare.Selection, 0, len(selectExprs))
+	for _, expr := range selectExprs {
+		selection, err := compare.ParseSelection(expr)
 		if err != nil {
-			fmt.Fprintf(cmd.OutOrStderr(), "Warning: could not fetch IAM policy for %s: %v\n", name2, err)
-		} else {
-			resource2["iamPolicy"] = iamPolicy2
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+
+	// Only gcloud needs to be told where a resource lives
+	if resourceSource(cmd) == sourceGcloud {
+		if spec.url1 == "" {
+			if err := checkLocationFlags(resourceTypeStr, flags1, "1"); err != nil {
+				return nil, err
+			}
 		}
+		if spec.url2 == "" {
+			if err := checkLocationFlags(resourceTypeStr, flags2, "2"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	specs := spec.sides()
+	fetchers := sideFetchers(cmd, fetcher, specs)
+	iamSpecs := specs
+	for i := range iamSpecs {
+		iamSpecs[i].IAMPolicy = true
+	}
+
+	// The reference lives where the first resource does unless told otherwise
+	var referenceSpec *gcp.ResourceSpec
+	if referenceName, _ := cmd.Flags().GetString("reference"); referenceName != "" {
+		ref := gcp.ResourceSpec{Type: specs[0].Type, Name: referenceName, Project: specs[0].Project, Flags: specs[0].Flags}
+		if referenceType, _ := cmd.Flags().GetString("reference-type"); referenceType != "" {
+			ref.Type = referenceType
+		}
+		if referenceProject, _ := cmd.Flags().GetString("reference-project"); referenceProject != "" {
+			ref.Project = referenceProject
+		}
+		referenceSpec = &ref
+	}
+
+	// How each resource is fetched, printed in dry-run mode and recorded by
+	// --with-provenance
+	commands := []string{fetchers[0].Describe(specs[0]), fetchers[1].Describe(specs[1])}
+	if includeIAM {
+		commands = append(commands, fetchers[0].Describe(iamSpecs[0]), fetchers[1].Describe(iamSpecs[1]))
+	}
+	if referenceSpec != nil {
+		commands = append(commands, fetcher.Describe(*referenceSpec))
+	}
+
+	// In dry-run mode, print how each resource would be fetched and stop
+	if viper.GetBool("dry-run") {
+		for _, command := range commands {
+			fmt.Fprintln(cmd.OutOrStdout(), command)
+		}
+		return nil, nil
 	}
 
 	// Load config for field filtering
-	cfg, err := config.Load(viper.ConfigFileUsed())
+	cfg, err := loadCompareConfig(cmd)
 	if err != nil {
-		fmt.Fprintf(cmd.OutOrStderr(), "Warning: could not load config: %v\n", err)
-		cfg = config.Default()
+		return nil, err
+	}
+	if viper.GetString("format") == "json-full" {
+		cfg.IncludeEqual = true
+	}
+	ignoreResourceNames(cfg, specs[0], specs[1])
+
+	// Compare fields un-ignored for this run despite the config
+	unignored, _ := cmd.Flags().GetStringArray("unignore")
+	for _, fieldPath := range unignored {
+		cfg.Unignore(fieldPath)
+	}
+
+	// Show the labels in place of the names; the fetch used the real names
+	if label1, _ := cmd.Flags().GetString("label1"); label1 != "" {
+		name1 = label1
+	}
+	if label2, _ := cmd.Flags().GetString("label2"); label2 != "" {
+		name2 = label2
 	}
 
-	// If comparing within the same project, ignore resource-specific identifiers
-	if project1 == project2 {
-		cfg.IgnoreFields = append(cfg.IgnoreFields,
-			"name",
-			"self_link",
-			"selfLink",
-		)
+	// Swap sides so added/removed invert without re-fetching
+	reverse := viper.GetBool("reverse")
+	if reverse {
+		name1, name2 = name2, name1
 	}
 
-	// Compare and output
 	differ := compare.NewDiffer(cfg, viper.GetBool("show-all"))
-	diff := differ.Compare(resource1, resource2)
-
-	format := viper.GetString("format")
-	switch format {
-	case "json":
-		output, _ := json.MarshalIndent(diff, "", "  ")
-		fmt.Println(string(output))
-	case "diff":
-		fallthrough
-	default:
-		compare.PrintGitStyleDiffV2(cmd.OutOrStdout(), diff, name1, name2)
+	if parallel, _ := cmd.Flags().GetBool("parallel-compare"); parallel {
+		differ.SetParallel(runtime.GOMAXPROCS(0))
 	}
 
-	return nil
-}
+	// In strict mode, count the differences that ignore rules hide by also
+	// diffing without them. The resource names stay ignored: they aren't an
+	// ignore rule, and always differ within one scope.
+	strict, _ := cmd.Flags().GetBool("strict")
+	failOnSuppressed, _ := cmd.Flags().GetBool("fail-on-suppressed")
+	if failOnSuppressed && !strict {
+		return nil, fmt.Errorf("--fail-on-suppressed requires --strict")
+	}
+	var strictDiffer *compare.Differ
+	if strict {
+		strictCfg := *cfg
+		strictCfg.IgnoreFields = resourceNameFields(specs[0], specs[1])
+		strictCfg.IgnoreUnderPath, strictCfg.Unignored = nil, nil
+		strictDiffer = compare.NewDiffer(&strictCfg, false)
+	}
+	var suppressed int
 
-func buildResourceFlags(cmd *cobra.Command, suffix string) map[string]string {
-	flags := make(map[string]string)
+	dumpDir, _ := cmd.Flags().GetString("dump-resources")
+	patchPath, _ := cmd.Flags().GetString("output-patch")
+	metricsPath, _ := cmd.Flags().GetString("metrics-file")
+
+	// Fields that must be present in both resources, checked after each fetch
+	requiredFields, _ := cmd.Flags().GetStringArray("require-field")
+	var missing [2][]string
 
-	if zone, _ := cmd.Flags().GetString("zone" + suffix); zone != "" {
-		flags["zone"] = zone
-	} else if zone1, _ := cmd.Flags().GetString("zone1"); suffix == "2" && zone1 != "" {
-		// Default to zone1 for resource 2
-		flags["zone"] = zone1
+	// Fields to compare instead of the whole resources
+	var fields []string
+	if fieldsPath, _ := cmd.Flags().GetString("fields-file"); fieldsPath != "" {
+		if fields, err = compare.LoadFieldList(fieldsPath); err != nil {
+			return nil, fmt.Errorf("failed to load fields file: %w", err)
+		}
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("no fields listed in %s", fieldsPath)
+		}
 	}
 
-	if region, _ := cmd.Flags().GetString("region" + suffix); region != "" {
-		flags["region"] = region
-	} else if region1, _ := cmd.Flags().GetString("region1"); suffix == "2" && region1 != "" {
-		// Default to region1 for resource 2
-		flags["region"] = region1
+	// diffResources diffs the listed fields, or the whole resources when no
+	// fields file is given, returning the listed fields neither resource has
+	diffResources := func(d *compare.Differ, resource1, resource2 map[string]interface{}) (*compare.Diff, []string) {
+		if fields == nil {
+			return d.Compare(resource1, resource2), nil
+		}
+		return d.CompareFields(resource1, resource2, fields)
 	}
 
-	if location, _ := cmd.Flags().GetString("location" + suffix); location != "" {
-		flags["location"] = location
-	} else if location1, _ := cmd.Flags().GetString("location1"); suffix == "2" && location1 != "" {
-		// Default to location1 for resource 2
-		flags["location"] = location1
+	saveBaselinePath, _ := cmd.Flags().GetString("save-baseline")
+	var baseline []*compare.Diff
+	if baselinePath, _ := cmd.Flags().GetString("baseline"); baselinePath != "" {
+		baseline, err = compare.LoadBaseline(baselinePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load baseline: %w", err)
+		}
 	}
 
-	return flags
+	expectDiffPath, _ := cmd.Flags().GetString("expect-diff")
+	var expected []*compare.Diff
+	if expectDiffPath != "" {
+		expected, err = compare.LoadExpectedDiff(expectDiffPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load expected diff: %w", err)
+		}
+	}
+
+	// The reference is fetched once, even in watch mode
+	var reference map[string]interface{}
+	if referenceSpec != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Fetching reference resource with: %s...\n", fetcher.Describe(*referenceSpec))
+		reference, err = fetcher.Fetch(cmd.Context(), *referenceSpec)
+		if err != nil {
+			return nil, fmt.Errorf("reference %s: %w", referenceSpec.Name, describeFetchError(err))
+		}
+		if err := compare.ApplySelections(reference, selections); err != nil {
+			return nil, err
+		}
+	}
+
+	// compareOnce fetches both resources and diffs them
+	var startedAt time.Time
+	compareOnce := func(ctx context.Context) (*compare.Diff, error) {
+		startedAt = time.Now()
+		resource1, resource2, err := fetchResourcePair(ctx, cmd, fetchers, maxConcurrency, includeIAM, specs, iamSpecs)
+		if err != nil {
+			return nil, err
+		}
+		if dumpDir != "" {
+			if err := dumpResources(dumpDir, resource1, resource2); err != nil {
+				return nil, err
+			}
+		}
+		// Like the diff, the patch follows --reverse: it turns the dumped
+		// file of the side shown first into the other
+		if patchPath != "" {
+			from, to, fileName := resource1, resource2, "resource1.json"
+			if reverse {
+				from, to, fileName = resource2, resource1, "resource2.json"
+			}
+			if err := compare.SavePatch(patchPath, fileName, from, to); err != nil {
+				return nil, fmt.Errorf("failed to write patch: %w", err)
+			}
+		}
+		for _, resource := range []map[string]interface{}{resource1, resource2} {
+			if err := compare.ApplySelections(resource, selections); err != nil {
+				return nil, err
+			}
+		}
+		missing[0] = compare.MissingFields(resource1, requiredFields)
+		missing[1] = compare.MissingFields(resource2, requiredFields)
+		if reverse {
+			resource1, resource2 = resource2, resource1
+		}
+		start := time.Now()
+		diff, absent := diffResources(differ, resource1, resource2)
+		elapsed := time.Since(start)
+		for _, warning := range differ.Warnings() {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", warning)
+		}
+		for _, field := range absent {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Field %s is absent from both resources\n", field)
+		}
+		if strict {
+			full, _ := diffResources(strictDiffer, resource1, resource2)
+			suppressed = full.Count() - diff.Count()
+		}
+		if saveBaselinePath != "" {
+			if err := compare.SaveBaseline(saveBaselinePath, diff); err != nil {
+				return nil, fmt.Errorf("failed to save baseline: %w", err)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Saved %d accepted difference(s) to %s\n", diff.Count(), saveBaselinePath)
+		}
+		if baseline != nil {
+			var accepted int
+			diff, accepted = compare.ApplyBaseline(diff, baseline)
+			fmt.Fprintf(cmd.ErrOrStderr(), "Suppressed %d difference(s) accepted in the baseline\n", accepted)
+		}
+		if viper.GetBool("verbose") {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Compared %d fields in %dms (%d changed)\n",
+				differ.ComparedFields(), elapsed.Milliseconds(), diff.Count())
+		}
+		return diff, nil
+	}
+
+	// printDiff renders a diff in the requested format
+	quietEqual, _ := cmd.Flags().GetBool("quiet-equal")
+	printDiff := func(diff *compare.Diff) error {
+		if quietEqual && diff.IsEmpty() {
+			return nil
+		}
+		format := viper.GetString("format")
+		switch format {
+		case "json", "json-full":
+			maxDepth, _ := cmd.Flags().GetInt("json-max-depth")
+			var value interface{} = compare.CollapseDepth(diff, maxDepth)
+			if withProvenance {
+				value = provenanceEnvelope{
+					Provenance: provenance{
+						Version:    rootCmd.Version,
+						Commands:   commands,
+						StartedAt:  startedAt,
+						FinishedAt: time.Now(),
+						Config:     newProvenanceConfig(cfg),
+					},
+					Diff: value,
+				}
+			}
+			output, _ := json.MarshalIndent(value, "", "  ")
+			fmt.Fprintln(cmd.OutOrStdout(), string(output))
+		case "json-tree":
+			output, _ := json.MarshalIndent(compare.ChangeTree(diff), "", "  ")
+			fmt.Fprintln(cmd.OutOrStdout(), string(output))
+		case "tfplan":
+			compare.WriteTerraformPlanWithOptions(cmd.OutOrStdout(), diff, name1, name2, compare.OutputOptions{Color: colors})
+		case "dot":
+			compare.WriteDOT(cmd.OutOrStdout(), diff)
+		case "github":
+			compare.WriteGitHubAnnotations(cmd.OutOrStdout(), diff)
+		case "csv":
+			if err := compare.WriteCSV(cmd.OutOrStdout(), diff); err != nil {
+				return fmt.Errorf("failed to write csv: %w", err)
+			}
+		case "diff":
+			fallthrough
+		default:
+			opts := compare.OutputOptions{
+				DecodeBase64:     viper.GetBool("decode-base64"),
+				UnitFields:       cfg.UnitFields,
+				RiskWeights:      cfg.RiskWeights,
+				DeprecatedFields: cfg.DeprecatedFields,
+				Width:            outputWidth(cmd.OutOrStdout()),
+				Color:            colors,
+			}
+			if contextLines, _ := cmd.Flags().GetInt("context-lines"); contextLines >= 0 {
+				opts.CompactValues = true
+				opts.ContextLines = contextLines
+			}
+			opts.CompactArrays, _ = cmd.Flags().GetBool("compact-arrays")
+			opts.ShowTypes = showTypes
+			opts.Limit, _ = cmd.Flags().GetInt("limit")
+			opts.Reference = reference
+			if interactive && browseDiff(cmd, diff, name1, name2, opts) {
+				return nil
+			}
+			if groupBy == "section" {
+				compare.PrintSectionedDiff(cmd.OutOrStdout(), diff, name1, name2, cfg.Sections, opts)
+			} else {
+				compare.PrintGitStyleDiffV2WithOptions(cmd.OutOrStdout(), diff, name1, name2, opts)
+			}
+		}
+		return nil
+	}
+
+	changedFieldsPath, _ := cmd.Flags().GetString("changed-fields-file")
+	var changedFields []string
+	if changedFieldsPath != "" {
+		changedFields, err = compare.LoadChangedFields(changedFieldsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load changed fields: %w", err)
+		}
+	}
+
+	if interval, _ := cmd.Flags().GetDuration("watch"); interval > 0 {
+		if changedFieldsPath != "" {
+			return nil, fmt.Errorf("--changed-fields-file cannot be used with --watch")
+		}
+		if interactive {
+			return nil, fmt.Errorf("--interactive cannot be used with --watch")
+		}
+		if len(requiredFields) > 0 {
+			return nil, fmt.Errorf("--require-field cannot be used with --watch")
+		}
+		if expectDiffPath != "" {
+			return nil, fmt.Errorf("--expect-diff cannot be used with --watch")
+		}
+		if metricsPath != "" {
+			return nil, fmt.Errorf("--metrics-file cannot be used with --watch")
+		}
+		if cmd.Flags().Changed("on-diff") {
+			return nil, fmt.Errorf("--on-diff cannot be used with --watch")
+		}
+		return nil, watchDiff(cmd, interval, compareOnce, printDiff)
+	}
+
+	diff, err := compareOnce(cmd.Context())
+	if err != nil {
+		return nil, err
+	}
+	if err := printDiff(diff); err != nil {
+		return diff, err
+	}
+
+	if hook, _ := cmd.Flags().GetString("on-diff"); hook != "" {
+		if err := runDiffHook(cmd, hook, diff); err != nil {
+			return diff, err
+		}
+	}
+
+	if metricsPath != "" {
+		// The counts come from the diff, so the labels follow --reverse too
+		labels := compare.MetricLabels{ResourceType: resourceTypeStr, Name1: spec.name1, Name2: spec.name2}
+		if reverse {
+			labels.Name1, labels.Name2 = labels.Name2, labels.Name1
+		}
+		if err := compare.SaveMetrics(metricsPath, diff, labels); err != nil {
+			return diff, fmt.Errorf("failed to write metrics: %w", err)
+		}
+	}
+
+	if count := len(missing[0]) + len(missing[1]); count > 0 {
+		for i, name := range []string{spec.name1, spec.name2} {
+			for _, field := range missing[i] {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Required field %s is missing from %s\n", field, name)
+			}
+		}
+		cmd.SilenceUsage = true
+		return diff, fmt.Errorf("%d required field(s) missing", count)
+	}
+
+	if expectDiffPath != "" {
+		missingDiffs, unexpectedDiffs := compare.CompareExpected(diff, expected)
+		for _, d := range missingDiffs {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Expected difference not found: %s\n", describeLeafDiff(d))
+		}
+		for _, d := range unexpectedDiffs {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Unexpected difference: %s\n", describeLeafDiff(d))
+		}
+		if len(missingDiffs) > 0 || len(unexpectedDiffs) > 0 {
+			cmd.SilenceUsage = true
+			return diff, fmt.Errorf("differences do not match %s: %d expected not found, %d unexpected",
+				expectDiffPath, len(missingDiffs), len(unexpectedDiffs))
+		}
+	}
+
+	if changedFieldsPath != "" {
+		unexpected, missingChanges := compare.CheckChangedFields(diff, changedFields)
+		for _, d := range unexpected {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Unexpected drift: %s\n", describeLeafDiff(d))
+		}
+		for _, field := range missingChanges {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Missing change: %s did not change\n", field)
+		}
+		if len(unexpected) > 0 || len(missingChanges) > 0 {
+			cmd.SilenceUsage = true
+			return diff, fmt.Errorf("changes do not match %s: %d unexpected, %d missing",
+				changedFieldsPath, len(unexpected), len(missingChanges))
+		}
+	}
+
+	if strict {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Strict: %d difference(s) suppressed by ignore rules\n", suppressed)
+		if failOnSuppressed && suppressed > 0 {
+			cmd.SilenceUsage = true
+			return diff, fmt.Errorf("%d difference(s) suppressed by ignore rules (--fail-on-suppressed)", suppressed)
+		}
+	}
+
+	return diff, nil
 }
 
-func buildGcloudCommand(resourcePath, name, project string, flags map[string]string) string {
-	parts := []string{resourcePath, "describe", name}
+// describeLeafDiff renders a leaf difference on one line for reports, with
+// values as JSON
+func describeLeafDiff(d *compare.Diff) string {
+	value1, _ := json.Marshal(d.Value1)
+	value2, _ := json.Marshal(d.Value2)
+	switch d.Type {
+	case compare.DiffTypeAdded:
+		return fmt.Sprintf("%s %s: %s", d.Type, d.Path, value2)
+	case compare.DiffTypeRemoved:
+		return fmt.Sprintf("%s %s: %s", d.Type, d.Path, value1)
+	default:
+		return fmt.Sprintf("%s %s: %s -> %s", d.Type, d.Path, value1, value2)
+	}
+}
 
-	if project != "" {
-		parts = append(parts, "--project="+project)
+// dumpResources writes both fetched resources to dir as pretty-printed
+// resource1.json and resource2.json
+func dumpResources(dir string, resource1, resource2 map[string]interface{}) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create dump directory: %w", err)
 	}
+	for i, resource := range []map[string]interface{}{resource1, resource2} {
+		data, err := json.MarshalIndent(resource, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode resource %d: %w", i+1, err)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("resource%d.json", i+1))
+		if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// fetchResourcePair fetches both resources (and IAM policies when includeIAM
+// is set) concurrently with the fetcher of each side, running at most
+// maxConcurrency fetches at once, and merges each policy into its resource
+func fetchResourcePair(ctx context.Context, cmd *cobra.Command, fetchers [2]gcp.Fetcher, maxConcurrency int, includeIAM bool, specs, iamSpecs [2]gcp.ResourceSpec) (map[string]interface{}, map[string]interface{}, error) {
+	// Log lines are written up front so goroutines never share the writer
+	fmt.Fprintf(cmd.ErrOrStderr(), "Fetching resource with: %s...\n", fetchers[0].Describe(specs[0]))
+	fmt.Fprintf(cmd.ErrOrStderr(), "Fetching resource with: %s...\n", fetchers[1].Describe(specs[1]))
+	if includeIAM {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Fetching IAM policy with: %s...\n", fetchers[0].Describe(iamSpecs[0]))
+		fmt.Fprintf(cmd.ErrOrStderr(), "Fetching IAM policy with: %s...\n", fetchers[1].Describe(iamSpecs[1]))
+	}
+
+	var resources, iamPolicies [2]map[string]interface{}
+	var iamErrs [2]error
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxConcurrency)
+	for i := range resources {
+		g.Go(func() error {
+			var err error
+			resources[i], err = fetchers[i].Fetch(gctx, specs[i])
+			if err != nil {
+				return describeFetchError(err)
+			}
+			return nil
+		})
+
+		// IAM failures are non-fatal, so they never cancel the group
+		if includeIAM {
+			g.Go(func() error {
+				iamPolicies[i], iamErrs[i] = fetchers[i].Fetch(gctx, iamSpecs[i])
+				return nil
+			})
+		}
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	// If --iam flag is set, merge the IAM policies into the resources. A
+	// policy on only one side would show up as a spurious added or removed
+	// iamPolicy, so unless both were fetched IAM is left out of both.
+	if includeIAM {
+		iamStrict, _ := cmd.Flags().GetBool("iam-strict")
+		failed := false
+		for i := range resources {
+			if iamErrs[i] == nil {
+				continue
+			}
+			if iamStrict {
+				return nil, nil, fmt.Errorf("could not fetch IAM policy for %s: %w", specs[i].Name, iamErrs[i])
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: could not fetch IAM policy for %s: %v\n", specs[i].Name, iamErrs[i])
+			failed = true
+		}
 
-	for key, value := range flags {
-		if value != "" {
-			parts = append(parts, fmt.Sprintf("--%s=%s", key, value))
+		if failed {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Warning: leaving IAM policies out of the comparison (use --iam-strict to fail instead)")
+		} else {
+			for i := range resources {
+				resources[i]["iamPolicy"] = iamPolicies[i]
+			}
 		}
 	}
 
-	return strings.Join(parts, " ")
+	return resources[0], resources[1], nil
+}
+
+// flagLookup returns the value of a string flag on cmd by name
+func flagLookup(cmd *cobra.Command) func(name string) string {
+	return func(name string) string {
+		value, _ := cmd.Flags().GetString(name)
+		return value
+	}
 }
 
-func buildGcloudIAMCommand(resourcePath, name, project string, flags map[string]string) string {
-	parts := []string{resourcePath, "get-iam-policy", name}
+// buildResourceFlags collects the location and configuration flags for one
+// side of a comparison, reading values through lookup
+func buildResourceFlags(lookup func(name string) string, suffix string) map[string]string {
+	flags := make(map[string]string)
 
-	if project != "" {
-		parts = append(parts, "--project="+project)
+	for _, key := range []string{"zone", "region", "location", "configuration"} {
+		if value := lookup(key + suffix); value != "" {
+			flags[key] = value
+		} else if value1 := lookup(key + "1"); suffix == "2" && value1 != "" {
+			// Default to the first resource's value for resource 2
+			flags[key] = value1
+		}
 	}
 
-	for key, value := range flags {
-		if value != "" {
-			parts = append(parts, fmt.Sprintf("--%s=%s", key, value))
+	// A resource has at most one parent, so resource 2 only inherits the
+	// first resource's parent when it names neither an organization nor a folder
+	inherit := suffix == "2"
+	for _, key := range gcp.ParentScopeFlags {
+		if lookup(key+suffix) != "" {
+			inherit = false
 		}
 	}
+	for _, key := range gcp.ParentScopeFlags {
+		if value := lookup(key + suffix); value != "" {
+			flags[key] = value
+		} else if value1 := lookup(key + "1"); inherit && value1 != "" {
+			flags[key] = value1
+		}
+	}
+
+	return flags
+}
 
-	return strings.Join(parts, " ")
+// describeFetchError wraps a fetch failure with a hint for the failure modes
+// users can usually fix themselves
+func describeFetchError(err error) error {
+	switch {
+	case errors.Is(err, gcp.ErrResourceNotFound):
+		return fmt.Errorf("failed to fetch resource (check the name, project and zone/region/location flags): %w", err)
+	case errors.Is(err, gcp.ErrPermissionDenied):
+		return fmt.Errorf("failed to fetch resource (check that your gcloud account has access to the project): %w", err)
+	default:
+		return fmt.Errorf("failed to fetch resource: %w", err)
+	}
 }
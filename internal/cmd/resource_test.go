@@ -0,0 +1,1202 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/tflynn3/gcdiff/internal/compare"
+	"github.com/tflynn3/gcdiff/internal/gcp"
+)
+
+// fakeRunner records gcloud invocations and answers them from a canned
+// response table keyed by the describe/get-iam-policy command prefix
+type fakeRunner struct {
+	mu        sync.Mutex
+	calls     [][]string
+	responses map[string]string
+	delay     time.Duration
+
+	// failures maps a command prefix to the error its invocation returns
+	failures map[string]string
+
+	onCall func(calls int)
+
+	// active and maxActive track concurrent invocations
+	active    int
+	maxActive int
+}
+
+func (f *fakeRunner) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	f.mu.Lock()
+	f.active++
+	if f.active > f.maxActive {
+		f.maxActive = f.active
+	}
+	f.mu.Unlock()
+
+	time.Sleep(f.delay)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.active--
+	f.calls = append(f.calls, append([]string{name}, args...))
+	if f.onCall != nil {
+		f.onCall(len(f.calls))
+	}
+
+	command := strings.Join(args, " ")
+	for prefix, message := range f.failures {
+		if strings.HasPrefix(command, prefix) {
+			return nil, errors.New(message)
+		}
+	}
+	for prefix, response := range f.responses {
+		if strings.HasPrefix(command, prefix) {
+			return []byte(response), nil
+		}
+	}
+	return []byte("{}"), nil
+}
+
+func (f *fakeRunner) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.calls)
+}
+
+func (f *fakeRunner) maxConcurrency() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.maxActive
+}
+
+// useFakeRunner swaps the fetcher constructor for one backed by runner for the
+// duration of the test
+func useFakeRunner(t *testing.T, runner *fakeRunner) {
+	t.Helper()
+	original := newFetcher
+	newFetcher = func() *gcp.ResourceFetcher {
+		return gcp.NewResourceFetcherWithRunner(runner.run)
+	}
+	t.Cleanup(func() { newFetcher = original })
+}
+
+// resetFlags restores every flag on cmd and its subcommands to its default so
+// tests sharing the global command tree don't leak state into each other
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.PersistentFlags().VisitAll(reset)
+	cmd.Flags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// setContexts sets ctx on cmd and its subcommands; cobra otherwise keeps
+// the context from the first execution on every subcommand it ran
+func setContexts(cmd *cobra.Command, ctx context.Context) {
+	cmd.SetContext(ctx)
+	for _, sub := range cmd.Commands() {
+		setContexts(sub, ctx)
+	}
+}
+
+// executeCommand runs the root command with args and returns what it wrote
+// to stdout. Progress logs written to stderr are discarded.
+func executeCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	return executeCommandContext(t, context.Background(), args...)
+}
+
+// executeCommandContext is executeCommand with a caller-supplied context
+func executeCommandContext(t *testing.T, ctx context.Context, args ...string) (string, error) {
+	t.Helper()
+	stdout, _, err := executeCommandOutput(t, ctx, args...)
+	return stdout, err
+}
+
+// executeCommandOutput runs the root command with args and returns what it
+// wrote to stdout and stderr
+func executeCommandOutput(t *testing.T, ctx context.Context, args ...string) (string, string, error) {
+	t.Helper()
+	resetFlags(rootCmd)
+	setContexts(rootCmd, ctx)
+	t.Cleanup(func() { resetFlags(rootCmd) })
+
+	var stdout, stderr bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stderr)
+	rootCmd.SetArgs(args)
+	err := rootCmd.ExecuteContext(ctx)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestRunResource_DryRun(t *testing.T) {
+	runner := &fakeRunner{}
+	useFakeRunner(t, runner)
+
+	output, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--iam", "--dry-run")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	expected := []string{
+		"gcloud compute instances describe vm-1 --project=proj --zone=us-central1-a",
+		"gcloud compute instances describe vm-2 --project=proj --zone=us-central1-a",
+		"gcloud compute instances get-iam-policy vm-1 --project=proj --zone=us-central1-a",
+		"gcloud compute instances get-iam-policy vm-2 --project=proj --zone=us-central1-a",
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line) {
+			t.Errorf("Expected dry-run output to contain %q, got:\n%s", line, output)
+		}
+	}
+
+	if runner.callCount() != 0 {
+		t.Errorf("Expected no gcloud invocations in dry-run mode, got %d", runner.callCount())
+	}
+}
+
+func TestRunResource_FetchesConcurrently(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	runner := &fakeRunner{delay: delay}
+	useFakeRunner(t, runner)
+
+	start := time.Now()
+	_, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--iam")
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if runner.callCount() != 4 {
+		t.Errorf("Expected 4 gcloud invocations, got %d", runner.callCount())
+	}
+
+	// Four sequential fetches would take 4x the delay
+	if elapsed >= 2*delay {
+		t.Errorf("Expected concurrent fetches to take about %v, took %v", delay, elapsed)
+	}
+}
+
+func TestRunResource_Reverse(t *testing.T) {
+	responses := map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2"}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-2", "deletionProtection": true}`,
+	}
+
+	fieldType := func(t *testing.T, args ...string) compare.DiffType {
+		t.Helper()
+		useFakeRunner(t, &fakeRunner{responses: responses})
+		output, err := executeCommand(t, append([]string{"resource", "compute instances", "vm-1", "vm-2",
+			"--project1=proj", "--zone1=us-central1-a", "--format=json"}, args...)...)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+
+		var diff compare.Diff
+		if err := json.Unmarshal([]byte(output), &diff); err != nil {
+			t.Fatalf("Failed to parse json output: %v\n%s", err, output)
+		}
+		child := diff.Children["deletionProtection"]
+		if child == nil {
+			t.Fatalf("Expected deletionProtection in diff, got:\n%s", output)
+		}
+		return child.Type
+	}
+
+	if got := fieldType(t); got != compare.DiffTypeAdded {
+		t.Errorf("Expected field to be added without --reverse, got %v", got)
+	}
+	if got := fieldType(t, "--reverse"); got != compare.DiffTypeRemoved {
+		t.Errorf("Expected field to be removed with --reverse, got %v", got)
+	}
+}
+
+func TestRunResource_Labels(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe web-prod":    `{"machineType": "n1-standard-2"}`,
+		"compute instances describe web-staging": `{"machineType": "n1-standard-4"}`,
+	}})
+
+	// The resources are still fetched by their real names
+	output, err := executeCommand(t, "resource", "compute instances", "web-prod", "web-staging",
+		"--project1=prod", "--project2=staging", "--zone1=us-central1-a",
+		"--label1=prod (us-central1)", "--label2=staging (us-west1)")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output, "Comparing: prod (us-central1) <-> staging (us-west1)") || strings.Contains(output, "web-prod") {
+		t.Errorf("Expected the labels in the header instead of the names, got:\n%s", output)
+	}
+	if !strings.Contains(output, "n1-standard-4") {
+		t.Errorf("Expected the resources to be compared, got:\n%s", output)
+	}
+}
+
+func TestRunResource_Unignore(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1", "fingerprint": "abc", "etag": "e1", "id": "1"}`,
+		"compute instances describe vm-2": `{"machineType": "n1", "fingerprint": "def", "etag": "e2", "id": "2"}`,
+	}})
+
+	output, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--unignore=fingerprint", "--format=json")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	var diff compare.Diff
+	if err := json.Unmarshal([]byte(output), &diff); err != nil {
+		t.Fatalf("Failed to parse json output: %v\n%s", err, output)
+	}
+	var paths []string
+	for _, change := range compare.GetAllDiffs(&diff) {
+		paths = append(paths, change.Path)
+	}
+	// etag and id stay ignored by the default config
+	if got := strings.Join(paths, ","); got != "fingerprint" {
+		t.Errorf("Expected only the un-ignored fingerprint to differ, got %s", got)
+	}
+}
+
+func TestRunResource_WithProvenance(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2"}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-4"}`,
+	}})
+
+	output, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--format=json", "--with-provenance")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	var envelope struct {
+		Provenance struct {
+			Version    string    `json:"version"`
+			Commands   []string  `json:"commands"`
+			StartedAt  time.Time `json:"started_at"`
+			FinishedAt time.Time `json:"finished_at"`
+			Config     struct {
+				IgnoreFields []string `json:"ignore_fields"`
+			} `json:"config"`
+		} `json:"provenance"`
+		Diff compare.Diff `json:"diff"`
+	}
+	if err := json.Unmarshal([]byte(output), &envelope); err != nil {
+		t.Fatalf("Failed to parse json output: %v\n%s", err, output)
+	}
+
+	p := envelope.Provenance
+	if p.Version != rootCmd.Version {
+		t.Errorf("Expected version %s, got %q", rootCmd.Version, p.Version)
+	}
+	want := []string{
+		"gcloud compute instances describe vm-1 --project=proj --zone=us-central1-a",
+		"gcloud compute instances describe vm-2 --project=proj --zone=us-central1-a",
+	}
+	if !reflect.DeepEqual(p.Commands, want) {
+		t.Errorf("Expected commands %v, got %v", want, p.Commands)
+	}
+	if p.StartedAt.IsZero() || p.FinishedAt.Before(p.StartedAt) {
+		t.Errorf("Unexpected timestamps %v, %v", p.StartedAt, p.FinishedAt)
+	}
+	if !slices.Contains(p.Config.IgnoreFields, "selfLink") {
+		t.Errorf("Expected the effective ignore fields, got %v", p.Config.IgnoreFields)
+	}
+	if child := envelope.Diff.Children["machineType"]; child == nil || child.Value2 != "n1-standard-4" {
+		t.Errorf("Expected the diff in the envelope, got:\n%s", output)
+	}
+
+	if _, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--with-provenance"); err == nil || !strings.Contains(err.Error(), "requires --format=json") {
+		t.Errorf("Expected --with-provenance to require json output, got %v", err)
+	}
+}
+
+func TestRunResource_Configurations(t *testing.T) {
+	runner := &fakeRunner{}
+	useFakeRunner(t, runner)
+
+	output, err := executeCommand(t, "resource", "storage buckets", "bucket-1", "bucket-2",
+		"--project1=prod", "--project2=staging",
+		"--configuration1=prod-account", "--configuration2=staging-account", "--dry-run")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 commands, got:\n%s", output)
+	}
+	if lines[0] != "gcloud storage buckets describe bucket-1 --project=prod --configuration=prod-account" {
+		t.Errorf("Unexpected first command: %s", lines[0])
+	}
+	if lines[1] != "gcloud storage buckets describe bucket-2 --project=staging --configuration=staging-account" {
+		t.Errorf("Unexpected second command: %s", lines[1])
+	}
+}
+
+func TestRunResource_JSONFullIncludesEqualFields(t *testing.T) {
+	responses := map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2", "status": "RUNNING"}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-4", "status": "RUNNING"}`,
+	}
+
+	diffFor := func(t *testing.T, format string) compare.Diff {
+		t.Helper()
+		useFakeRunner(t, &fakeRunner{responses: responses})
+		output, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+			"--project1=proj", "--zone1=us-central1-a", "--format="+format)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+
+		var diff compare.Diff
+		if err := json.Unmarshal([]byte(output), &diff); err != nil {
+			t.Fatalf("Failed to parse %s output: %v\n%s", format, err, output)
+		}
+		return diff
+	}
+
+	full := diffFor(t, "json-full")
+	status := full.Children["status"]
+	if status == nil || status.Type != compare.DiffTypeEqual || status.Value1 != "RUNNING" {
+		t.Errorf("Expected equal status leaf with its value in json-full output, got %+v", status)
+	}
+	if machineType := full.Children["machineType"]; machineType == nil || machineType.Type != compare.DiffTypeModified {
+		t.Errorf("Expected modified machineType in json-full output, got %+v", machineType)
+	}
+
+	pruned := diffFor(t, "json")
+	if _, ok := pruned.Children["status"]; ok {
+		t.Error("Expected equal status leaf to be pruned from json output")
+	}
+}
+
+func TestRunResource_MaxConcurrency(t *testing.T) {
+	runner := &fakeRunner{delay: 50 * time.Millisecond}
+	useFakeRunner(t, runner)
+
+	_, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--iam", "--max-concurrency=2")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if runner.callCount() != 4 {
+		t.Errorf("Expected 4 gcloud invocations, got %d", runner.callCount())
+	}
+	if got := runner.maxConcurrency(); got > 2 {
+		t.Errorf("Expected at most 2 concurrent gcloud invocations, observed %d", got)
+	}
+}
+
+func TestRunResource_InvalidMaxConcurrency(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{})
+
+	_, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--max-concurrency=0")
+	if err == nil || !strings.Contains(err.Error(), "--max-concurrency") {
+		t.Errorf("Expected --max-concurrency validation error, got %v", err)
+	}
+}
+
+func TestRunResource_MissingZoneForZonalType(t *testing.T) {
+	runner := &fakeRunner{}
+	useFakeRunner(t, runner)
+
+	_, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2", "--project1=proj")
+	if err == nil || err.Error() != "compute instances is zonal; please provide --zone1" {
+		t.Errorf("Expected missing zone error, got %v", err)
+	}
+	if runner.callCount() != 0 {
+		t.Errorf("Expected no gcloud invocations, got %d", runner.callCount())
+	}
+}
+
+func TestRunResource_ZoneProvidedForZonalType(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{})
+
+	_, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--dry-run")
+	if err != nil {
+		t.Errorf("Expected zonal type with --zone1 to be accepted, got %v", err)
+	}
+}
+
+func TestRunResource_StrictReportsSuppressedDifferences(t *testing.T) {
+	// fingerprint is ignored by default, so the only change is masked
+	responses := map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2", "fingerprint": "abc"}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-2", "fingerprint": "xyz"}`,
+	}
+	useFakeRunner(t, &fakeRunner{responses: responses})
+
+	stdout, stderr, err := executeCommandOutput(t, context.Background(), "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--strict")
+	if err != nil {
+		t.Errorf("Expected strict mode to only report suppressed differences, got %v", err)
+	}
+	if !strings.Contains(stderr, "1 difference(s) suppressed by ignore rules") {
+		t.Errorf("Expected suppressed-count message on stderr, got:\n%s", stderr)
+	}
+	if !strings.Contains(stdout, "No differences found") {
+		t.Errorf("Expected the normal diff to still be printed, got:\n%s", stdout)
+	}
+
+	_, _, err = executeCommandOutput(t, context.Background(), "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--strict", "--fail-on-suppressed")
+	if err == nil || !strings.Contains(err.Error(), "1 difference(s) suppressed") {
+		t.Errorf("Expected --fail-on-suppressed to fail on suppressed differences, got %v", err)
+	}
+}
+
+func TestRunResource_StrictKeepsResourceNamesIgnored(t *testing.T) {
+	responses := map[string]string{
+		"compute instances describe vm-1": `{"name": "vm-1", "selfLink": "https://compute/vm-1", "machineType": "n1-standard-2"}`,
+		"compute instances describe vm-2": `{"name": "vm-2", "selfLink": "https://compute/vm-2", "machineType": "n1-standard-2"}`,
+	}
+	useFakeRunner(t, &fakeRunner{responses: responses})
+
+	_, stderr, err := executeCommandOutput(t, context.Background(), "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--strict", "--fail-on-suppressed")
+	if err != nil {
+		t.Errorf("Expected names to differ without counting as suppressed, got %v", err)
+	}
+	if !strings.Contains(stderr, "0 difference(s) suppressed by ignore rules") {
+		t.Errorf("Expected no suppressed differences, got:\n%s", stderr)
+	}
+}
+
+func TestRunResource_StrictWithoutSuppressedDifferences(t *testing.T) {
+	responses := map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2"}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-4"}`,
+	}
+	useFakeRunner(t, &fakeRunner{responses: responses})
+
+	_, stderr, err := executeCommandOutput(t, context.Background(), "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--strict")
+	if err != nil {
+		t.Errorf("Expected no error when nothing is suppressed, got %v", err)
+	}
+	if !strings.Contains(stderr, "0 difference(s) suppressed by ignore rules") {
+		t.Errorf("Expected suppressed-count message on stderr, got:\n%s", stderr)
+	}
+}
+
+func TestRunResource_GcloudPath(t *testing.T) {
+	runner := &fakeRunner{}
+	useFakeRunner(t, runner)
+
+	_, err := executeCommand(t, "resource", "storage buckets", "bucket-1", "bucket-2",
+		"--project1=proj", "--gcloud-path=/opt/google-cloud-sdk/bin/gcloud")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+	for _, call := range runner.calls {
+		if call[0] != "/opt/google-cloud-sdk/bin/gcloud" {
+			t.Errorf("Expected configured gcloud binary, got %q", call[0])
+		}
+	}
+}
+
+func TestRunResource_DumpResources(t *testing.T) {
+	responses := map[string]string{
+		"storage buckets describe bucket-1": `{"name": "bucket-1", "location": "US"}`,
+		"storage buckets describe bucket-2": `{"name": "bucket-2", "location": "EU"}`,
+	}
+	useFakeRunner(t, &fakeRunner{responses: responses})
+	dir := filepath.Join(t.TempDir(), "dump")
+
+	_, err := executeCommand(t, "resource", "storage buckets", "bucket-1", "bucket-2",
+		"--project1=proj", "--dump-resources="+dir)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"resource1.json": "{\n  \"location\": \"US\",\n  \"name\": \"bucket-1\"\n}\n",
+		"resource2.json": "{\n  \"location\": \"EU\",\n  \"name\": \"bucket-2\"\n}\n",
+	}
+	for name, content := range expected {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Expected %s to be written: %v", name, err)
+		}
+		if string(data) != content {
+			t.Errorf("Unexpected %s content:\n%s", name, data)
+		}
+	}
+}
+
+func TestRunResource_QuietEqual(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2"}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-2"}`,
+		"compute instances describe vm-3": `{"machineType": "n1-standard-4"}`,
+	}})
+
+	for _, format := range []string{"diff", "json"} {
+		output, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+			"--project1=proj", "--zone1=us-central1-a", "--quiet-equal", "--format="+format)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if output != "" {
+			t.Errorf("Expected no %s output for identical resources, got:\n%s", format, output)
+		}
+	}
+
+	output, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-3",
+		"--project1=proj", "--zone1=us-central1-a", "--quiet-equal")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output, "machineType") || !strings.Contains(output, "n1-standard-4") {
+		t.Errorf("Expected the full diff when resources differ, got:\n%s", output)
+	}
+}
+
+func TestRunResource_OutputPatch(t *testing.T) {
+	responses := map[string]string{
+		"storage buckets describe bucket-1": `{"name": "bucket-1", "location": "US"}`,
+		"storage buckets describe bucket-2": `{"name": "bucket-2", "location": "EU"}`,
+	}
+	useFakeRunner(t, &fakeRunner{responses: responses})
+	dir := t.TempDir()
+	patchPath := filepath.Join(dir, "diff.patch")
+
+	_, err := executeCommand(t, "resource", "storage buckets", "bucket-1", "bucket-2",
+		"--project1=proj", "--output-patch="+patchPath)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	data, err := os.ReadFile(patchPath)
+	if err != nil {
+		t.Fatalf("Expected the patch to be written: %v", err)
+	}
+	expected := "--- a/resource1.json\n+++ b/resource1.json\n@@ -1,4 +1,4 @@\n {\n" +
+		"-  \"location\": \"US\",\n-  \"name\": \"bucket-1\"\n" +
+		"+  \"location\": \"EU\",\n+  \"name\": \"bucket-2\"\n }\n"
+	if string(data) != expected {
+		t.Errorf("Unexpected patch:\n%s", data)
+	}
+}
+
+func TestRunResource_OutputPatchReverse(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"storage buckets describe bucket-1": `{"location": "US"}`,
+		"storage buckets describe bucket-2": `{"location": "EU"}`,
+	}})
+	patchPath := filepath.Join(t.TempDir(), "diff.patch")
+
+	_, err := executeCommand(t, "resource", "storage buckets", "bucket-1", "bucket-2",
+		"--project1=proj", "--reverse", "--output-patch="+patchPath)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	data, err := os.ReadFile(patchPath)
+	if err != nil {
+		t.Fatalf("Expected the patch to be written: %v", err)
+	}
+	expected := "--- a/resource2.json\n+++ b/resource2.json\n@@ -1,3 +1,3 @@\n {\n" +
+		"-  \"location\": \"EU\"\n+  \"location\": \"US\"\n }\n"
+	if string(data) != expected {
+		t.Errorf("Expected a patch from resource2.json to the first resource, got:\n%s", data)
+	}
+}
+
+func TestRunResource_Baseline(t *testing.T) {
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+	run := func(t *testing.T, vm2 string, args ...string) compare.Diff {
+		t.Helper()
+		useFakeRunner(t, &fakeRunner{responses: map[string]string{
+			"compute instances describe vm-1": `{"machineType": "n1-standard-2", "status": "RUNNING"}`,
+			"compute instances describe vm-2": vm2,
+		}})
+		output, err := executeCommand(t, append([]string{"resource", "compute instances", "vm-1", "vm-2",
+			"--project1=proj", "--zone1=us-central1-a", "--format=json"}, args...)...)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+
+		var diff compare.Diff
+		if err := json.Unmarshal([]byte(output), &diff); err != nil {
+			t.Fatalf("Failed to parse json output: %v\n%s", err, output)
+		}
+		return diff
+	}
+
+	accepted := `{"machineType": "n1-standard-4", "status": "RUNNING"}`
+	run(t, accepted, "--save-baseline="+baseline)
+
+	if diff := run(t, accepted, "--baseline="+baseline); diff.Type != compare.DiffTypeEqual {
+		t.Errorf("Expected accepted drift to be suppressed, got %+v", diff.Children)
+	}
+
+	diff := run(t, `{"machineType": "n1-standard-4", "status": "STOPPED"}`, "--baseline="+baseline)
+	if _, ok := diff.Children["machineType"]; ok {
+		t.Error("Expected accepted machineType drift to stay suppressed")
+	}
+	if status := diff.Children["status"]; status == nil || status.Type != compare.DiffTypeModified {
+		t.Errorf("Expected new status drift to be reported, got %+v", status)
+	}
+}
+
+func TestRunResource_VerboseSummary(t *testing.T) {
+	responses := map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2", "labels": {"env": "dev", "team": "web"}, "deletionProtection": true}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-4", "labels": {"env": "dev", "team": "web"}}`,
+	}
+	useFakeRunner(t, &fakeRunner{responses: responses})
+
+	_, stderr, err := executeCommandOutput(t, context.Background(), "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--verbose")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	summary := regexp.MustCompile(`(?m)^Compared 4 fields in \d+ms \(2 changed\)$`)
+	if !summary.MatchString(stderr) {
+		t.Errorf("Expected a verbose summary line on stderr, got:\n%s", stderr)
+	}
+}
+
+func TestRunResource_NoSummaryWithoutVerbose(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{})
+
+	_, stderr, err := executeCommandOutput(t, context.Background(), "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if strings.Contains(stderr, "Compared ") {
+		t.Errorf("Expected no summary line without --verbose, got:\n%s", stderr)
+	}
+}
+
+func TestRunResource_ColorFlag(t *testing.T) {
+	responses := map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2"}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-4"}`,
+	}
+	useFakeRunner(t, &fakeRunner{responses: responses})
+
+	output, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--color=always")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output, "\x1b[") {
+		t.Errorf("Expected ANSI codes with --color=always, got %q", output)
+	}
+
+	output, err = executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--color=never")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if strings.Contains(output, "\x1b[") {
+		t.Errorf("Expected no ANSI codes with --color=never, got %q", output)
+	}
+
+	if _, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--color=sometimes"); err == nil {
+		t.Error("Expected an error for an unknown --color value")
+	}
+}
+
+func TestRunResource_ResolvesDefaultProject(t *testing.T) {
+	runner := &fakeRunner{responses: map[string]string{"config get-value project": "my-default\n"}}
+	useFakeRunner(t, runner)
+
+	output, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--zone1=us-central1-a", "--dry-run")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if !strings.Contains(output, "gcloud compute instances describe vm-1 --project=my-default --zone=us-central1-a") ||
+		!strings.Contains(output, "gcloud compute instances describe vm-2 --project=my-default --zone=us-central1-a") {
+		t.Errorf("Expected the resolved project in both commands, got:\n%s", output)
+	}
+}
+
+func TestRunResource_DefaultProjectOfConfiguration(t *testing.T) {
+	runner := &fakeRunner{responses: map[string]string{"config get-value project": "work-default\n"}}
+	useFakeRunner(t, runner)
+
+	output, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--zone1=us-central1-a", "--configuration1=work", "--dry-run")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if len(runner.calls) != 1 || strings.Join(runner.calls[0][1:], " ") != "config get-value project --configuration=work" {
+		t.Errorf("Expected the project to be read from --configuration1, got calls %v", runner.calls)
+	}
+	if !strings.Contains(output, "--project=work-default") {
+		t.Errorf("Expected the configuration's project in the commands, got:\n%s", output)
+	}
+}
+
+func TestRunResource_NoDefaultProject(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{"config get-value project": "(unset)\n"}})
+
+	_, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2", "--zone1=us-central1-a")
+	if err == nil || !strings.Contains(err.Error(), "--project1 is required") {
+		t.Errorf("Expected a missing project error, got %v", err)
+	}
+}
+
+func TestBuildResourceFlags_ParentScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   map[string]string
+	}{
+		{"inherits organization", map[string]string{"organization1": "123"}, map[string]string{"organization": "123"}},
+		{"own folder", map[string]string{"organization1": "123", "folder2": "456"}, map[string]string{"folder": "456"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildResourceFlags(func(name string) string { return tt.values[name] }, "2")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRunResource_OrganizationWithoutProject(t *testing.T) {
+	runner := &fakeRunner{responses: map[string]string{"config get-value project": "(unset)\n"}}
+	useFakeRunner(t, runner)
+
+	output, err := executeCommand(t, "resource", "resource-manager org-policies", "c1", "c1",
+		"--organization1=123", "--folder2=456", "--dry-run")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if runner.callCount() != 0 {
+		t.Errorf("Expected no default project lookup, got %d gcloud call(s)", runner.callCount())
+	}
+	if !strings.Contains(output, "describe c1 --organization=123\n") || !strings.Contains(output, "describe c1 --folder=456\n") {
+		t.Errorf("Expected organization and folder scoped commands, got:\n%s", output)
+	}
+	if strings.Contains(output, "--project") {
+		t.Errorf("Expected no --project flag, got:\n%s", output)
+	}
+}
+
+// stubFetcher serves canned resources by name
+type stubFetcher struct {
+	resources map[string]map[string]interface{}
+}
+
+func (f *stubFetcher) Fetch(_ context.Context, spec gcp.ResourceSpec) (map[string]interface{}, error) {
+	resource, ok := f.resources[spec.Name]
+	if !ok {
+		return nil, fmt.Errorf("no resource %s", spec.Name)
+	}
+	return resource, nil
+}
+
+func (f *stubFetcher) Describe(spec gcp.ResourceSpec) string {
+	return "stub " + spec.Name
+}
+
+func TestRunResource_StubFetcher(t *testing.T) {
+	original := sourceFetcher
+	sourceFetcher = func(*cobra.Command) (gcp.Fetcher, error) {
+		return &stubFetcher{resources: map[string]map[string]interface{}{
+			"a": {"tier": "basic"},
+			"b": {"tier": "premium"},
+		}}, nil
+	}
+	t.Cleanup(func() { sourceFetcher = original })
+
+	output, err := executeCommand(t, "resource", "storage buckets", "a", "b", "--project1=proj")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output, "basic") || !strings.Contains(output, "premium") {
+		t.Errorf("Expected the stub resources to be diffed, got:\n%s", output)
+	}
+}
+
+func TestRunResource_FileSource(t *testing.T) {
+	runner := &fakeRunner{responses: map[string]string{}}
+	useFakeRunner(t, runner)
+
+	dir := t.TempDir()
+	path1 := filepath.Join(dir, "vm-1.json")
+	if err := os.WriteFile(path1, []byte(`{"machineType": "n1-standard-2"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rootCmd.SetIn(strings.NewReader("machineType: n1-standard-4\n"))
+	t.Cleanup(func() { rootCmd.SetIn(nil) })
+
+	output, err := executeCommand(t, "resource", "compute instances", path1, "-", "--source=file")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if runner.callCount() != 0 {
+		t.Errorf("Expected no gcloud calls, got %d", runner.callCount())
+	}
+	if !strings.Contains(output, "n1-standard-2") || !strings.Contains(output, "n1-standard-4") {
+		t.Errorf("Expected the file and stdin resources to be diffed, got:\n%s", output)
+	}
+}
+
+func TestRunResource_FileSourceRejectsIAM(t *testing.T) {
+	_, err := executeCommand(t, "resource", "storage buckets", "a.json", "b.json", "--source=file", "--iam")
+	if err == nil || !strings.Contains(err.Error(), "--iam cannot be used") {
+		t.Errorf("Expected an --iam error, got %v", err)
+	}
+}
+
+func TestRunResource_URL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/vm-1.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"machineType": "n1-standard-2"}`))
+	}))
+	defer server.Close()
+
+	runner := &fakeRunner{responses: map[string]string{
+		"compute instances describe vm-2": `{"machineType": "n1-standard-4"}`,
+	}}
+	useFakeRunner(t, runner)
+
+	output, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--url1="+server.URL+"/vm-1.json", "--project2=proj", "--zone2=us-central1-a")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if runner.callCount() != 1 {
+		t.Errorf("Expected only the second resource to use gcloud, got %d calls", runner.callCount())
+	}
+	if !strings.Contains(output, "n1-standard-2") || !strings.Contains(output, "n1-standard-4") {
+		t.Errorf("Expected the downloaded and fetched resources to be diffed, got:\n%s", output)
+	}
+
+	useFakeRunner(t, runner)
+	_, err = executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--url1="+server.URL+"/missing.json", "--project2=proj", "--zone2=us-central1-a")
+	if err == nil || !strings.Contains(err.Error(), "HTTP 404 Not Found") {
+		t.Errorf("Expected an HTTP status error, got %v", err)
+	}
+}
+
+func TestRunResource_MetricsFile(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2", "labels": {"env": "dev"}}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-4"}`,
+	}})
+
+	path := filepath.Join(t.TempDir(), "gcdiff.prom")
+	if _, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--metrics-file="+path); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected a metrics file: %v", err)
+	}
+	labels := `{resource_type="compute instances",resource1="vm-1",resource2="vm-2"}`
+	for _, sample := range []string{
+		"gcdiff_differences_total" + labels + " 2",
+		"gcdiff_removed_total" + labels + " 1",
+		"gcdiff_modified_total" + labels + " 1",
+		"gcdiff_added_total" + labels + " 0",
+	} {
+		if !strings.Contains(string(data), sample+"\n") {
+			t.Errorf("Expected sample %q, got:\n%s", sample, data)
+		}
+	}
+}
+
+func TestRunResource_MetricsFileReverse(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2", "labels": {"env": "dev"}}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-4"}`,
+	}})
+
+	path := filepath.Join(t.TempDir(), "gcdiff.prom")
+	if _, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--reverse", "--metrics-file="+path); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected a metrics file: %v", err)
+	}
+	// vm-2 is the baseline, so vm-1's extra label counts as added
+	sample := `gcdiff_added_total{resource_type="compute instances",resource1="vm-2",resource2="vm-1"} 1`
+	if !strings.Contains(string(data), sample+"\n") {
+		t.Errorf("Expected sample %q, got:\n%s", sample, data)
+	}
+}
+
+func TestRunResource_Reference(t *testing.T) {
+	runner := &fakeRunner{responses: map[string]string{
+		"compute instances describe vm-1":    `{"machineType": "n1-standard-2", "status": "RUNNING"}`,
+		"compute instances describe vm-2":    `{"machineType": "n1-standard-4", "status": "RUNNING"}`,
+		"compute instances describe vm-prod": `{"machineType": "n1-standard-4"}`,
+	}}
+	useFakeRunner(t, runner)
+
+	output, stderr, err := executeCommandOutput(t, context.Background(), "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--reference=vm-prod", "--reference-project=prod")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output, `= reference: "n1-standard-4"`) {
+		t.Errorf("Expected the reference value for machineType, got:\n%s", output)
+	}
+	if !strings.Contains(stderr, "compute instances describe vm-prod --project=prod --zone=us-central1-a") {
+		t.Errorf("Expected the reference to be fetched from prod in the first zone, got:\n%s", stderr)
+	}
+}
+
+func TestRunResource_UnknownSource(t *testing.T) {
+	_, err := executeCommand(t, "resource", "storage buckets", "a", "b", "--project1=proj", "--source=api")
+	if err == nil || !strings.Contains(err.Error(), "unknown --source") {
+		t.Errorf("Expected an unknown source error, got %v", err)
+	}
+}
+
+func TestRunResource_RequireField(t *testing.T) {
+	responses := map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1", "scheduling": {"preemptible": false}}`,
+		"compute instances describe vm-2": `{"machineType": "n1", "scheduling": {}}`,
+	}
+
+	tests := []struct {
+		name    string
+		fields  []string
+		wantErr string
+		stderr  string
+	}{
+		{"present in both", []string{"machineType"}, "", ""},
+		{"missing in one", []string{"machineType", "scheduling.preemptible"}, "1 required field(s) missing",
+			"Required field scheduling.preemptible is missing from vm-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeRunner(t, &fakeRunner{responses: responses})
+
+			args := []string{"resource", "compute instances", "vm-1", "vm-2", "--project1=proj", "--zone1=us-central1-a"}
+			for _, field := range tt.fields {
+				args = append(args, "--require-field="+field)
+			}
+			_, stderr, err := executeCommandOutput(t, context.Background(), args...)
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected success, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+			if !strings.Contains(stderr, tt.stderr) {
+				t.Errorf("Expected %q on stderr, got:\n%s", tt.stderr, stderr)
+			}
+		})
+	}
+}
+
+func TestRunResource_FieldsFile(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2", "disks": [{"type": "pd-standard", "sizeGb": 10}], "labels": {"env": "dev"}}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-4", "disks": [{"type": "pd-ssd", "sizeGb": 20}], "labels": {"env": "prod"}}`,
+	}})
+
+	path := filepath.Join(t.TempDir(), "fields.txt")
+	contents := "# Fields that matter\ndisks[0].type\nlabels.env\nshieldedInstanceConfig.enableSecureBoot\n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	output, stderr, err := executeCommandOutput(t, context.Background(), "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--fields-file="+path, "--format=json")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	var diff compare.Diff
+	if err := json.Unmarshal([]byte(output), &diff); err != nil {
+		t.Fatalf("Failed to parse json output: %v\n%s", err, output)
+	}
+	var paths []string
+	for _, change := range compare.GetAllDiffs(&diff) {
+		paths = append(paths, change.Path)
+	}
+	if got := strings.Join(paths, ","); got != "disks[0].type,labels.env" {
+		t.Errorf("Expected only the listed fields to differ, got %s", got)
+	}
+	if !strings.Contains(stderr, "Field shieldedInstanceConfig.enableSecureBoot is absent from both resources") {
+		t.Errorf("Expected the absent field to be reported, got:\n%s", stderr)
+	}
+}
+
+func TestRunResource_ExpectDiff(t *testing.T) {
+	run := func(t *testing.T, vm2 string, args ...string) (string, string, error) {
+		t.Helper()
+		useFakeRunner(t, &fakeRunner{responses: map[string]string{
+			"compute instances describe vm-1": `{"machineType": "n1-standard-2", "status": "RUNNING"}`,
+			"compute instances describe vm-2": vm2,
+		}})
+		return executeCommandOutput(t, context.Background(), append([]string{"resource", "compute instances", "vm-1", "vm-2",
+			"--project1=proj", "--zone1=us-central1-a"}, args...)...)
+	}
+
+	golden := `{"machineType": "n1-standard-4", "status": "RUNNING"}`
+	saved, _, err := run(t, golden, "--format=json")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	expected := filepath.Join(t.TempDir(), "expected.json")
+	if err := os.WriteFile(expected, []byte(saved), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := run(t, golden, "--expect-diff="+expected); err != nil {
+		t.Errorf("Expected the same differences to match, got %v", err)
+	}
+
+	_, stderr, err := run(t, `{"machineType": "n1-standard-2", "status": "STOPPED"}`, "--expect-diff="+expected)
+	if err == nil || !strings.Contains(err.Error(), "1 expected not found, 1 unexpected") {
+		t.Errorf("Expected a mismatch error, got %v", err)
+	}
+	for _, want := range []string{
+		`Expected difference not found: modified machineType: "n1-standard-2" -> "n1-standard-4"`,
+		`Unexpected difference: modified status: "RUNNING" -> "STOPPED"`,
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("Expected %q on stderr, got:\n%s", want, stderr)
+		}
+	}
+}
+
+func TestRunResource_ChangedFieldsFile(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2", "status": "RUNNING"}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-4", "status": "STOPPED"}`,
+	}})
+	changes := filepath.Join(t.TempDir(), "changes.txt")
+	if err := os.WriteFile(changes, []byte("machineType\nlabels.env\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, err := executeCommandOutput(t, context.Background(), "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--changed-fields-file="+changes)
+	if err == nil || !strings.Contains(err.Error(), "1 unexpected, 1 missing") {
+		t.Errorf("Expected a mismatch error, got %v", err)
+	}
+	for _, want := range []string{
+		`Unexpected drift: modified status: "RUNNING" -> "STOPPED"`,
+		"Missing change: labels.env did not change",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("Expected %q on stderr, got:\n%s", want, stderr)
+		}
+	}
+}
+
+func TestRunResource_Show(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe vm-1": `{"machineType": "n1-standard-2", "labels": {"env": "dev"}}`,
+		"compute instances describe vm-2": `{"machineType": "n1-standard-4", "tags": ["web"]}`,
+	}})
+
+	output, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--show=removed")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(output, "env") || strings.Contains(output, "machineType") || strings.Contains(output, "tags") {
+		t.Errorf("Expected only the removed label, got:\n%s", output)
+	}
+
+	if _, err := executeCommand(t, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--show=renamed"); err == nil || !strings.Contains(err.Error(), "invalid --show") {
+		t.Errorf("Expected an invalid --show error, got %v", err)
+	}
+}
+
+func TestRunResource_IAMFailsOnOneSide(t *testing.T) {
+	newRunner := func() *fakeRunner {
+		return &fakeRunner{
+			responses: map[string]string{
+				"compute instances describe":            `{"machineType": "n1-standard-2"}`,
+				"compute instances get-iam-policy vm-1": `{"bindings": [{"role": "roles/viewer", "members": ["user:a@example.com"]}]}`,
+			},
+			failures: map[string]string{
+				"compute instances get-iam-policy vm-2": "permission denied",
+			},
+		}
+	}
+	args := []string{"resource", "compute instances", "vm-1", "vm-2", "--project1=proj", "--zone1=us-central1-a", "--iam"}
+
+	// By default IAM is left out of both sides rather than reported as removed
+	useFakeRunner(t, newRunner())
+	output, stderr, err := executeCommandOutput(t, context.Background(), args...)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if strings.Contains(output, "iamPolicy") || !strings.Contains(output, "No differences") {
+		t.Errorf("Expected no IAM diff, got:\n%s", output)
+	}
+	if !strings.Contains(stderr, "could not fetch IAM policy for vm-2") || !strings.Contains(stderr, "leaving IAM policies out") {
+		t.Errorf("Expected IAM warnings, got:\n%s", stderr)
+	}
+
+	useFakeRunner(t, newRunner())
+	_, _, err = executeCommandOutput(t, context.Background(), append(args, "--iam-strict")...)
+	if err == nil || !strings.Contains(err.Error(), "could not fetch IAM policy for vm-2") {
+		t.Errorf("Expected --iam-strict to fail, got %v", err)
+	}
+}
@@ -3,17 +3,33 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/tflynn3/gcdiff/internal/compare"
+	"github.com/tflynn3/gcdiff/internal/config"
+	"github.com/tflynn3/gcdiff/internal/gcp"
 )
 
 var (
-	cfgFile  string
-	project1 string
-	project2 string
-	format   string
-	showAll  bool
+	cfgFile        string
+	project1       string
+	project2       string
+	format         string
+	showAll        bool
+	dryRun         bool
+	groupBy        string
+	structureOnly  bool
+	reverse        bool
+	showContext    bool
+	decodeBase64   bool
+	schemaFile     string
+	maxConcurrency int
+	gcloudPath     string
+	verbose        bool
+	colorMode      string
+	arrayMode      string
 )
 
 var rootCmd = &cobra.Command{
@@ -26,6 +42,9 @@ replicating environments or validating Terraform configurations.
 Example:
   gcdiff compute my-instance-1 my-instance-2 --project1=prod --project2=staging`,
 	Version: "0.4.0",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		applyConfigColors(cmd)
+	},
 }
 
 // Execute runs the root command
@@ -37,22 +56,51 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	// Global flags
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.gcdiff.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $GCDIFF_CONFIG or $HOME/.gcdiff.yaml)")
 	rootCmd.PersistentFlags().StringVar(&project1, "project1", "", "First GCP project ID")
 	rootCmd.PersistentFlags().StringVar(&project2, "project2", "", "Second GCP project ID (defaults to project1 if not specified)")
-	rootCmd.PersistentFlags().StringVar(&format, "format", "diff", "Output format: diff, json")
+	rootCmd.PersistentFlags().StringVar(&format, "format", "diff", "Output format: diff, json, json-full (json including equal fields), json-tree (changed leaves as nested old/new values), tfplan, github (GitHub Actions annotations), dot (GraphViz), csv")
 	rootCmd.PersistentFlags().BoolVar(&showAll, "show-all", false, "Show all fields including ignored ones")
+	rootCmd.PersistentFlags().BoolVar(&decodeBase64, "decode-base64", false, "Show decoded text for values that look like base64")
+	rootCmd.PersistentFlags().BoolVar(&showContext, "show-context", false, "Show unchanged sibling fields next to changes (dimmed)")
+	rootCmd.PersistentFlags().BoolVar(&reverse, "reverse", false, "Swap the two resources so the second is treated as the baseline")
+	rootCmd.PersistentFlags().BoolVar(&structureOnly, "structure-only", false, "Compare only keys and value types, ignoring values")
+	rootCmd.PersistentFlags().StringVar(&arrayMode, "array-mode", "", "How changed arrays are shown: granular (per element, default) or whole")
+	rootCmd.PersistentFlags().StringVar(&schemaFile, "schema", "", "JSON schema file declaring field types to coerce values to before comparing")
+	rootCmd.PersistentFlags().StringVar(&groupBy, "group-by", "", "Group diff output: section (uses sections from config)")
+	rootCmd.PersistentFlags().StringVar(&gcloudPath, "gcloud-path", "", "gcloud executable to run (default is $GCDIFF_GCLOUD or gcloud on PATH)")
+	rootCmd.PersistentFlags().IntVar(&maxConcurrency, "max-concurrency", 4, "Maximum number of gcloud commands to run at once")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print the gcloud commands that would be run without executing them")
+	rootCmd.PersistentFlags().StringVar(&colorMode, "color", "auto", "Colorize output: auto, always, never")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Print a summary of compared fields and elapsed time to stderr")
 
 	// Bind flags to viper
 	_ = viper.BindPFlag("project1", rootCmd.PersistentFlags().Lookup("project1"))
 	_ = viper.BindPFlag("project2", rootCmd.PersistentFlags().Lookup("project2"))
 	_ = viper.BindPFlag("format", rootCmd.PersistentFlags().Lookup("format"))
 	_ = viper.BindPFlag("show-all", rootCmd.PersistentFlags().Lookup("show-all"))
+	_ = viper.BindPFlag("decode-base64", rootCmd.PersistentFlags().Lookup("decode-base64"))
+	_ = viper.BindPFlag("show-context", rootCmd.PersistentFlags().Lookup("show-context"))
+	_ = viper.BindPFlag("reverse", rootCmd.PersistentFlags().Lookup("reverse"))
+	_ = viper.BindPFlag("structure-only", rootCmd.PersistentFlags().Lookup("structure-only"))
+	_ = viper.BindPFlag("array-mode", rootCmd.PersistentFlags().Lookup("array-mode"))
+	_ = viper.BindPFlag("schema", rootCmd.PersistentFlags().Lookup("schema"))
+	_ = viper.BindPFlag("group-by", rootCmd.PersistentFlags().Lookup("group-by"))
+	_ = viper.BindPFlag("max-concurrency", rootCmd.PersistentFlags().Lookup("max-concurrency"))
+	_ = viper.BindPFlag("dry-run", rootCmd.PersistentFlags().Lookup("dry-run"))
+	_ = viper.BindPFlag("color", rootCmd.PersistentFlags().Lookup("color"))
+	_ = viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
 }
 
+// configEnvVar names the environment variable that points at a config file
+// when --config is not given
+const configEnvVar = "GCDIFF_CONFIG"
+
 func initConfig() {
-	if cfgFile != "" {
-		viper.SetConfigFile(cfgFile)
+	if path := resolveConfigFile(cfgFile); path != "" {
+		viper.SetConfigFile(path)
+	} else if path := findProjectConfig(); path != "" {
+		viper.SetConfigFile(path)
 	} else {
 		home, err := os.UserHomeDir()
 		if err != nil {
@@ -72,3 +120,71 @@ func initConfig() {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 }
+
+// applyConfigColors sets the diff marker colors from the config, so every
+// command prints with them. A config that can't be loaded leaves the default
+// colors; the commands that read the rest of it report the error.
+func applyConfigColors(cmd *cobra.Command) {
+	cfg, err := config.Load(viper.ConfigFileUsed())
+	if err != nil {
+		cfg = config.Default()
+	}
+	for _, warning := range compare.SetColors(cfg.Colors) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", warning)
+	}
+}
+
+// projectConfigName is the config file looked for in the current directory
+// and its parents
+const projectConfigName = ".gcdiff.yaml"
+
+// findProjectConfig returns the nearest .gcdiff.yaml in the current
+// directory or one of its parents, or "" if there is none
+func findProjectConfig() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return findConfigUpward(dir)
+}
+
+// findConfigUpward walks from dir up to the filesystem root and returns the
+// first projectConfigName found, so the config nearest dir wins
+func findConfigUpward(dir string) string {
+	for {
+		path := filepath.Join(dir, projectConfigName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// gcloudEnvVar names the environment variable that sets the gcloud executable
+// when --gcloud-path is not given
+const gcloudEnvVar = "GCDIFF_GCLOUD"
+
+// resolveGcloudPath returns the gcloud executable to run: the --gcloud-path
+// flag wins, then GCDIFF_GCLOUD, then gcloud on PATH
+func resolveGcloudPath(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if path := os.Getenv(gcloudEnvVar); path != "" {
+		return path
+	}
+	return gcp.DefaultBinary
+}
+
+// resolveConfigFile returns the explicit config file to use: the --config flag
+// wins, then GCDIFF_CONFIG. An empty result means the standard search paths apply.
+func resolveConfigFile(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return os.Getenv(configEnvVar)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveConfigFile_FromEnv(t *testing.T) {
+	t.Setenv(configEnvVar, "/etc/gcdiff/config.yaml")
+
+	if got := resolveConfigFile(""); got != "/etc/gcdiff/config.yaml" {
+		t.Errorf("Expected config path from %s, got %q", configEnvVar, got)
+	}
+}
+
+func TestResolveConfigFile_FlagTakesPrecedence(t *testing.T) {
+	t.Setenv(configEnvVar, "/etc/gcdiff/config.yaml")
+
+	if got := resolveConfigFile("./local.yaml"); got != "./local.yaml" {
+		t.Errorf("Expected --config to take precedence, got %q", got)
+	}
+}
+
+func TestResolveConfigFile_Unset(t *testing.T) {
+	t.Setenv(configEnvVar, "")
+
+	if got := resolveConfigFile(""); got != "" {
+		t.Errorf("Expected empty path when neither flag nor env var is set, got %q", got)
+	}
+}
+
+func TestFindConfigUpward(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "project")
+	nested := filepath.Join(project, "envs", "prod")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{root, project} {
+		if err := os.WriteFile(filepath.Join(dir, projectConfigName), []byte("project1: p\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The nearest config wins over ones further up
+	if got := findConfigUpward(nested); got != filepath.Join(project, projectConfigName) {
+		t.Errorf("Expected the project config, got %q", got)
+	}
+	if got := findConfigUpward(project); got != filepath.Join(project, projectConfigName) {
+		t.Errorf("Expected the config in the directory itself, got %q", got)
+	}
+
+	// A directory of that name is not a config
+	if err := os.Mkdir(filepath.Join(nested, projectConfigName), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := findConfigUpward(nested); got != filepath.Join(project, projectConfigName) {
+		t.Errorf("Expected a directory named %s to be skipped, got %q", projectConfigName, got)
+	}
+}
+
+func TestResolveGcloudPath(t *testing.T) {
+	t.Setenv(gcloudEnvVar, "")
+	if got := resolveGcloudPath(""); got != "gcloud" {
+		t.Errorf("Expected gcloud on PATH by default, got %q", got)
+	}
+
+	t.Setenv(gcloudEnvVar, "/usr/lib/google-cloud-sdk/bin/gcloud")
+	if got := resolveGcloudPath(""); got != "/usr/lib/google-cloud-sdk/bin/gcloud" {
+		t.Errorf("Expected gcloud path from %s, got %q", gcloudEnvVar, got)
+	}
+	if got := resolveGcloudPath("/opt/gcloud"); got != "/opt/gcloud" {
+		t.Errorf("Expected --gcloud-path to take precedence, got %q", got)
+	}
+}
+
+func TestApplyConfigColors_EveryCommand(t *testing.T) {
+	useFakeRunner(t, &fakeRunner{responses: map[string]string{
+		"compute instances describe web-1": `{"labels": {"owner": "web-team"}}`,
+	}})
+	path := writeConfig(t, "colors:\n  sparkle: red\n")
+
+	_, stderr, err := executeCommandOutput(t, context.Background(), "labels-check", "compute instances", "web-1",
+		"--project1=proj", "--zone=us-central1-a", "--required=owner", "--config="+path)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(stderr, `Warning: unknown color role "sparkle"`) {
+		t.Errorf("Expected the config's colors to be applied to labels-check, got:\n%s", stderr)
+	}
+}
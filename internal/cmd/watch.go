@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/tflynn3/gcdiff/internal/compare"
+)
+
+// clearScreen moves the cursor to the top-left corner and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+var (
+	watchHeader  = color.New(color.Bold).SprintFunc()
+	watchChanged = color.New(color.FgYellow, color.Bold).SprintFunc()
+)
+
+// watchDiff re-runs compareOnce every interval and reprints the diff under a
+// timestamp header until the command's context is cancelled or SIGINT arrives.
+// Fetch errors are shown and retried; an error printing the diff stops it.
+func watchDiff(cmd *cobra.Command, interval time.Duration, compareOnce func(context.Context) (*compare.Diff, error), printDiff func(*compare.Diff) error) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+
+	out := cmd.OutOrStdout()
+	var previous *compare.Diff
+	for {
+		diff, err := compareOnce(ctx)
+		if err != nil && ctx.Err() != nil {
+			// Interrupted mid-fetch
+			return nil
+		}
+
+		fmt.Fprint(out, clearScreen)
+		fmt.Fprintf(out, "%s\n\n", watchHeader(fmt.Sprintf("Every %s: %s", interval, time.Now().Format("2006-01-02 15:04:05"))))
+
+		if err != nil {
+			// Keep watching; resources are often briefly unavailable mid-deployment
+			fmt.Fprintf(out, "Error: %v\n", err)
+		} else {
+			if previous != nil {
+				if changed := changedSince(previous, diff); len(changed) > 0 {
+					fmt.Fprintln(out, watchChanged("Changed since last refresh:"))
+					for _, path := range changed {
+						fmt.Fprintf(out, "  %s\n", watchChanged(path))
+					}
+					fmt.Fprintln(out)
+				}
+			}
+			if err := printDiff(diff); err != nil {
+				return err
+			}
+			previous = diff
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// changedSince returns the sorted paths of leaf differences that appeared,
+// disappeared or changed between two watch cycles
+func changedSince(previous, current *compare.Diff) []string {
+	before := leafDiffsByPath(previous)
+	after := leafDiffsByPath(current)
+
+	var changed []string
+	for path, diff := range after {
+		if old, ok := before[path]; !ok || !reflect.DeepEqual(old, diff) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func leafDiffsByPath(diff *compare.Diff) map[string]*compare.Diff {
+	leaves := make(map[string]*compare.Diff)
+	for _, leaf := range compare.GetAllDiffs(diff) {
+		leaves[leaf.Path] = leaf
+	}
+	return leaves
+}
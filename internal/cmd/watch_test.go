@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/compare"
+)
+
+func TestRunResource_WatchTwoCycles(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Each cycle fetches both resources; stop once the second round is in
+	runner := &fakeRunner{onCall: func(calls int) {
+		if calls == 4 {
+			cancel()
+		}
+	}}
+	useFakeRunner(t, runner)
+
+	output, err := executeCommandContext(t, ctx, "resource", "compute instances", "vm-1", "vm-2",
+		"--project1=proj", "--zone1=us-central1-a", "--watch=10ms")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if runner.callCount() != 4 {
+		t.Errorf("Expected 2 fetch rounds (4 gcloud invocations), got %d invocations", runner.callCount())
+	}
+	if got := strings.Count(output, "Every 10ms:"); got != 2 {
+		t.Errorf("Expected 2 timestamp headers, got %d:\n%s", got, output)
+	}
+}
+
+func TestChangedSince(t *testing.T) {
+	previous := &compare.Diff{
+		Type: compare.DiffTypeModified,
+		Children: map[string]*compare.Diff{
+			"machineType": {Path: "machineType", Type: compare.DiffTypeModified, Value1: "n1-standard-2", Value2: "n1-standard-4"},
+			"labels.env":  {Path: "labels.env", Type: compare.DiffTypeAdded, Value2: "prod"},
+		},
+	}
+	current := &compare.Diff{
+		Type: compare.DiffTypeModified,
+		Children: map[string]*compare.Diff{
+			"machineType": {Path: "machineType", Type: compare.DiffTypeModified, Value1: "n1-standard-2", Value2: "n1-standard-8"},
+			"status":      {Path: "status", Type: compare.DiffTypeModified, Value1: "RUNNING", Value2: "STOPPING"},
+		},
+	}
+
+	expected := []string{"labels.env", "machineType", "status"}
+	if got := changedSince(previous, current); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if got := changedSince(current, current); len(got) != 0 {
+		t.Errorf("Expected no changes between identical cycles, got %v", got)
+	}
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"strconv"
+)
+
+// outputWidth returns the number of columns to wrap values to: $COLUMNS when
+// set, otherwise the width of the terminal w writes to, or 0 when unknown
+func outputWidth(w io.Writer) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	if f, ok := w.(*os.File); ok {
+		return terminalWidth(f)
+	}
+	return 0
+}
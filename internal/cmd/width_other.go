@@ -0,0 +1,10 @@
+//go:build !unix
+
+package cmd
+
+import "os"
+
+// terminalWidth reports an unknown width where terminal sizes aren't queried
+func terminalWidth(f *os.File) int {
+	return 0
+}
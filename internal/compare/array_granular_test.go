@@ -1,6 +1,8 @@
 package compare
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/tflynn3/gcdiff/internal/config"
@@ -172,3 +174,396 @@ func TestCompare_ArrayComplexNestedChange(t *testing.T) {
 		t.Errorf("Expected port change from 443 to 8443, got %v to %v", portDiff.Value1, portDiff.Value2)
 	}
 }
+
+// TestCompare_ArraySimilarityPairsSingleFieldChange tests that a mostly-equal
+// element moved to a new index is paired as a modification
+func TestCompare_ArraySimilarityPairsSingleFieldChange(t *testing.T) {
+	cfg := config.Default()
+	cfg.ArraySimilarityThreshold = 0.5
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{"name": "http", "protocol": "tcp", "port": 80},
+			map[string]interface{}{"name": "https", "protocol": "tcp", "port": 443},
+		},
+	}
+
+	obj2 := map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{"name": "dns", "protocol": "udp", "port": 53},
+			map[string]interface{}{"name": "http", "protocol": "tcp", "port": 80},
+			map[string]interface{}{"name": "https", "protocol": "tcp", "port": 8443},
+		},
+	}
+
+	diff := d.Compare(obj1, obj2)
+
+	rulesDiff := diff.Children["rules"]
+	if rulesDiff == nil {
+		t.Fatal("Expected 'rules' field in children")
+	}
+
+	if len(rulesDiff.Children) != 2 {
+		t.Fatalf("Expected 2 changed elements (one added, one modified), got %d: %v", len(rulesDiff.Children), rulesDiff.Children)
+	}
+
+	added := rulesDiff.Children["[0]"]
+	if added == nil || added.Type != DiffTypeAdded {
+		t.Errorf("Expected the dns rule at [0] to be added, got %v", added)
+	}
+
+	modified := rulesDiff.Children["[2]"]
+	if modified == nil || modified.Type != DiffTypeModified {
+		t.Fatalf("Expected the https rule to be paired as modified, got %v", modified)
+	}
+
+	portDiff := modified.Children["port"]
+	if portDiff == nil || portDiff.Value1 != 443 || portDiff.Value2 != 8443 {
+		t.Errorf("Expected port change from 443 to 8443, got %v", portDiff)
+	}
+}
+
+// TestCompare_ArraySimilarityDoesNotPairDifferentObjects tests that wholly
+// different elements are reported as removed and added
+func TestCompare_ArraySimilarityDoesNotPairDifferentObjects(t *testing.T) {
+	cfg := config.Default()
+	cfg.ArraySimilarityThreshold = 0.5
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{"name": "http", "protocol": "tcp", "port": 80},
+		},
+	}
+
+	obj2 := map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{"name": "dns", "protocol": "udp", "port": 53},
+		},
+	}
+
+	diff := d.Compare(obj1, obj2)
+
+	rulesDiff := diff.Children["rules"]
+	if rulesDiff == nil {
+		t.Fatal("Expected 'rules' field in children")
+	}
+
+	var added, removed int
+	for _, child := range rulesDiff.Children {
+		switch child.Type {
+		case DiffTypeAdded:
+			added++
+		case DiffTypeRemoved:
+			removed++
+		default:
+			t.Errorf("Unexpected %v child at %s", child.Type, child.Path)
+		}
+	}
+
+	if added != 1 || removed != 1 {
+		t.Errorf("Expected 1 added and 1 removed element, got %d added and %d removed", added, removed)
+	}
+}
+
+// TestCompare_ArraySimilarityScoringIsNotCounted tests that scoring candidate
+// pairs leaves the compared field count to the reported comparison
+func TestCompare_ArraySimilarityScoringIsNotCounted(t *testing.T) {
+	http := map[string]interface{}{"name": "http", "port": 80}
+	dns := map[string]interface{}{"name": "dns", "port": 53}
+
+	sequential := NewDiffer(config.Default(), false)
+	sequential.Compare(
+		map[string]interface{}{"rules": []interface{}{http, dns}},
+		map[string]interface{}{"rules": []interface{}{http, dns}},
+	)
+
+	cfg := config.Default()
+	cfg.ArraySimilarityThreshold = 0.5
+	d := NewDiffer(cfg, false)
+	diff := d.Compare(
+		map[string]interface{}{"rules": []interface{}{http, dns}},
+		map[string]interface{}{"rules": []interface{}{dns, http}},
+	)
+
+	if !diff.IsEmpty() {
+		t.Fatalf("Expected reordered rules to be equal, got %v", GetAllDiffs(diff))
+	}
+	if d.ComparedFields() != sequential.ComparedFields() {
+		t.Errorf("Expected %d compared fields, got %d", sequential.ComparedFields(), d.ComparedFields())
+	}
+}
+
+func TestCompare_ArraySimilarityScoresPathScopedRules(t *testing.T) {
+	cfg := config.Default()
+	cfg.ArraySimilarityThreshold = 0.75
+	cfg.AllowedDelta = map[string]float64{`pools[*]."app.kubernetes.io/replicas"`: 1}
+	// Churn in an ignored field must not lower the score
+	cfg.IgnoreFields = append(cfg.IgnoreFields, "pools[*].fingerprint")
+	web1 := map[string]interface{}{"name": "web", "app.kubernetes.io/replicas": 3, "fingerprint": "a1"}
+	web2 := map[string]interface{}{"name": "web", "app.kubernetes.io/replicas": 3.5, "fingerprint": "b2"}
+	db := map[string]interface{}{"name": "db", "app.kubernetes.io/replicas": 1}
+
+	diff := NewDiffer(cfg, false).Compare(
+		map[string]interface{}{"pools": []interface{}{web1, db}},
+		map[string]interface{}{"pools": []interface{}{db, web2}},
+	)
+
+	if !diff.IsEmpty() {
+		t.Errorf("Expected web to pair with itself within tolerance, got %v", GetAllDiffs(diff))
+	}
+}
+
+func TestCompare_ArraySimilarityCountsUnpairedElements(t *testing.T) {
+	cfg := config.Default()
+	cfg.ArraySimilarityThreshold = 0.5
+	d := NewDiffer(cfg, false)
+	d.Compare(
+		map[string]interface{}{"rules": []interface{}{map[string]interface{}{"name": "http", "port": 80}}},
+		map[string]interface{}{"rules": []interface{}{map[string]interface{}{"name": "dns", "port": 53}}},
+	)
+
+	// One removed element and one added, as compareArraysByKey counts them
+	if d.ComparedFields() != 2 {
+		t.Errorf("Expected 2 compared fields, got %d", d.ComparedFields())
+	}
+}
+
+// TestCompare_ArraySimilarityThresholdTuning tests that a stricter threshold
+// stops a single-field change from pairing
+func TestCompare_ArraySimilarityThresholdTuning(t *testing.T) {
+	obj1 := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"a": 1, "b": 2, "c": 3},
+		},
+	}
+	obj2 := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"a": 1, "b": 2, "c": 4},
+		},
+	}
+
+	cfg := config.Default()
+	cfg.ArraySimilarityThreshold = 0.6
+	paired := NewDiffer(cfg, false).Compare(obj1, obj2).Children["items"]
+	if child := paired.Children["[0]"]; child == nil || child.Type != DiffTypeModified || len(paired.Children) != 1 {
+		t.Errorf("Expected elements sharing 2/3 fields to pair at threshold 0.6, got %v", paired.Children)
+	}
+
+	cfg = config.Default()
+	cfg.ArraySimilarityThreshold = 0.9
+	unpaired := NewDiffer(cfg, false).Compare(obj1, obj2).Children["items"]
+	if len(unpaired.Children) != 2 {
+		t.Errorf("Expected add+remove at threshold 0.9, got %v", unpaired.Children)
+	}
+	removed := unpaired.Children["[removed:0]"]
+	if removed == nil || removed.Type != DiffTypeRemoved {
+		t.Fatalf("Expected removed element under its own key, got %v", unpaired.Children)
+	}
+	if added := unpaired.Children["[0]"]; added == nil || added.Path == removed.Path {
+		t.Errorf("Expected added and removed elements on distinct paths, got %v", unpaired.Children)
+	}
+}
+
+// TestCompare_MaxArrayElementsCollapses tests that arrays above the limit are
+// summarized by length instead of compared element-wise
+func TestCompare_MaxArrayElementsCollapses(t *testing.T) {
+	cfg := config.Default()
+	cfg.MaxArrayElements = 3
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{
+		"rules": []interface{}{"a", "b", "c", "d"},
+	}
+	obj2 := map[string]interface{}{
+		"rules": []interface{}{"a", "b", "x", "d", "e"},
+	}
+
+	diff := d.Compare(obj1, obj2)
+
+	rulesDiff := diff.Children["rules"]
+	if rulesDiff == nil {
+		t.Fatal("Expected 'rules' field in children")
+	}
+	if rulesDiff.Type != DiffTypeModified || len(rulesDiff.Children) != 0 {
+		t.Fatalf("Expected a single collapsed modification, got %v with children %v", rulesDiff.Type, rulesDiff.Children)
+	}
+	if rulesDiff.Value1 != 4 || rulesDiff.Value2 != 5 {
+		t.Errorf("Expected array lengths 4 and 5, got %v and %v", rulesDiff.Value1, rulesDiff.Value2)
+	}
+	if rulesDiff.Note == "" {
+		t.Error("Expected a note explaining the collapse")
+	}
+}
+
+// TestCompare_MaxArrayElementsEqualLargeArrays tests that identical oversized
+// arrays still compare equal
+func TestCompare_MaxArrayElementsEqualLargeArrays(t *testing.T) {
+	cfg := config.Default()
+	cfg.MaxArrayElements = 2
+	d := NewDiffer(cfg, false)
+
+	obj := map[string]interface{}{"rules": []interface{}{"a", "b", "c"}}
+	diff := d.Compare(obj, map[string]interface{}{"rules": []interface{}{"a", "b", "c"}})
+
+	if diff.Type != DiffTypeEqual {
+		t.Errorf("Expected equal oversized arrays to be equal, got %v", diff.Type)
+	}
+}
+
+// TestCompare_MaxArrayElementsBelowLimit tests normal element-wise behavior
+// below the limit
+func TestCompare_MaxArrayElementsBelowLimit(t *testing.T) {
+	cfg := config.Default()
+	cfg.MaxArrayElements = 10
+	d := NewDiffer(cfg, false)
+
+	diff := d.Compare(
+		map[string]interface{}{"rules": []interface{}{"a", "b"}},
+		map[string]interface{}{"rules": []interface{}{"a", "c"}},
+	)
+
+	rulesDiff := diff.Children["rules"]
+	if rulesDiff == nil || rulesDiff.Children["[1]"] == nil {
+		t.Fatal("Expected element-wise diff at [1] below the limit")
+	}
+	if rulesDiff.Note != "" {
+		t.Error("Expected no collapse note below the limit")
+	}
+}
+
+func firewallAllowed(entries ...[]interface{}) []interface{} {
+	allowed := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		allowed[i] = map[string]interface{}{"IPProtocol": entry[0], "ports": entry[1:]}
+	}
+	return allowed
+}
+
+func TestCompare_ArrayKeysCompositeKey(t *testing.T) {
+	cfg := config.Default()
+	cfg.ArrayKeys = map[string][]string{"allowed": {"IPProtocol", "ports[0]"}}
+	d := NewDiffer(cfg, false)
+
+	rule1 := map[string]interface{}{"allowed": firewallAllowed(
+		[]interface{}{"tcp", "80"},
+		[]interface{}{"tcp", "443"},
+		[]interface{}{"udp", "53"},
+	)}
+	rule2 := map[string]interface{}{"allowed": firewallAllowed(
+		[]interface{}{"udp", "53"},
+		[]interface{}{"tcp", "443", "8443"},
+		[]interface{}{"tcp", "80"},
+	)}
+
+	diffs := GetAllDiffs(d.Compare(rule1, rule2))
+
+	// Only the tcp/443 entry changed; the reordering is not a difference
+	if len(diffs) != 1 || diffs[0].Path != "allowed[1].ports[1]" || diffs[0].Type != DiffTypeAdded {
+		t.Errorf("Expected only allowed[1].ports[1] added, got %v", diffs)
+	}
+}
+
+func TestCompare_ArrayKeysAddedAndRemoved(t *testing.T) {
+	cfg := config.Default()
+	cfg.ArrayKeys = map[string][]string{"allowed": {"IPProtocol", "ports[0]"}}
+	d := NewDiffer(cfg, false)
+
+	diff := d.Compare(
+		map[string]interface{}{"allowed": firewallAllowed([]interface{}{"tcp", "80"}, []interface{}{"tcp", "22"})},
+		map[string]interface{}{"allowed": firewallAllowed([]interface{}{"tcp", "443"}, []interface{}{"tcp", "80"})},
+	)
+
+	children := diff.Children["allowed"].Children
+	if len(children) != 2 || children["[0]"] == nil || children["[0]"].Type != DiffTypeAdded ||
+		children["[removed:1]"] == nil || children["[removed:1]"].Type != DiffTypeRemoved {
+		t.Errorf("Expected tcp/443 added and tcp/22 removed, got %v", GetAllDiffs(diff))
+	}
+}
+
+func TestCompare_ArrayKeysRepeatedKeyFallsBack(t *testing.T) {
+	cfg := config.Default()
+	cfg.ArrayKeys = map[string][]string{"allowed": {"IPProtocol"}}
+	d := NewDiffer(cfg, false)
+
+	diffs := GetAllDiffs(d.Compare(
+		map[string]interface{}{"allowed": firewallAllowed([]interface{}{"tcp", "80"}, []interface{}{"tcp", "443"})},
+		map[string]interface{}{"allowed": firewallAllowed([]interface{}{"tcp", "443"}, []interface{}{"tcp", "80"})},
+	))
+
+	// IPProtocol alone repeats, so elements are compared by index
+	if len(diffs) != 2 {
+		t.Errorf("Expected index comparison when keys repeat, got %v", diffs)
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	obj := map[string]interface{}{
+		"ports": []interface{}{"80", "443"},
+		"meta":  map[string]interface{}{"tags": []interface{}{[]interface{}{"a"}}},
+	}
+
+	tests := map[string]interface{}{
+		"ports[1]":        "443",
+		"meta.tags[0][0]": "a",
+		"ports[5]":        nil,
+		"missing.field":   nil,
+	}
+	for field, want := range tests {
+		if got := fieldValue(obj, field); got != want {
+			t.Errorf("fieldValue(%q) = %v, want %v", field, got, want)
+		}
+	}
+}
+
+func TestPrintGitStyleDiffV2_CompactArrays(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+
+	numbers := func(changes map[int]interface{}, extra ...interface{}) []interface{} {
+		arr := make([]interface{}, 10)
+		for i := range arr {
+			arr[i] = float64(i)
+			if v, ok := changes[i]; ok {
+				arr[i] = v
+			}
+		}
+		return append(arr, extra...)
+	}
+	obj1 := map[string]interface{}{"ports": numbers(nil)}
+	obj2 := map[string]interface{}{"ports": numbers(map[int]interface{}{2: float64(20), 8: float64(80)})}
+
+	var buf bytes.Buffer
+	opts := OutputOptions{Color: ColorNever, CompactArrays: true}
+	PrintGitStyleDiffV2WithOptions(&buf, d.Compare(obj1, obj2), "a", "b", opts)
+	output := buf.String()
+
+	for _, want := range []string{
+		"      [0..1] 2 unchanged elements\n    ~ [2]",
+		"      [3..7] 5 unchanged elements\n    ~ [8]",
+		"      [9] 1 unchanged element\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	// An appended element leaves no trailing run
+	buf.Reset()
+	obj2 = map[string]interface{}{"ports": numbers(nil, float64(10))}
+	PrintGitStyleDiffV2WithOptions(&buf, d.Compare(obj1, obj2), "a", "b", opts)
+	output = buf.String()
+	if !strings.Contains(output, "      [0..9] 10 unchanged elements\n    + [10] 10\n") {
+		t.Errorf("Expected a single leading run, got:\n%s", output)
+	}
+	if strings.Count(output, "unchanged") != 1 {
+		t.Errorf("Expected exactly one unchanged marker, got:\n%s", output)
+	}
+
+	// Without the option no markers are printed
+	buf.Reset()
+	PrintGitStyleDiffV2WithOptions(&buf, d.Compare(obj1, obj2), "a", "b", OutputOptions{Color: ColorNever})
+	if strings.Contains(buf.String(), "unchanged") {
+		t.Errorf("Expected no unchanged markers by default, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,286 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+// arrayPair is a candidate match between arr1[i] and arr2[j]
+type arrayPair struct {
+	i, j       int
+	similarity float64
+}
+
+// compareArraysBySimilarity compares arrays as unordered collections, pairing
+// each element with its most similar counterpart on the other side. Pairs at
+// or above the configured threshold are compared as modifications; anything
+// left over is reported as added or removed.
+//
+// Paired and added elements are keyed by their index in arr2. Removed elements
+// are keyed by removedElementKey.
+func (d *Differ) compareArraysBySimilarity(arr1, arr2 []interface{}, path string) *Diff {
+	diff := &Diff{
+		Path:     path,
+		Type:     DiffTypeEqual,
+		Children: make(map[string]*Diff),
+	}
+
+	threshold := d.config.ArraySimilarityThreshold
+
+	var candidates []arrayPair
+	for i := range arr1 {
+		for j := range arr2 {
+			sim := d.elementSimilarity(arr1[i], arr2[j], fmt.Sprintf("%s[%d]", path, j))
+			if sim >= threshold {
+				candidates = append(candidates, arrayPair{i: i, j: j, similarity: sim})
+			}
+		}
+	}
+
+	// Greedily take the most similar pairs first, preferring original order
+	// on ties so identical arrays pair up index-for-index
+	sort.SliceStable(candidates, func(a, b int) bool {
+		if candidates[a].similarity != candidates[b].similarity {
+			return candidates[a].similarity > candidates[b].similarity
+		}
+		if candidates[a].i != candidates[b].i {
+			return candidates[a].i < candidates[b].i
+		}
+		return candidates[a].j < candidates[b].j
+	})
+
+	matched1 := make(map[int]bool)
+	matched2 := make(map[int]bool)
+	for _, c := range candidates {
+		if matched1[c.i] || matched2[c.j] {
+			continue
+		}
+		matched1[c.i] = true
+		matched2[c.j] = true
+
+		indexPath := fmt.Sprintf("%s[%d]", path, c.j)
+		childDiff := d.compareValues(arr1[c.i], arr2[c.j], indexPath)
+		if childDiff.Type != DiffTypeEqual {
+			diff.Children[fmt.Sprintf("[%d]", c.j)] = childDiff
+			diff.Type = DiffTypeModified
+		} else if d.config.IncludeEqual {
+			diff.Children[fmt.Sprintf("[%d]", c.j)] = withLeafValues(childDiff, arr1[c.i], arr2[c.j])
+		}
+	}
+
+	for j := range arr2 {
+		if matched2[j] {
+			continue
+		}
+		d.compared++
+		diff.Children[fmt.Sprintf("[%d]", j)] = &Diff{
+			Path:   fmt.Sprintf("%s[%d]", path, j),
+			Type:   DiffTypeAdded,
+			Value2: arr2[j],
+		}
+		diff.Type = DiffTypeModified
+	}
+
+	for i := range arr1 {
+		if matched1[i] {
+			continue
+		}
+		d.compared++
+		addRemovedElement(diff, i, arr1[i])
+	}
+
+	return diff
+}
+
+// elementSimilarity returns the fraction of fields two array elements share.
+// Objects score the ratio of equal fields to all fields present on either
+// side, leaving out ignored fields as comparison does; any other values score
+// 1 when equal and 0 otherwise.
+func (d *Differ) elementSimilarity(val1, val2 interface{}, path string) float64 {
+	obj1, ok1 := val1.(map[string]interface{})
+	obj2, ok2 := val2.(map[string]interface{})
+	if !ok1 || !ok2 {
+		if d.equalValues(val1, val2, path) {
+			return 1
+		}
+		return 0
+	}
+
+	keys := make(map[string]bool)
+	for _, obj := range []map[string]interface{}{obj1, obj2} {
+		for k := range obj {
+			if d.showAll || !d.config.ShouldIgnore(joinPath(path, k)) {
+				keys[k] = true
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return 1
+	}
+
+	equal := 0
+	for k := range keys {
+		v1, exists1 := obj1[k]
+		v2, exists2 := obj2[k]
+		if exists1 && exists2 && d.equalValues(v1, v2, joinPath(path, k)) {
+			equal++
+		}
+	}
+
+	return float64(equal) / float64(len(keys))
+}
+
+// equalValues reports whether two values compare equal, without counting
+// fields or recording warnings, so scoring candidate pairs leaves
+// ComparedFields and Warnings to the comparison that is reported
+func (d *Differ) equalValues(val1, val2 interface{}, path string) bool {
+	compared, warnings := d.compared, d.warnings
+	d.warnings = nil
+	equal := d.compareValues(val1, val2, path).Type == DiffTypeEqual
+	d.compared, d.warnings = compared, warnings
+	return equal
+}
+
+// compareArraysByKey compares arrays as unordered collections, pairing the
+// elements whose key fields are all equal. Children are keyed like
+// compareArraysBySimilarity. It returns nil if an element isn't an object or
+// a key repeats within an array, in which case callers fall back to the
+// index-based comparison.
+func (d *Differ) compareArraysByKey(arr1, arr2 []interface{}, path string, fields []string) *Diff {
+	keys1, ok1 := elementKeys(arr1, fields)
+	keys2, ok2 := elementKeys(arr2, fields)
+	if !ok1 || !ok2 {
+		return nil
+	}
+
+	index1 := make(map[string]int, len(keys1))
+	for i, key := range keys1 {
+		index1[key] = i
+	}
+
+	diff := &Diff{
+		Path:     path,
+		Type:     DiffTypeEqual,
+		Children: make(map[string]*Diff),
+	}
+
+	matched1 := make(map[int]bool)
+	for j, key := range keys2 {
+		indexPath := fmt.Sprintf("%s[%d]", path, j)
+		childKey := fmt.Sprintf("[%d]", j)
+
+		i, ok := index1[key]
+		if !ok {
+			d.compared++
+			diff.Children[childKey] = &Diff{Path: indexPath, Type: DiffTypeAdded, Value2: arr2[j]}
+			diff.Type = DiffTypeModified
+			continue
+		}
+		matched1[i] = true
+
+		childDiff := d.compareValues(arr1[i], arr2[j], indexPath)
+		if childDiff.Type != DiffTypeEqual {
+			diff.Children[childKey] = childDiff
+			diff.Type = DiffTypeModified
+		} else if d.config.IncludeEqual {
+			diff.Children[childKey] = withLeafValues(childDiff, arr1[i], arr2[j])
+		}
+	}
+
+	for i := range arr1 {
+		if matched1[i] {
+			continue
+		}
+		d.compared++
+		addRemovedElement(diff, i, arr1[i])
+	}
+
+	return diff
+}
+
+// removedElementKey returns the child key of the unmatched element at index i
+// of the first array. Matchers key paired and added elements by their index in
+// the second array, so removed elements are marked [removed:i] to give them a
+// path of their own.
+func removedElementKey(i int) string {
+	return fmt.Sprintf("[removed:%d]", i)
+}
+
+// addRemovedElement records value, found only at index i of the first array,
+// as removed from the array diff
+func addRemovedElement(diff *Diff, i int, value interface{}) {
+	key := removedElementKey(i)
+	diff.Children[key] = &Diff{Path: diff.Path + key, Type: DiffTypeRemoved, Value1: value}
+	diff.Type = DiffTypeModified
+}
+
+// elementKeys returns the composite key of each array element built from the
+// values of fields. It fails if an element isn't an object or two elements
+// share a key.
+func elementKeys(arr []interface{}, fields []string) ([]string, bool) {
+	keys := make([]string, len(arr))
+	seen := make(map[string]bool, len(arr))
+	for i, element := range arr {
+		obj, ok := element.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		values := make([]interface{}, len(fields))
+		for f, field := range fields {
+			values[f] = fieldValue(obj, field)
+		}
+		encoded, err := json.Marshal(values)
+		if err != nil {
+			return nil, false
+		}
+
+		key := string(encoded)
+		if seen[key] {
+			return nil, false
+		}
+		seen[key] = true
+		keys[i] = key
+	}
+	return keys, true
+}
+
+// fieldValue returns the value at a dotted field path within obj, where a
+// segment may index into arrays as name[n]. Missing values are nil.
+func fieldValue(obj map[string]interface{}, field string) interface{} {
+	value, _ := lookupField(obj, field)
+	return value
+}
+
+// lookupField returns the value at a field path like fieldValue, and whether
+// the path exists at all
+func lookupField(obj map[string]interface{}, field string) (interface{}, bool) {
+	tokens, err := config.SplitPath(field)
+	if err != nil {
+		return nil, false
+	}
+	var current interface{} = obj
+	for _, token := range tokens {
+		if token.Index {
+			n, err := strconv.Atoi(token.Text)
+			array, ok := current.([]interface{})
+			if err != nil || !ok || n < 0 || n >= len(array) {
+				return nil, false
+			}
+			current = array[n]
+			continue
+		}
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[token.Text]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,100 @@
+package compare
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadTemplate loads a policy template of expected field values from a YAML
+// or JSON file
+func LoadTemplate(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var template map[string]interface{}
+	if err := yaml.Unmarshal(data, &template); err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+	}
+	if template == nil {
+		return nil, fmt.Errorf("template %s is empty", path)
+	}
+
+	return template, nil
+}
+
+// TemplateFields returns the leaf field paths specified by a template.
+// Nested objects are descended into; any other value (including arrays) is a leaf.
+func TemplateFields(template map[string]interface{}) []string {
+	var fields []string
+	collectTemplateFields(template, "", &fields)
+	sort.Strings(fields)
+	return fields
+}
+
+func collectTemplateFields(obj map[string]interface{}, path string, fields *[]string) {
+	for key, value := range obj {
+		fieldPath := joinPath(path, key)
+
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			collectTemplateFields(nested, fieldPath, fields)
+		} else {
+			*fields = append(*fields, fieldPath)
+		}
+	}
+}
+
+// Audit compares a live resource against a policy template. Only fields
+// specified in the template are compared; the template is the "before" side,
+// so a removed diff means the field is missing from the live resource.
+func Audit(template, live map[string]interface{}) *Diff {
+	cfg := &config.Config{OnlyFields: TemplateFields(template)}
+	return NewDiffer(cfg, true).Compare(template, live)
+}
+
+// PrintAuditReport prints the violations found by Audit
+func PrintAuditReport(w io.Writer, diff *Diff, name, templatePath string) {
+	PrintAuditReportWithOptions(w, diff, name, templatePath, OutputOptions{})
+}
+
+// PrintAuditReportWithOptions prints a report like PrintAuditReport,
+// rendering values according to opts
+func PrintAuditReportWithOptions(w io.Writer, diff *Diff, name, templatePath string, opts OutputOptions) {
+	opts = opts.withColors()
+	fmt.Fprintf(w, "%s\n", opts.colors.bold(fmt.Sprintf("Auditing: %s against %s", name, templatePath)))
+	fmt.Fprintln(w, strings.Repeat("-", 80))
+
+	violations := GetAllDiffs(diff)
+	if len(violations) == 0 {
+		fmt.Fprintf(w, "%s\n", opts.colors.green("✓ Resource complies with template"))
+		return
+	}
+
+	fmt.Fprintf(w, "\n%s\n\n", opts.colors.bold(fmt.Sprintf("%d violation(s) found:", len(violations))))
+	for _, v := range violations {
+		fmt.Fprintf(w, "  %s %s\n", opts.colors.red("✗"), opts.colors.cyan(v.Path))
+		switch v.Type {
+		case DiffTypeRemoved:
+			fmt.Fprintf(w, "      expected: ")
+			printValue(w, opts, "                ", opts.display(v.Path, v.Value1), opts.colors.green)
+			fmt.Fprintf(w, "      actual:   %s\n", opts.colors.red("<missing>"))
+		case DiffTypeAdded:
+			fmt.Fprintf(w, "      expected: %s\n", opts.colors.green("<absent>"))
+			fmt.Fprintf(w, "      actual:   ")
+			printValue(w, opts, "                ", opts.display(v.Path, v.Value2), opts.colors.red)
+		default:
+			fmt.Fprintf(w, "      expected: ")
+			printValue(w, opts, "                ", opts.display(v.Path, v.Value1), opts.colors.green)
+			fmt.Fprintf(w, "      actual:   ")
+			printValue(w, opts, "                ", opts.display(v.Path, v.Value2), opts.colors.red)
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,110 @@
+package compare
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func auditLiveResource() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "web-1",
+		"machineType": "n1-standard-2",
+		"scheduling": map[string]interface{}{
+			"preemptible":       false,
+			"automaticRestart":  true,
+			"onHostMaintenance": "MIGRATE",
+		},
+		"labels": map[string]interface{}{"env": "prod", "team": "web"},
+	}
+}
+
+func TestAudit_Compliant(t *testing.T) {
+	template := map[string]interface{}{
+		"machineType": "n1-standard-2",
+		"scheduling": map[string]interface{}{
+			"preemptible": false,
+		},
+		"labels": map[string]interface{}{"env": "prod"},
+	}
+
+	diff := Audit(template, auditLiveResource())
+
+	if violations := GetAllDiffs(diff); len(violations) != 0 {
+		t.Errorf("Expected compliant resource, got violations: %v", violations)
+	}
+}
+
+func TestAudit_Violations(t *testing.T) {
+	template := map[string]interface{}{
+		"machineType": "e2-medium",
+		"scheduling": map[string]interface{}{
+			"preemptible": false,
+		},
+		"labels":             map[string]interface{}{"costCenter": "1234"},
+		"deletionProtection": true,
+	}
+
+	diff := Audit(template, auditLiveResource())
+	violations := GetAllDiffs(diff)
+
+	byPath := make(map[string]*Diff)
+	for _, v := range violations {
+		byPath[v.Path] = v
+	}
+
+	if len(violations) != 3 {
+		t.Errorf("Expected 3 violations, got %d: %v", len(violations), violations)
+	}
+	if v := byPath["machineType"]; v == nil || v.Type != DiffTypeModified {
+		t.Error("Expected machineType mismatch")
+	}
+	if v := byPath["labels.costCenter"]; v == nil || v.Type != DiffTypeRemoved {
+		t.Error("Expected missing labels.costCenter")
+	}
+	if v := byPath["deletionProtection"]; v == nil || v.Type != DiffTypeRemoved {
+		t.Error("Expected missing deletionProtection")
+	}
+	if _, ok := byPath["labels.team"]; ok {
+		t.Error("Fields not in the template should be ignored")
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "template.yaml")
+	content := `machineType: n1-standard-2
+scheduling:
+  preemptible: false
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	template, err := LoadTemplate(path)
+	if err != nil {
+		t.Fatalf("LoadTemplate failed: %v", err)
+	}
+
+	fields := TemplateFields(template)
+	if strings.Join(fields, ",") != "machineType,scheduling.preemptible" {
+		t.Errorf("Unexpected template fields: %v", fields)
+	}
+}
+
+func TestPrintAuditReport(t *testing.T) {
+	template := map[string]interface{}{"machineType": "e2-medium", "deletionProtection": true}
+	diff := Audit(template, auditLiveResource())
+
+	var buf bytes.Buffer
+	PrintAuditReport(&buf, diff, "web-1", "policy.yaml")
+	output := buf.String()
+
+	if !strings.Contains(output, "2 violation(s) found") {
+		t.Errorf("Expected violation count, got:\n%s", output)
+	}
+	if !strings.Contains(output, "<missing>") {
+		t.Errorf("Expected missing field to be reported, got:\n%s", output)
+	}
+}
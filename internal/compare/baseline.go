@@ -0,0 +1,69 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// SaveBaseline records the leaf differences of diff in a JSON file so a
+// later run can accept them with ApplyBaseline
+func SaveBaseline(path string, diff *Diff) error {
+	entries := GetAllDiffs(diff)
+	if entries == nil {
+		entries = []*Diff{}
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LoadBaseline loads the accepted differences saved by SaveBaseline
+func LoadBaseline(path string) ([]*Diff, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Diff
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// ApplyBaseline returns a copy of diff without the leaf differences whose
+// path, type and values match an accepted entry, so only new or changed drift
+// remains, along with the number removed. Parents left without differences
+// are dropped as in Diff.Filter.
+func ApplyBaseline(diff *Diff, baseline []*Diff) (*Diff, int) {
+	accepted := make(map[string]bool, len(baseline))
+	for _, entry := range baseline {
+		accepted[baselineKey(entry)] = true
+	}
+
+	removed := 0
+	filtered := diff.Filter(func(leaf *Diff) bool {
+		if leaf.Type != DiffTypeEqual && accepted[baselineKey(leaf)] {
+			removed++
+			return false
+		}
+		return true
+	})
+	return filtered, removed
+}
+
+// baselineKey identifies a leaf difference by its path, type and values.
+// Values are compared by their JSON encoding, as that is how baselines store them.
+func baselineKey(diff *Diff) string {
+	key, _ := json.Marshal(struct {
+		Path   string      `json:"path"`
+		Type   DiffType    `json:"type"`
+		Value1 interface{} `json:"value1"`
+		Value2 interface{} `json:"value2"`
+	}{diff.Path, diff.Type, diff.Value1, diff.Value2})
+	return string(key)
+}
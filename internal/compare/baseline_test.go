@@ -0,0 +1,99 @@
+package compare
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+func TestBaseline_SuppressesAcceptedDifferences(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+	obj1 := map[string]interface{}{
+		"machineType": "n1-standard-2",
+		"labels":      map[string]interface{}{"env": "prod"},
+	}
+	obj2 := map[string]interface{}{
+		"machineType": "n1-standard-4",
+		"labels":      map[string]interface{}{"env": "staging"},
+	}
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := SaveBaseline(path, d.Compare(obj1, obj2)); err != nil {
+		t.Fatalf("SaveBaseline failed: %v", err)
+	}
+	baseline, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline failed: %v", err)
+	}
+	if len(baseline) != 2 {
+		t.Fatalf("Expected 2 baseline entries, got %d", len(baseline))
+	}
+
+	// Same drift on the next run is fully accepted
+	diff, removed := ApplyBaseline(d.Compare(obj1, obj2), baseline)
+	if removed != 2 {
+		t.Errorf("Expected 2 suppressed differences, got %d", removed)
+	}
+	if diff.Type != DiffTypeEqual || len(GetAllDiffs(diff)) != 0 {
+		t.Errorf("Expected no remaining differences, got %v", GetAllDiffs(diff))
+	}
+}
+
+func TestBaseline_NewDifferencesRemain(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+	obj1 := map[string]interface{}{"machineType": "n1-standard-2", "status": "RUNNING"}
+	accepted := map[string]interface{}{"machineType": "n1-standard-4", "status": "RUNNING"}
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := SaveBaseline(path, d.Compare(obj1, accepted)); err != nil {
+		t.Fatalf("SaveBaseline failed: %v", err)
+	}
+	baseline, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline failed: %v", err)
+	}
+
+	// machineType drifted further and status is new drift
+	drifted := map[string]interface{}{"machineType": "n1-standard-8", "status": "STOPPED"}
+	diff, _ := ApplyBaseline(d.Compare(obj1, drifted), baseline)
+
+	if len(GetAllDiffs(diff)) != 2 {
+		t.Errorf("Expected changed and new differences to remain, got %v", GetAllDiffs(diff))
+	}
+}
+
+func TestBaseline_DropsFullyAcceptedParent(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+	obj1 := map[string]interface{}{
+		"machineType": "n1-standard-2",
+		"labels":      map[string]interface{}{"env": "prod", "team": "web"},
+	}
+	accepted := map[string]interface{}{
+		"machineType": "n1-standard-2",
+		"labels":      map[string]interface{}{"env": "staging", "team": "api"},
+	}
+	baseline := GetAllDiffs(d.Compare(obj1, accepted))
+
+	// Every labels change is accepted; only machineType is new drift
+	drifted := map[string]interface{}{
+		"machineType": "n1-standard-4",
+		"labels":      map[string]interface{}{"env": "staging", "team": "api"},
+	}
+	diff, removed := ApplyBaseline(d.Compare(obj1, drifted), baseline)
+
+	if removed != 2 {
+		t.Errorf("Expected 2 suppressed differences, got %d", removed)
+	}
+	if _, ok := diff.Children["labels"]; ok {
+		t.Errorf("Expected the fully accepted labels node to be dropped, got %v", diff.Children)
+	}
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2(&buf, diff, "a", "b")
+	if strings.Contains(buf.String(), "labels") {
+		t.Errorf("Expected no labels line in the output, got:\n%s", buf.String())
+	}
+}
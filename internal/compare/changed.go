@@ -0,0 +1,76 @@
+package compare
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+// LoadChangedFields reads the field paths expected to change, in the format
+// of LoadFieldList
+func LoadChangedFields(path string) ([]string, error) {
+	return LoadFieldList(path)
+}
+
+// LoadFieldList reads field paths, one per line. Blank lines and lines
+// starting with # are skipped.
+func LoadFieldList(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields = append(fields, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read field list %s: %w", path, err)
+	}
+	return fields, nil
+}
+
+// CheckChangedFields audits the leaf differences of diff against the field
+// paths expected to change. A difference is expected when its path is listed
+// or lies beneath a listed path. It returns the differences that weren't
+// expected (unexpected drift) and the listed paths under which nothing
+// changed (missing changes), both in path order.
+func CheckChangedFields(diff *Diff, expected []string) (unexpected []*Diff, missing []string) {
+	changed := make(map[string]bool, len(expected))
+	for _, d := range GetAllDiffs(diff) {
+		covered := false
+		for _, field := range expected {
+			if isPathOrBeneath(d.Path, field) {
+				changed[field] = true
+				covered = true
+			}
+		}
+		if !covered {
+			unexpected = append(unexpected, d)
+		}
+	}
+
+	for _, field := range expected {
+		if !changed[field] {
+			missing = append(missing, field)
+		}
+	}
+	sortPaths(missing)
+	return unexpected, missing
+}
+
+// isPathOrBeneath reports whether fieldPath is matched by field or lies
+// beneath a path it matches, e.g. labels.env or disks[0] beneath labels or
+// disks
+func isPathOrBeneath(fieldPath, field string) bool {
+	return config.MatchPath(field, fieldPath) || config.MatchAncestor(field, fieldPath)
+}
@@ -0,0 +1,88 @@
+package compare
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheckChangedFields_UnexpectedDrift(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"machineType": {Path: "machineType", Type: DiffTypeModified, Value1: "n1", Value2: "n2"},
+			"labels": {Path: "labels", Type: DiffTypeModified, Children: map[string]*Diff{
+				"env":  {Path: "labels.env", Type: DiffTypeModified, Value1: "dev", Value2: "prod"},
+				"team": {Path: "labels.team", Type: DiffTypeAdded, Value2: "web"},
+			}},
+		},
+	}
+
+	unexpected, missing := CheckChangedFields(diff, []string{"labels"})
+
+	if len(unexpected) != 1 || unexpected[0].Path != "machineType" {
+		t.Errorf("Expected machineType to be unexpected drift, got %+v", unexpected)
+	}
+	if len(missing) != 0 {
+		t.Errorf("Expected no missing changes, got %v", missing)
+	}
+}
+
+func TestCheckChangedFields_MissingChange(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"machineType": {Path: "machineType", Type: DiffTypeModified, Value1: "n1", Value2: "n2"},
+			"labels": {Path: "labels", Type: DiffTypeModified, Children: map[string]*Diff{
+				"env":  {Path: "labels.env", Type: DiffTypeModified, Value1: "dev", Value2: "prod"},
+				"team": {Path: "labels.team", Type: DiffTypeAdded, Value2: "web"},
+			}},
+		},
+	}
+
+	expected := []string{"machineType", "labels.env", "labels.team", "scheduling.preemptible", "disks"}
+	unexpected, missing := CheckChangedFields(diff, expected)
+
+	if len(unexpected) != 0 {
+		t.Errorf("Expected no unexpected drift, got %+v", unexpected)
+	}
+	if want := []string{"disks", "scheduling.preemptible"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("Expected missing changes %v, got %v", want, missing)
+	}
+}
+
+func TestCheckChangedFields_PrefixIsNotParent(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"machineType": {Path: "machineType", Type: DiffTypeModified, Value1: "n1", Value2: "n2"},
+			"labels": {Path: "labels", Type: DiffTypeModified, Children: map[string]*Diff{
+				"env":  {Path: "labels.env", Type: DiffTypeModified, Value1: "dev", Value2: "prod"},
+				"team": {Path: "labels.team", Type: DiffTypeAdded, Value2: "web"},
+			}},
+		},
+	}
+
+	// "label" is a prefix of "labels" but not an ancestor
+	unexpected, missing := CheckChangedFields(diff, []string{"label", "machineType"})
+	if len(unexpected) != 2 || !reflect.DeepEqual(missing, []string{"label"}) {
+		t.Errorf("Expected labels.* unexpected and label missing, got %+v, %v", unexpected, missing)
+	}
+}
+
+func TestLoadChangedFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "changes.txt")
+	content := "# expected by PR 42\nmachineType\n\n  labels.env  \n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fields, err := LoadChangedFields(path)
+	if err != nil {
+		t.Fatalf("LoadChangedFields failed: %v", err)
+	}
+	if want := []string{"machineType", "labels.env"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("Expected %v, got %v", want, fields)
+	}
+}
@@ -0,0 +1,34 @@
+package compare
+
+// ChangeTree reshapes diff into a nested object mirroring the compared
+// resources that holds only the changed leaves: {"old": ..., "new": ...}
+// for a modified value, {"added": ...} or {"removed": ...} otherwise. Array
+// elements are keyed by their index, e.g. "[0]". Identical resources give an
+// empty object.
+func ChangeTree(diff *Diff) interface{} {
+	if diff.Type == DiffTypeEqual {
+		return map[string]interface{}{}
+	}
+	return changeTreeNode(diff)
+}
+
+func changeTreeNode(diff *Diff) interface{} {
+	if len(diff.Children) == 0 {
+		switch diff.Type {
+		case DiffTypeAdded:
+			return map[string]interface{}{"added": diff.Value2}
+		case DiffTypeRemoved:
+			return map[string]interface{}{"removed": diff.Value1}
+		default:
+			return map[string]interface{}{"old": diff.Value1, "new": diff.Value2}
+		}
+	}
+
+	node := make(map[string]interface{}, len(diff.Children))
+	for key, child := range diff.Children {
+		if child.Type != DiffTypeEqual {
+			node[key] = changeTreeNode(child)
+		}
+	}
+	return node
+}
@@ -0,0 +1,45 @@
+package compare
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+func TestChangeTree(t *testing.T) {
+	obj1 := map[string]interface{}{
+		"name":        "web",
+		"scheduling":  map[string]interface{}{"preemptible": false, "onHostMaintenance": "MIGRATE"},
+		"disks":       []interface{}{map[string]interface{}{"type": "pd-standard"}},
+		"description": "old",
+	}
+	obj2 := map[string]interface{}{
+		"name":       "web",
+		"scheduling": map[string]interface{}{"preemptible": true, "onHostMaintenance": "MIGRATE"},
+		"disks":      []interface{}{map[string]interface{}{"type": "pd-ssd"}, map[string]interface{}{"type": "local"}},
+		"labels":     map[string]interface{}{"env": "prod"},
+	}
+	cfg := config.Default()
+	cfg.IncludeEqual = true
+	diff := NewDiffer(cfg, false).Compare(obj1, obj2)
+
+	got, err := json.Marshal(ChangeTree(diff))
+	if err != nil {
+		t.Fatalf("Failed to marshal change tree: %v", err)
+	}
+	expected := `{"description":{"removed":"old"},` +
+		`"disks":{"[0]":{"type":{"new":"pd-ssd","old":"pd-standard"}},"[1]":{"added":{"type":"local"}}},` +
+		`"labels":{"added":{"env":"prod"}},` +
+		`"scheduling":{"preemptible":{"new":true,"old":false}}}`
+	if string(got) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestChangeTree_Equal(t *testing.T) {
+	got, _ := json.Marshal(ChangeTree(&Diff{Type: DiffTypeEqual}))
+	if string(got) != "{}" {
+		t.Errorf("Expected an empty object, got %s", got)
+	}
+}
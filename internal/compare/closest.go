@@ -0,0 +1,58 @@
+package compare
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Candidate is one resource compared against a target by RankCandidates
+type Candidate struct {
+	Name string `json:"name"`
+
+	// Differences is the number of leaf differences from the target
+	Differences int   `json:"differences"`
+	Diff        *Diff `json:"diff"`
+}
+
+// RankCandidates diffs target against each candidate and returns them from
+// closest to furthest, by number of differences. Candidates with the same
+// count keep their given order.
+func (d *Differ) RankCandidates(target map[string]interface{}, names []string, candidates []map[string]interface{}) []Candidate {
+	ranked := make([]Candidate, len(candidates))
+	for i, candidate := range candidates {
+		diff := d.Compare(target, candidate)
+		ranked[i] = Candidate{Name: names[i], Differences: len(GetAllDiffs(diff)), Diff: diff}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Differences < ranked[j].Differences
+	})
+	return ranked
+}
+
+// PrintCandidateRanking prints the ranked candidates with their difference
+// counts, followed by the diff from target to the closest one
+func PrintCandidateRanking(w io.Writer, ranked []Candidate, target string, opts OutputOptions) {
+	opts = opts.withColors()
+	fmt.Fprintf(w, "%s\n", opts.colors.bold(fmt.Sprintf("Closest to %s:", target)))
+	fmt.Fprintln(w, strings.Repeat("-", 80))
+
+	width := 0
+	for _, c := range ranked {
+		width = max(width, len(c.Name))
+	}
+	for i, c := range ranked {
+		line := fmt.Sprintf("  %d. %-*s  %d difference(s)", i+1, width, c.Name, c.Differences)
+		if i == 0 {
+			line = opts.colors.green(line)
+		}
+		fmt.Fprintln(w, line)
+	}
+	if len(ranked) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	PrintGitStyleDiffV2WithOptions(w, ranked[0].Diff, target, ranked[0].Name, opts)
+}
@@ -0,0 +1,60 @@
+package compare
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+func TestRankCandidates(t *testing.T) {
+	target := map[string]interface{}{"machineType": "n1-standard-4", "zone": "us-central1-a", "disk": 100}
+	candidates := []map[string]interface{}{
+		{"machineType": "n1-standard-2", "zone": "us-east1-b", "disk": 50},
+		{"machineType": "n1-standard-4", "zone": "us-central1-a", "disk": 50},
+		{"machineType": "n1-standard-2", "zone": "us-central1-a", "disk": 100},
+		{"machineType": "e2-small", "zone": "us-central1-a", "disk": 100},
+	}
+	names := []string{"far", "closest", "tie-1", "tie-2"}
+
+	ranked := NewDiffer(config.Default(), false).RankCandidates(target, names, candidates)
+
+	var got []string
+	for _, c := range ranked {
+		got = append(got, c.Name)
+	}
+	if strings.Join(got, ",") != "closest,tie-1,tie-2,far" {
+		t.Errorf("Expected candidates ranked closest first with ties in order, got %v", got)
+	}
+	if ranked[0].Differences != 1 || ranked[3].Differences != 3 {
+		t.Errorf("Unexpected difference counts %d and %d", ranked[0].Differences, ranked[3].Differences)
+	}
+	if diffs := GetAllDiffs(ranked[0].Diff); len(diffs) != 1 || diffs[0].Path != "disk" {
+		t.Errorf("Expected the closest candidate's diff, got %+v", diffs)
+	}
+}
+
+func TestPrintCandidateRanking(t *testing.T) {
+	ranked := []Candidate{
+		{Name: "staging-2", Differences: 1, Diff: &Diff{Type: DiffTypeModified, Children: map[string]*Diff{
+			"disk": {Path: "disk", Type: DiffTypeModified, Value1: 100, Value2: 50},
+		}}},
+		{Name: "staging-10", Differences: 4},
+	}
+
+	var buf bytes.Buffer
+	PrintCandidateRanking(&buf, ranked, "prod", OutputOptions{Color: ColorNever})
+	output := buf.String()
+
+	for _, want := range []string{
+		"Closest to prod:",
+		"  1. staging-2   1 difference(s)",
+		"  2. staging-10  4 difference(s)",
+		"disk",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package compare
+
+// collapsedSubtree replaces a subtree below the JSON depth limit
+type collapsedSubtree struct {
+	Collapsed bool `json:"_collapsed"`
+	Changes   int  `json:"changes"`
+}
+
+// collapsedNode is a Diff whose children may be collapsedSubtree
+// placeholders. It marshals to the same JSON fields as Diff.
+type collapsedNode struct {
+	Path     string                 `json:"path"`
+	Type     DiffType               `json:"type"`
+	Value1   interface{}            `json:"value1,omitempty"`
+	Value2   interface{}            `json:"value2,omitempty"`
+	Children map[string]interface{} `json:"children,omitempty"`
+	Note     string                 `json:"note,omitempty"`
+}
+
+// CollapseDepth returns a copy of diff for JSON output in which every node
+// deeper than maxDepth (the root is depth 0) is replaced by a
+// {"_collapsed": true, "changes": N} placeholder, N being the number of leaf
+// differences it held. A maxDepth of zero or less keeps the whole tree.
+func CollapseDepth(diff *Diff, maxDepth int) interface{} {
+	if maxDepth <= 0 {
+		return diff
+	}
+	return collapseNode(diff, 0, maxDepth)
+}
+
+func collapseNode(diff *Diff, depth, maxDepth int) interface{} {
+	if depth > maxDepth {
+		return collapsedSubtree{Collapsed: true, Changes: diff.Count()}
+	}
+
+	node := &collapsedNode{
+		Path:   diff.Path,
+		Type:   diff.Type,
+		Value1: diff.Value1,
+		Value2: diff.Value2,
+		Note:   diff.Note,
+	}
+	if len(diff.Children) > 0 {
+		node.Children = make(map[string]interface{}, len(diff.Children))
+		for key, child := range diff.Children {
+			node.Children[key] = collapseNode(child, depth+1, maxDepth)
+		}
+	}
+	return node
+}
@@ -0,0 +1,76 @@
+package compare
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func collapseTestDiff() *Diff {
+	return &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"machineType": {Path: "machineType", Type: DiffTypeModified, Value1: "a", Value2: "b"},
+			"networkInterfaces": {
+				Path: "networkInterfaces",
+				Type: DiffTypeModified,
+				Children: map[string]*Diff{
+					"[0]": {
+						Path: "networkInterfaces[0]",
+						Type: DiffTypeModified,
+						Children: map[string]*Diff{
+							"network":    {Path: "networkInterfaces[0].network", Type: DiffTypeModified, Value1: "a", Value2: "b"},
+							"networkIP":  {Path: "networkInterfaces[0].networkIP", Type: DiffTypeAdded, Value2: "10.0.0.2"},
+							"stackType":  {Path: "networkInterfaces[0].stackType", Type: DiffTypeRemoved, Value1: "IPV4"},
+							"subnetwork": {Path: "networkInterfaces[0].subnetwork", Type: DiffTypeEqual},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// marshalCollapsed round-trips CollapseDepth output through JSON
+func marshalCollapsed(t *testing.T, diff *Diff, maxDepth int) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(CollapseDepth(diff, maxDepth))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	return result
+}
+
+func TestCollapseDepth(t *testing.T) {
+	result := marshalCollapsed(t, collapseTestDiff(), 1)
+	children := result["children"].(map[string]interface{})
+
+	// Depth 1 nodes are kept
+	machineType := children["machineType"].(map[string]interface{})
+	if machineType["value2"] != "b" {
+		t.Errorf("Expected machineType to be kept, got %v", machineType)
+	}
+
+	// Depth 2 nodes are collapsed with the count of changes beneath them
+	interfaces := children["networkInterfaces"].(map[string]interface{})
+	first := interfaces["children"].(map[string]interface{})["[0]"]
+	want := map[string]interface{}{"_collapsed": true, "changes": float64(3)}
+	if !reflect.DeepEqual(first, want) {
+		t.Errorf("Expected %v at depth 2, got %v", want, first)
+	}
+}
+
+func TestCollapseDepth_DeepEnoughKeepsTree(t *testing.T) {
+	diff := collapseTestDiff()
+	full, _ := json.Marshal(diff)
+	for _, maxDepth := range []int{0, 3} {
+		collapsed, _ := json.Marshal(CollapseDepth(diff, maxDepth))
+		if string(collapsed) != string(full) {
+			t.Errorf("maxDepth %d: expected the full tree, got %s", maxDepth, collapsed)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package compare
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// palette holds the color of each configurable role (see defaultColors) and is
+// changed with SetColors
+var palette map[string]color.Attribute
+
+// ColorMode selects when text output is colorized
+type ColorMode string
+
+const (
+	// ColorAuto colorizes output when stdout is a terminal and NO_COLOR is unset
+	ColorAuto ColorMode = "auto"
+	// ColorAlways always colorizes output, e.g. when piping to less -R
+	ColorAlways ColorMode = "always"
+	// ColorNever never colorizes output
+	ColorNever ColorMode = "never"
+)
+
+// ParseColorMode parses a --color value; an empty value means ColorAuto
+func ParseColorMode(mode string) (ColorMode, error) {
+	switch ColorMode(mode) {
+	case "", ColorAuto:
+		return ColorAuto, nil
+	case ColorAlways, ColorNever:
+		return ColorMode(mode), nil
+	default:
+		return "", fmt.Errorf("unknown color mode %q (expected: auto, always, never)", mode)
+	}
+}
+
+// renderer holds the color funcs one output call renders with. green, red,
+// yellow, cyan and bold render added, removed, modified, field names and
+// headers respectively; gray (context and notes) is fixed.
+type renderer struct {
+	green  func(a ...interface{}) string
+	red    func(a ...interface{}) string
+	yellow func(a ...interface{}) string
+	cyan   func(a ...interface{}) string
+	bold   func(a ...interface{}) string
+	gray   func(a ...interface{}) string
+}
+
+// newRenderer creates a renderer using the current palette, colorizing as
+// mode says
+func newRenderer(mode ColorMode) *renderer {
+	sprint := func(attribute color.Attribute) func(a ...interface{}) string {
+		c := color.New(attribute)
+		switch mode {
+		case ColorAlways:
+			c.EnableColor()
+		case ColorNever:
+			c.DisableColor()
+		}
+		return c.SprintFunc()
+	}
+	return &renderer{
+		green:  sprint(palette["added"]),
+		red:    sprint(palette["removed"]),
+		yellow: sprint(palette["modified"]),
+		cyan:   sprint(palette["field"]),
+		bold:   sprint(palette["header"]),
+		gray:   sprint(color.FgHiBlack),
+	}
+}
+
+// defaultColors are the colors of each configurable role
+var defaultColors = map[string]color.Attribute{
+	"added":    color.FgGreen,
+	"removed":  color.FgRed,
+	"modified": color.FgYellow,
+	"field":    color.FgCyan,
+	"header":   color.Bold,
+}
+
+// colorNames maps the color names accepted by SetColors to attributes
+var colorNames = map[string]color.Attribute{
+	"black":          color.FgBlack,
+	"red":            color.FgRed,
+	"green":          color.FgGreen,
+	"yellow":         color.FgYellow,
+	"blue":           color.FgBlue,
+	"magenta":        color.FgMagenta,
+	"cyan":           color.FgCyan,
+	"white":          color.FgWhite,
+	"bright-black":   color.FgHiBlack,
+	"bright-red":     color.FgHiRed,
+	"bright-green":   color.FgHiGreen,
+	"bright-yellow":  color.FgHiYellow,
+	"bright-blue":    color.FgHiBlue,
+	"bright-magenta": color.FgHiMagenta,
+	"bright-cyan":    color.FgHiCyan,
+	"bright-white":   color.FgHiWhite,
+	"bold":           color.Bold,
+}
+
+func init() {
+	SetColors(nil)
+}
+
+// SetColors sets the colors of the diff markers from a map of role (added,
+// removed, modified, field, header) to color name (e.g. blue, bright-red,
+// bold). Roles not in the map use their default color. Unknown roles and
+// color names are skipped and described in the returned warnings.
+func SetColors(colors map[string]string) []string {
+	attributes := make(map[string]color.Attribute, len(defaultColors))
+	for role, attribute := range defaultColors {
+		attributes[role] = attribute
+	}
+
+	var warnings []string
+	for _, role := range sortedColorRoles(colors) {
+		name := colors[role]
+		if _, ok := defaultColors[role]; !ok {
+			warnings = append(warnings, fmt.Sprintf("unknown color role %q (expected one of: added, removed, modified, field, header)", role))
+			continue
+		}
+		attribute, ok := colorNames[strings.ToLower(name)]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("unknown color %q for %s; using the default", name, role))
+			continue
+		}
+		attributes[role] = attribute
+	}
+
+	palette = attributes
+
+	return warnings
+}
+
+func sortedColorRoles(colors map[string]string) []string {
+	roles := make([]string, 0, len(colors))
+	for role := range colors {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
@@ -0,0 +1,102 @@
+package compare
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestSetColors_ConfiguredColor(t *testing.T) {
+	original := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = original }()
+	defer SetColors(nil)
+
+	if warnings := SetColors(map[string]string{"added": "blue", "field": "Magenta"}); len(warnings) != 0 {
+		t.Fatalf("Expected no warnings, got %v", warnings)
+	}
+
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"labels": {Path: "labels", Type: DiffTypeAdded, Value2: "prod"},
+		},
+	}
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2(&buf, diff, "a", "b")
+	output := buf.String()
+
+	if !strings.Contains(output, "\x1b[34m+") {
+		t.Errorf("Expected added marker in blue (34), got %q", output)
+	}
+	if !strings.Contains(output, "\x1b[35mlabels") {
+		t.Errorf("Expected field name in magenta (35), got %q", output)
+	}
+	if strings.Contains(output, "\x1b[32m") {
+		t.Errorf("Expected default green to be replaced, got %q", output)
+	}
+}
+
+func TestSetColors_InvalidNameFallsBack(t *testing.T) {
+	original := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = original }()
+	defer SetColors(nil)
+
+	warnings := SetColors(map[string]string{"removed": "chartreuse", "background": "blue"})
+	if len(warnings) != 2 {
+		t.Fatalf("Expected 2 warnings, got %v", warnings)
+	}
+	if !strings.Contains(warnings[0], "background") || !strings.Contains(warnings[1], "chartreuse") {
+		t.Errorf("Unexpected warnings: %v", warnings)
+	}
+
+	if got := newRenderer(ColorAuto).red("-"); got != "\x1b[31m-\x1b[0m" {
+		t.Errorf("Expected removed marker to keep default red, got %q", got)
+	}
+}
+
+func TestOutputOptions_ColorMode(t *testing.T) {
+	original := color.NoColor
+	defer func() { color.NoColor = original }()
+
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"machineType": {Path: "machineType", Type: DiffTypeModified, Value1: "n1-standard-2", Value2: "n1-standard-4"},
+		},
+	}
+	render := func(mode ColorMode) string {
+		var buf bytes.Buffer
+		PrintGitStyleDiffV2WithOptions(&buf, diff, "a", "b", OutputOptions{Color: mode})
+		return buf.String()
+	}
+
+	// The mode wins over the global terminal detection either way
+	color.NoColor = true
+	if output := render(ColorAlways); !strings.Contains(output, "\x1b[33m~") {
+		t.Errorf("Expected colored output with ColorAlways, got %q", output)
+	}
+	color.NoColor = false
+	if output := render(ColorNever); strings.Contains(output, "\x1b[") {
+		t.Errorf("Expected no ANSI codes with ColorNever, got %q", output)
+	}
+	if output := render(ColorAuto); !strings.Contains(output, "\x1b[33m~") {
+		t.Errorf("Expected ColorAuto to follow color.NoColor, got %q", output)
+	}
+}
+
+func TestParseColorMode(t *testing.T) {
+	for input, want := range map[string]ColorMode{"": ColorAuto, "auto": ColorAuto, "always": ColorAlways, "never": ColorNever} {
+		got, err := ParseColorMode(input)
+		if err != nil || got != want {
+			t.Errorf("ParseColorMode(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseColorMode("sometimes"); err == nil {
+		t.Error("Expected an error for an unknown color mode")
+	}
+}
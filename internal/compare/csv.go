@@ -0,0 +1,43 @@
+package compare
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+)
+
+// WriteCSV writes each leaf difference as a row of path, change, before and
+// after under a header row, for review in a spreadsheet. Identical resources
+// produce only the header.
+func WriteCSV(w io.Writer, diff *Diff) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"path", "change", "before", "after"}); err != nil {
+		return err
+	}
+	for _, d := range GetAllDiffs(diff) {
+		row := []string{
+			d.Path,
+			string(d.Type),
+			csvValue(d.Value1, d.Type == DiffTypeAdded),
+			csvValue(d.Value2, d.Type == DiffTypeRemoved),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvValue renders a value for a CSV cell: strings as is, anything else as
+// JSON, and a missing side as an empty cell
+func csvValue(v interface{}, absent bool) string {
+	if absent {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
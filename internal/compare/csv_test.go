@@ -0,0 +1,53 @@
+package compare
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"description": {Path: "description", Type: DiffTypeModified, Value1: "web, primary", Value2: `say "hi"`},
+			"labels":      {Path: "labels", Type: DiffTypeAdded, Value2: map[string]interface{}{"env": "prod"}},
+			"diskSizeGb":  {Path: "diskSizeGb", Type: DiffTypeRemoved, Value1: 10},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, diff); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+
+	expected := "path,change,before,after\n" +
+		"description,modified,\"web, primary\",\"say \"\"hi\"\"\"\n" +
+		"diskSizeGb,removed,10,\n" +
+		"labels,added,,\"{\"\"env\"\":\"\"prod\"\"}\"\n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("Output is not valid CSV: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("Expected a header and 3 rows, got %d", len(rows))
+	}
+	if rows[1][2] != "web, primary" || rows[3][3] != `{"env":"prod"}` {
+		t.Errorf("Expected values to read back unchanged, got %q", rows)
+	}
+}
+
+func TestWriteCSV_Equal(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, &Diff{Type: DiffTypeEqual}); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	if buf.String() != "path,change,before,after\n" {
+		t.Errorf("Expected only the header, got %q", buf.String())
+	}
+}
@@ -1,9 +1,13 @@
 package compare
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/tflynn3/gcdiff/internal/config"
 )
@@ -25,28 +29,132 @@ type Diff struct {
 	Value1   interface{}      `json:"value1,omitempty"`
 	Value2   interface{}      `json:"value2,omitempty"`
 	Children map[string]*Diff `json:"children,omitempty"`
+
+	// Note explains a diff whose values are a summary rather than the
+	// compared data (e.g. a collapsed oversized array)
+	Note string `json:"note,omitempty"`
+
+	// Length1 and Length2 are the lengths of the arrays an element-level
+	// array diff compared, so renderers can summarize unchanged elements
+	Length1 int `json:"-"`
+	Length2 int `json:"-"`
 }
 
 // Differ performs deep comparison of objects
 type Differ struct {
 	config  *config.Config
 	showAll bool
+
+	// compared counts the leaf fields visited by the last Compare
+	compared int
+
+	// warnings collects problems found by the last Compare, keyed by message
+	// so repeated comparisons of the same objects report them once
+	warnings map[string]bool
+
+	// parallel is the number of goroutines that compare top-level fields;
+	// 0 or 1 compares sequentially
+	parallel int
+
+	// tolerance is the largest difference between two numbers that still
+	// compare equal
+	tolerance float64
 }
 
-// NewDiffer creates a new Differ
+// NewDiffer creates a new Differ; see NewDifferWithOptions for more settings
 func NewDiffer(cfg *config.Config, showAll bool) *Differ {
-	if cfg == nil {
-		cfg = config.Default()
-	}
-	return &Differ{
-		config:  cfg,
-		showAll: showAll,
-	}
+	return NewDifferWithOptions(WithConfig(cfg), WithShowAll(showAll))
+}
+
+// SetParallel makes Compare diff the top-level fields of two objects on up to
+// workers goroutines. The result is the same as comparing sequentially.
+func (d *Differ) SetParallel(workers int) {
+	d.parallel = workers
 }
 
 // Compare compares two objects and returns differences
 func (d *Differ) Compare(obj1, obj2 map[string]interface{}) *Diff {
-	return d.compareObjects(obj1, obj2, "")
+	return d.CompareValues(obj1, obj2)
+}
+
+// CompareValues compares two values of any JSON type, such as the top-level
+// arrays of list output, and returns differences. Paths beneath a top-level
+// array start at its index, e.g. "[0].name".
+func (d *Differ) CompareValues(val1, val2 interface{}) *Diff {
+	d.compared = 0
+	d.warnings = nil
+	return d.compareValues(d.migrateFields(val1), d.migrateFields(val2), "")
+}
+
+// Warnings returns the problems found by the last Compare in sorted order,
+// such as keys dropped because they collided after applying KeyAliases
+func (d *Differ) Warnings() []string {
+	warnings := make([]string, 0, len(d.warnings))
+	for warning := range d.warnings {
+		warnings = append(warnings, warning)
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+func (d *Differ) warn(format string, args ...interface{}) {
+	if d.warnings == nil {
+		d.warnings = make(map[string]bool)
+	}
+	d.warnings[fmt.Sprintf(format, args...)] = true
+}
+
+// canonicalKeys returns obj with its keys renamed by KeyAliases. When several
+// keys map to the same name, the key already spelled that way wins, then the
+// alias that sorts first; the losers are dropped with a warning so the result
+// never depends on map iteration order.
+func (d *Differ) canonicalKeys(obj map[string]interface{}, path string) map[string]interface{} {
+	if len(d.config.KeyAliases) == 0 {
+		return obj
+	}
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make(map[string]interface{}, len(obj))
+	source := make(map[string]string, len(obj))
+	for _, key := range keys {
+		canonical := key
+		if alias, ok := d.config.KeyAliases[key]; ok {
+			canonical = alias
+		}
+
+		winner, taken := source[canonical]
+		if taken && key != canonical {
+			d.warn("%s: ignoring key %q because %q also maps to %q", joinPath(path, key), key, winner, canonical)
+			continue
+		}
+		if taken {
+			d.warn("%s: ignoring key %q because %q also maps to %q", joinPath(path, winner), winner, key, canonical)
+		}
+		result[canonical] = obj[key]
+		source[canonical] = key
+	}
+	return result
+}
+
+// joinPath appends key to a dotted parent path, quoting keys that contain
+// path separators (see quoteKey)
+func joinPath(path, key string) string {
+	if path == "" {
+		return quoteKey(key)
+	}
+	return path + "." + quoteKey(key)
+}
+
+// ComparedFields returns the number of leaf fields the last Compare call
+// compared, including ones present on only one side. Ignored fields are not
+// counted.
+func (d *Differ) ComparedFields() int {
+	return d.compared
 }
 
 func (d *Differ) compareObjects(obj1, obj2 map[string]interface{}, path string) *Diff {
@@ -56,6 +164,9 @@ func (d *Differ) compareObjects(obj1, obj2 map[string]interface{}, path string)
 		Children: make(map[string]*Diff),
 	}
 
+	obj1 = d.canonicalKeys(obj1, path)
+	obj2 = d.canonicalKeys(obj2, path)
+
 	// Get all keys from both objects
 	keys := make(map[string]bool)
 	for k := range obj1 {
@@ -66,47 +177,110 @@ func (d *Differ) compareObjects(obj1, obj2 map[string]interface{}, path string)
 	}
 
 	// Compare each key
-	for key := range keys {
-		fieldPath := key
-		if path != "" {
-			fieldPath = path + "." + key
+	if d.parallel > 1 && path == "" {
+		d.compareFieldsParallel(obj1, obj2, keys, diff)
+	} else {
+		for key := range keys {
+			if child := d.compareField(obj1, obj2, key, path); child != nil {
+				diff.Children[key] = child
+				if child.Type != DiffTypeEqual {
+					diff.Type = DiffTypeModified
+				}
+			}
 		}
+	}
 
-		// Skip ignored fields unless showAll is true
-		if !d.showAll && d.config.ShouldIgnore(fieldPath) {
-			continue
-		}
+	// Context is only useful next to a change
+	if diff.Type == DiffTypeEqual && len(diff.Children) > 0 && !d.config.IncludeEqual {
+		diff.Children = make(map[string]*Diff)
+	}
 
-		val1, exists1 := obj1[key]
-		val2, exists2 := obj2[key]
+	return diff
+}
 
-		if !exists1 && exists2 {
-			diff.Children[key] = &Diff{
-				Path:   fieldPath,
-				Type:   DiffTypeAdded,
-				Value2: val2,
-			}
-			diff.Type = DiffTypeModified
-		} else if exists1 && !exists2 {
-			diff.Children[key] = &Diff{
-				Path:   fieldPath,
-				Type:   DiffTypeRemoved,
-				Value1: val1,
-			}
-			diff.Type = DiffTypeModified
-		} else {
-			childDiff := d.compareValues(val1, val2, fieldPath)
-			if childDiff.Type != DiffTypeEqual {
-				diff.Children[key] = childDiff
-				diff.Type = DiffTypeModified
-			}
+// compareField compares key of two objects at path, returning the child diff
+// to keep under their diff, or nil when there is none
+func (d *Differ) compareField(obj1, obj2 map[string]interface{}, key, path string) *Diff {
+	fieldPath := joinPath(path, key)
+
+	// Skip ignored fields unless showAll is true
+	if !d.showAll && d.config.ShouldIgnore(fieldPath) {
+		return nil
+	}
+
+	// Skip fields outside the allowlist
+	if !d.config.IsAllowed(fieldPath) {
+		return nil
+	}
+
+	val1, exists1 := obj1[key]
+	val2, exists2 := obj2[key]
+
+	if d.config.IgnoreEmptyString {
+		if val1 == "" {
+			exists1 = false
+		}
+		if val2 == "" {
+			exists2 = false
+		}
+		if !exists1 && !exists2 {
+			d.compared++
+			return nil
+		}
+	}
+
+	if !exists1 && exists2 {
+		d.compared++
+		return &Diff{
+			Path:   fieldPath,
+			Type:   DiffTypeAdded,
+			Value2: val2,
+		}
+	}
+	if exists1 && !exists2 {
+		d.compared++
+		return &Diff{
+			Path:   fieldPath,
+			Type:   DiffTypeRemoved,
+			Value1: val1,
 		}
 	}
 
+	childDiff := d.compareValues(val1, val2, fieldPath)
+	if childDiff.Type != DiffTypeEqual {
+		return childDiff
+	}
+	if d.config.IncludeEqual || (d.config.ShowContext && isLeafValue(val1)) {
+		// Keep unchanged fields so output can show them
+		return withLeafValues(childDiff, val1, val2)
+	}
+	return nil
+}
+
+// withLeafValues fills in the values of an equal leaf diff, which the
+// comparison otherwise omits
+func withLeafValues(diff *Diff, val1, val2 interface{}) *Diff {
+	if isLeafValue(val1) {
+		diff.Value1 = val1
+		diff.Value2 = val2
+	}
 	return diff
 }
 
+// isLeafValue reports whether a value is a scalar rather than an object or array
+func isLeafValue(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return true
+}
+
 func (d *Differ) compareValues(val1, val2 interface{}, path string) *Diff {
+	if isLeafValue(val1) || isLeafValue(val2) {
+		d.compared++
+	}
+
 	// Handle nil values
 	if val1 == nil && val2 == nil {
 		return &Diff{Path: path, Type: DiffTypeEqual}
@@ -118,6 +292,133 @@ func (d *Differ) compareValues(val1, val2 interface{}, path string) *Diff {
 		return &Diff{Path: path, Type: DiffTypeRemoved, Value1: val1}
 	}
 
+	// Compare strings after the transforms configured for this path
+	if names := d.transformsFor(path); len(names) > 0 {
+		if t1, ok := applyTransforms(val1, names); ok {
+			if t2, ok := applyTransforms(val2, names); ok {
+				return d.leafDiff(val1, val2, path, t1 == t2)
+			}
+		}
+	}
+
+	// Compare ID-keyed maps configured for normalization as lists of values
+	if field, ok := d.mapToListField(path); ok {
+		m1, ok1 := val1.(map[string]interface{})
+		m2, ok2 := val2.(map[string]interface{})
+		if ok1 && ok2 {
+			return d.compareArrays(mapValuesToList(m1, field), mapValuesToList(m2, field), path)
+		}
+	}
+
+	// Compare arrays of {key, value} objects by key
+	if keyField, valueField, ok := d.keyValueFields(path); ok {
+		a1, ok1 := val1.([]interface{})
+		a2, ok2 := val2.([]interface{})
+		if ok1 && ok2 {
+			if m1, ok := keyValueMap(a1, keyField, valueField); ok {
+				if m2, ok := keyValueMap(a2, keyField, valueField); ok {
+					return d.compareObjects(m1, m2, path)
+				}
+			}
+		}
+	}
+
+	// Compare arrays of named entries as maps keyed by name, so either side
+	// may use the map-of-maps representation instead
+	if nameField, ok := d.entryNameField(path); ok {
+		if m1, ok := entryMap(val1, nameField); ok {
+			if m2, ok := entryMap(val2, nameField); ok {
+				return d.compareObjects(m1, m2, path)
+			}
+		}
+	}
+
+	// Compare enum values by their canonical names
+	if values, ok := d.enumMap(path); ok {
+		if e1, ok := canonicalEnum(val1, values); ok {
+			if e2, ok := canonicalEnum(val2, values); ok {
+				return d.leafDiff(val1, val2, path, e1 == e2)
+			}
+		}
+	}
+
+	// Coerce values to the type the schema declares for this path
+	if fieldType, ok := d.schemaType(path); ok {
+		if c1, ok := coerceToType(val1, fieldType); ok {
+			if c2, ok := coerceToType(val2, fieldType); ok {
+				return d.leafDiff(val1, val2, path, c1 == c2)
+			}
+		}
+	}
+
+	// Compare version fields as semantic versions
+	if d.isSemverPath(path) {
+		if v1, ok := canonicalSemver(val1); ok {
+			if v2, ok := canonicalSemver(val2); ok {
+				return d.leafDiff(val1, val2, path, v1 == v2)
+			}
+		}
+	}
+
+	// Compare quantity fields by the amount they denote
+	if d.isQuantityPath(path) {
+		if q1, ok := parseQuantity(val1); ok {
+			if q2, ok := parseQuantity(val2); ok {
+				return d.leafDiff(val1, val2, path, q1.Cmp(q2) == 0)
+			}
+		}
+	}
+
+	// Optionally treat "true"/"false" strings as booleans
+	if d.config.CoerceStringBooleans {
+		if b1, ok := toBool(val1); ok {
+			if b2, ok := toBool(val2); ok {
+				return d.leafDiff(val1, val2, path, b1 == b2)
+			}
+		}
+	}
+
+	// Optionally compare URLs by their canonical form
+	if d.isURLPath(path) {
+		if u1, ok := canonicalURL(val1); ok {
+			if u2, ok := canonicalURL(val2); ok {
+				return d.leafDiff(val1, val2, path, u1 == u2)
+			}
+		}
+	}
+
+	// Optionally compare strings ignoring case; the original values are
+	// reported when they differ beyond casing
+	if d.isCaseInsensitivePath(path) {
+		if s1, ok := val1.(string); ok {
+			if s2, ok := val2.(string); ok {
+				return d.leafDiff(val1, val2, path, strings.EqualFold(s1, s2))
+			}
+		}
+	}
+
+	// Optionally compare JSON-in-a-string values by their canonical form
+	if d.config.NormalizeJSONStrings {
+		if j1, ok := canonicalJSON(val1); ok {
+			if j2, ok := canonicalJSON(val2); ok {
+				return d.leafDiff(val1, val2, path, j1 == j2)
+			}
+		}
+	}
+
+	// Numbers are compared by value regardless of their Go type, since
+	// encoding/json yields float64 while other fetch paths may yield int.
+	// A path's allowed delta replaces the global tolerance.
+	if n1, ok := toFloat64(val1); ok {
+		if n2, ok := toFloat64(val2); ok {
+			tolerance := d.tolerance
+			if delta, ok := d.allowedDelta(path); ok {
+				tolerance = delta
+			}
+			return d.leafDiff(val1, val2, path, n1 == n2 || math.Abs(n1-n2) <= tolerance)
+		}
+	}
+
 	// Check if types match
 	type1 := reflect.TypeOf(val1)
 	type2 := reflect.TypeOf(val2)
@@ -139,19 +440,154 @@ func (d *Differ) compareValues(val1, val2 interface{}, path string) *Diff {
 		v2 := val2.([]interface{})
 		return d.compareArrays(v1, v2, path)
 	default:
-		if reflect.DeepEqual(val1, val2) {
+		return d.leafDiff(val1, val2, path, reflect.DeepEqual(val1, val2))
+	}
+}
+
+// leafDiff builds the diff for two present leaf values of compatible type.
+// In structure-only mode the values themselves are never compared.
+func (d *Differ) leafDiff(val1, val2 interface{}, path string, equal bool) *Diff {
+	if equal || d.config.StructureOnly {
+		return &Diff{Path: path, Type: DiffTypeEqual}
+	}
+	return &Diff{
+		Path:   path,
+		Type:   DiffTypeModified,
+		Value1: val1,
+		Value2: val2,
+	}
+}
+
+// toBool converts a boolean or a case-insensitive "true"/"false" string to a bool
+func toBool(v interface{}) (bool, bool) {
+	switch b := v.(type) {
+	case bool:
+		return b, true
+	case string:
+		if strings.EqualFold(b, "true") {
+			return true, true
+		}
+		if strings.EqualFold(b, "false") {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// toFloat64 converts a numeric value to float64, the canonical form used
+// when comparing numbers. json.Number is parsed; non-numeric values return false.
+func toFloat64(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		if err != nil {
+			return 0, false
+		}
+		return f, true
+	}
+	return 0, false
+}
+
+// coerceToType converts a leaf value to a schema type (string, integer,
+// number or boolean). Numeric strings are parsed for integer and number
+// fields; values that cannot be converted return false.
+func coerceToType(v interface{}, fieldType string) (interface{}, bool) {
+	switch fieldType {
+	case "integer", "number":
+		if s, ok := v.(string); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, false
+			}
+			return f, true
+		}
+		return toFloat64(v)
+	case "boolean":
+		return toBool(v)
+	case "string":
+		switch s := v.(type) {
+		case string:
+			return s, true
+		case bool:
+			return strconv.FormatBool(s), true
+		}
+		if f, ok := toFloat64(v); ok {
+			return strconv.FormatFloat(f, 'f', -1, 64), true
+		}
+	}
+	return nil, false
+}
+
+func (d *Differ) compareArrays(arr1, arr2 []interface{}, path string) *Diff {
+	diff := d.compareArrayElements(arr1, arr2, path)
+
+	// In whole mode any change reports the entire array as one value
+	if d.config.ArrayMode == config.ArrayModeWhole && diff.Type != DiffTypeEqual && diff.Note == "" {
+		return &Diff{Path: path, Type: DiffTypeModified, Value1: arr1, Value2: arr2}
+	}
+	if len(diff.Children) > 0 {
+		diff.Length1, diff.Length2 = len(arr1), len(arr2)
+	}
+	return diff
+}
+
+// compareArrayElements compares two arrays element by element
+func (d *Differ) compareArrayElements(arr1, arr2 []interface{}, path string) *Diff {
+	// Collapse oversized arrays into a single length summary
+	if limit := d.config.MaxArrayElements; limit > 0 && (len(arr1) > limit || len(arr2) > limit) {
+		if reflect.DeepEqual(arr1, arr2) {
 			return &Diff{Path: path, Type: DiffTypeEqual}
 		}
 		return &Diff{
 			Path:   path,
 			Type:   DiffTypeModified,
-			Value1: val1,
-			Value2: val2,
+			Value1: len(arr1),
+			Value2: len(arr2),
+			Note:   fmt.Sprintf("array exceeds %d elements; showing lengths only", limit),
 		}
 	}
-}
 
-func (d *Differ) compareArrays(arr1, arr2 []interface{}, path string) *Diff {
+	// IAM bindings are matched by role, not position
+	if path == iamBindingsPath {
+		if diff := d.compareIAMBindings(arr1, arr2, path); diff != nil {
+			return diff
+		}
+	}
+
+	if fields := d.arrayKeyFields(path); len(fields) > 0 {
+		if diff := d.compareArraysByKey(arr1, arr2, path, fields); diff != nil {
+			return diff
+		}
+	}
+
+	if d.config.ArraySimilarityThreshold > 0 {
+		return d.compareArraysBySimilarity(arr1, arr2, path)
+	}
+
 	diff := &Diff{
 		Path:     path,
 		Type:     DiffTypeEqual,
@@ -173,9 +609,12 @@ func (d *Differ) compareArrays(arr1, arr2 []interface{}, path string) *Diff {
 			if childDiff.Type != DiffTypeEqual {
 				diff.Children[key] = childDiff
 				diff.Type = DiffTypeModified
+			} else if d.config.IncludeEqual {
+				diff.Children[key] = withLeafValues(childDiff, arr1[i], arr2[i])
 			}
 		} else if i >= len(arr1) {
 			// Element only exists in arr2 - it was added
+			d.compared++
 			diff.Children[key] = &Diff{
 				Path:   indexPath,
 				Type:   DiffTypeAdded,
@@ -184,6 +623,7 @@ func (d *Differ) compareArrays(arr1, arr2 []interface{}, path string) *Diff {
 			diff.Type = DiffTypeModified
 		} else {
 			// Element only exists in arr1 - it was removed
+			d.compared++
 			diff.Children[key] = &Diff{
 				Path:   indexPath,
 				Type:   DiffTypeRemoved,
@@ -196,6 +636,68 @@ func (d *Differ) compareArrays(arr1, arr2 []interface{}, path string) *Diff {
 	return diff
 }
 
+// IsEmpty reports whether the diff found no differences at all
+func (d *Diff) IsEmpty() bool {
+	return d.Type == DiffTypeEqual && d.Count() == 0
+}
+
+// Count returns the number of leaf differences, the same as
+// len(GetAllDiffs(d))
+func (d *Diff) Count() int {
+	if d.Type != DiffTypeEqual && len(d.Children) == 0 {
+		return 1
+	}
+	count := 0
+	for _, child := range d.Children {
+		count += child.Count()
+	}
+	return count
+}
+
+// Filter returns a pruned copy of the diff keeping only the leaves for which
+// pred returns true and the ancestors leading to them. A node left with no
+// differing children becomes equal, and an array that loses elements drops its
+// lengths, since the hidden elements are not unchanged. The original diff is
+// not modified.
+func (d *Diff) Filter(pred func(*Diff) bool) *Diff {
+	if filtered, ok := filterDiff(d, pred); ok {
+		return filtered
+	}
+	return &Diff{Path: d.Path, Type: DiffTypeEqual}
+}
+
+// filterDiff returns the filtered copy of d, and false if nothing in it was
+// kept
+func filterDiff(d *Diff, pred func(*Diff) bool) (*Diff, bool) {
+	filtered := *d
+	if len(d.Children) == 0 {
+		return &filtered, pred(d)
+	}
+
+	filtered.Children = make(map[string]*Diff)
+	changed := false
+	for key, child := range d.Children {
+		kept, ok := filterDiff(child, pred)
+		if !ok {
+			continue
+		}
+		filtered.Children[key] = kept
+		if kept.Type != DiffTypeEqual {
+			changed = true
+		}
+	}
+	if len(filtered.Children) == 0 {
+		return nil, false
+	}
+	if len(filtered.Children) < len(d.Children) {
+		filtered.Length1, filtered.Length2 = 0, 0
+	}
+	if !changed {
+		filtered.Type = DiffTypeEqual
+	}
+	return &filtered, true
+}
+
 // GetAllDiffs returns a flat list of all differences
 func GetAllDiffs(diff *Diff) []*Diff {
 	var diffs []*Diff
@@ -209,7 +711,7 @@ func GetAllDiffs(diff *Diff) []*Diff {
 	for k := range diff.Children {
 		keys = append(keys, k)
 	}
-	sort.Strings(keys)
+	sortPaths(keys)
 
 	for _, key := range keys {
 		child := diff.Children[key]
@@ -1,6 +1,9 @@
 package compare
 
 import (
+	"encoding/json"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/tflynn3/gcdiff/internal/config"
@@ -449,3 +452,1093 @@ func TestGetAllDiffs_Nested(t *testing.T) {
 		t.Errorf("Expected path 'metadata.version', got %q", diffs[0].Path)
 	}
 }
+
+func TestCompare_NumberTypesNormalized(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+
+	obj1 := map[string]interface{}{
+		"diskSizeGb": 100,
+		"cpus":       int64(4),
+		"ports":      []interface{}{80, 443},
+		"ratio":      json.Number("0.5"),
+	}
+
+	obj2 := map[string]interface{}{
+		"diskSizeGb": float64(100),
+		"cpus":       float64(4),
+		"ports":      []interface{}{float64(80), float64(443)},
+		"ratio":      0.5,
+	}
+
+	diff := d.Compare(obj1, obj2)
+
+	if diff.Type != DiffTypeEqual {
+		t.Errorf("Expected int and float64 representations of the same numbers to be equal, got %v: %v", diff.Type, diff.Children)
+	}
+}
+
+func TestCompare_NumberTypesDifferentValues(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+
+	obj1 := map[string]interface{}{"diskSizeGb": 100}
+	obj2 := map[string]interface{}{"diskSizeGb": float64(200)}
+
+	diff := d.Compare(obj1, obj2)
+
+	sizeDiff := diff.Children["diskSizeGb"]
+	if sizeDiff == nil || sizeDiff.Type != DiffTypeModified {
+		t.Fatal("Expected diskSizeGb to be modified")
+	}
+
+	// Original values are preserved in the diff
+	if sizeDiff.Value1 != 100 || sizeDiff.Value2 != float64(200) {
+		t.Errorf("Expected original values to be preserved, got %v to %v", sizeDiff.Value1, sizeDiff.Value2)
+	}
+}
+
+func TestCompare_CoerceStringBooleans(t *testing.T) {
+	cfg := config.Default()
+	cfg.CoerceStringBooleans = true
+	d := NewDiffer(cfg, false)
+
+	tests := []struct {
+		name     string
+		val1     interface{}
+		val2     interface{}
+		expected DiffType
+	}{
+		{"string true vs bool true", "true", true, DiffTypeEqual},
+		{"uppercase string vs bool", "TRUE", true, DiffTypeEqual},
+		{"string false vs bool true", "false", true, DiffTypeModified},
+		{"bool change", false, true, DiffTypeModified},
+		{"non-boolean string", "yes", true, DiffTypeModified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := d.Compare(
+				map[string]interface{}{"enabled": tt.val1},
+				map[string]interface{}{"enabled": tt.val2},
+			)
+			if diff.Type != tt.expected {
+				t.Errorf("Compare(%v, %v) = %v, want %v", tt.val1, tt.val2, diff.Type, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompare_StringBooleansNotCoercedByDefault(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+
+	diff := d.Compare(
+		map[string]interface{}{"enabled": "true"},
+		map[string]interface{}{"enabled": true},
+	)
+
+	if diff.Type != DiffTypeModified {
+		t.Errorf("Expected string and bool to differ without coercion, got %v", diff.Type)
+	}
+}
+
+func TestCompare_StructureOnly(t *testing.T) {
+	cfg := config.Default()
+	cfg.StructureOnly = true
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{
+		"machineType": "n1-standard-2",
+		"diskSizeGb":  50,
+		"labels":      map[string]interface{}{"env": "prod"},
+		"tags":        []interface{}{"web"},
+	}
+
+	obj2 := map[string]interface{}{
+		"machineType": "n1-standard-4",
+		"diskSizeGb":  float64(100),
+		"labels":      map[string]interface{}{"env": "staging"},
+		"tags":        []interface{}{"api"},
+	}
+
+	diff := d.Compare(obj1, obj2)
+
+	if diff.Type != DiffTypeEqual {
+		t.Errorf("Expected identical shapes to be equal, got %v: %v", diff.Type, diff.Children)
+	}
+}
+
+func TestCompare_StructureOnlyReportsShapeChanges(t *testing.T) {
+	cfg := config.Default()
+	cfg.StructureOnly = true
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{
+		"machineType": "n1-standard-2",
+		"labels":      map[string]interface{}{"env": "prod", "team": "web"},
+		"diskSizeGb":  "50",
+	}
+
+	obj2 := map[string]interface{}{
+		"machineType": "n1-standard-4",
+		"labels":      map[string]interface{}{"env": "staging"},
+		"diskSizeGb":  50,
+	}
+
+	diff := d.Compare(obj1, obj2)
+
+	if _, exists := diff.Children["machineType"]; exists {
+		t.Error("Value-only change should not be reported in structure-only mode")
+	}
+
+	labelsDiff := diff.Children["labels"]
+	if labelsDiff == nil || labelsDiff.Children["team"] == nil || labelsDiff.Children["team"].Type != DiffTypeRemoved {
+		t.Error("Expected missing key labels.team to be reported as removed")
+	}
+
+	if sizeDiff := diff.Children["diskSizeGb"]; sizeDiff == nil || sizeDiff.Type != DiffTypeModified {
+		t.Error("Expected type change of diskSizeGb to be reported")
+	}
+}
+
+func TestCompare_ShowContextRetainsEqualSiblings(t *testing.T) {
+	cfg := config.Default()
+	cfg.ShowContext = true
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{
+		"scheduling": map[string]interface{}{"preemptible": false, "automaticRestart": true},
+		"labels":     map[string]interface{}{"env": "prod"},
+	}
+	obj2 := map[string]interface{}{
+		"scheduling": map[string]interface{}{"preemptible": true, "automaticRestart": true},
+		"labels":     map[string]interface{}{"env": "prod"},
+	}
+
+	diff := d.Compare(obj1, obj2)
+
+	scheduling := diff.Children["scheduling"]
+	if scheduling == nil {
+		t.Fatal("Expected 'scheduling' in children")
+	}
+
+	restart := scheduling.Children["automaticRestart"]
+	if restart == nil || restart.Type != DiffTypeEqual || restart.Value1 != true {
+		t.Errorf("Expected unchanged sibling to be retained as context, got %v", restart)
+	}
+
+	if _, exists := diff.Children["labels"]; exists {
+		t.Error("Unchanged objects should not be retained")
+	}
+
+	if len(GetAllDiffs(diff)) != 1 {
+		t.Errorf("Context should not count as a difference, got %v", GetAllDiffs(diff))
+	}
+}
+
+func TestCompare_ContextDiscardedWithoutFlag(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+
+	diff := d.Compare(
+		map[string]interface{}{"a": 1, "b": 2},
+		map[string]interface{}{"a": 1, "b": 3},
+	)
+
+	if _, exists := diff.Children["a"]; exists {
+		t.Error("Equal fields should be discarded without ShowContext")
+	}
+}
+
+func TestCompare_SchemaCoercion(t *testing.T) {
+	cfg := config.Default()
+	cfg.Schema = map[string]string{
+		"diskSizeGb":  "integer",
+		"preemptible": "boolean",
+		"port":        "string",
+	}
+	d := NewDiffer(cfg, false)
+
+	tests := []struct {
+		name     string
+		field    string
+		val1     interface{}
+		val2     interface{}
+		expected DiffType
+	}{
+		{"integer string vs number", "diskSizeGb", "5", float64(5), DiffTypeEqual},
+		{"integer string vs different number", "diskSizeGb", "5", float64(10), DiffTypeModified},
+		{"non-numeric string for integer", "diskSizeGb", "five", float64(5), DiffTypeModified},
+		{"boolean string vs bool", "preemptible", "false", false, DiffTypeEqual},
+		{"number vs string field", "port", float64(8080), "8080", DiffTypeEqual},
+		{"unlisted path keeps type diff", "count", "5", float64(5), DiffTypeModified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := d.Compare(
+				map[string]interface{}{tt.field: tt.val1},
+				map[string]interface{}{tt.field: tt.val2},
+			)
+			if diff.Type != tt.expected {
+				t.Errorf("Compare(%v, %v) = %v, want %v", tt.val1, tt.val2, diff.Type, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompare_IncludeEqualRetainsFullTree(t *testing.T) {
+	cfg := config.Default()
+	cfg.IncludeEqual = true
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{
+		"labels": map[string]interface{}{"env": "prod"},
+		"tags":   []interface{}{"web", "http"},
+	}
+	obj2 := map[string]interface{}{
+		"labels": map[string]interface{}{"env": "prod"},
+		"tags":   []interface{}{"web", "http"},
+	}
+
+	diff := d.Compare(obj1, obj2)
+
+	if diff.Type != DiffTypeEqual {
+		t.Errorf("Expected identical objects to be equal, got %v", diff.Type)
+	}
+
+	env := diff.Children["labels"].Children["env"]
+	if env == nil || env.Type != DiffTypeEqual || env.Value1 != "prod" || env.Value2 != "prod" {
+		t.Errorf("Expected equal leaf inside an equal object to be retained, got %v", env)
+	}
+
+	tag := diff.Children["tags"].Children["[1]"]
+	if tag == nil || tag.Value1 != "http" {
+		t.Errorf("Expected equal array element to be retained, got %v", tag)
+	}
+
+	if len(GetAllDiffs(diff)) != 0 {
+		t.Errorf("Retained equal fields should not count as differences, got %v", GetAllDiffs(diff))
+	}
+}
+
+func TestCompare_IgnoreUnderPath(t *testing.T) {
+	cfg := config.Default()
+	cfg.IgnoreUnderPath = []string{"healthChecks"}
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{
+		"port":         float64(80),
+		"healthChecks": []interface{}{map[string]interface{}{"port": float64(80), "path": "/healthz"}},
+	}
+	obj2 := map[string]interface{}{
+		"port":         float64(8080),
+		"healthChecks": []interface{}{map[string]interface{}{"port": float64(8080), "path": "/ready"}},
+	}
+
+	diffs := GetAllDiffs(d.Compare(obj1, obj2))
+
+	if len(diffs) != 1 || diffs[0].Path != "port" {
+		t.Errorf("Expected only the top-level port to differ, got %v", diffs)
+	}
+}
+
+func TestCompare_KeysWithDots(t *testing.T) {
+	cfg := config.Default()
+	cfg.IgnoreFields = []string{`metadata.labels."app.kubernetes.io/version"`}
+	cfg.IgnoreUnderPath = []string{`"example.com/notes"`}
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{
+				"app.kubernetes.io/name":    "web",
+				"app.kubernetes.io/version": "1.0",
+				"app":                       "web",
+			},
+		},
+		"example.com/notes": map[string]interface{}{"text": "a"},
+	}
+	obj2 := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{
+				"app.kubernetes.io/name":    "api",
+				"app.kubernetes.io/version": "2.0",
+				"app":                       "web",
+			},
+		},
+		"example.com/notes": map[string]interface{}{"text": "b"},
+	}
+
+	diffs := GetAllDiffs(d.Compare(obj1, obj2))
+
+	if len(diffs) != 1 || diffs[0].Path != `metadata.labels."app.kubernetes.io/name"` {
+		t.Fatalf("Expected only the quoted name label to differ, got %v", diffs)
+	}
+	if value, ok := lookupField(obj2, diffs[0].Path); !ok || value != "api" {
+		t.Errorf("Expected the quoted path to resolve to api, got %v", value)
+	}
+}
+
+func TestCompare_NormalizeMapToList(t *testing.T) {
+	cfg := config.Default()
+	cfg.NormalizeMapToList = []string{"backends", "rules:name"}
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{
+		"backends": map[string]interface{}{
+			"id123": map[string]interface{}{"group": "ig-a", "weight": float64(1)},
+			"id456": map[string]interface{}{"group": "ig-b", "weight": float64(2)},
+		},
+		"rules": map[string]interface{}{
+			"r-1": map[string]interface{}{"name": "allow-http", "port": float64(80)},
+			"r-2": map[string]interface{}{"name": "allow-ssh", "port": float64(22)},
+		},
+	}
+	obj2 := map[string]interface{}{
+		"backends": map[string]interface{}{
+			"id999": map[string]interface{}{"group": "ig-b", "weight": float64(2)},
+			"id777": map[string]interface{}{"group": "ig-a", "weight": float64(1)},
+		},
+		"rules": map[string]interface{}{
+			"r-9": map[string]interface{}{"name": "allow-ssh", "port": float64(2222)},
+			"r-8": map[string]interface{}{"name": "allow-http", "port": float64(80)},
+		},
+	}
+
+	diffs := GetAllDiffs(d.Compare(obj1, obj2))
+
+	if len(diffs) != 1 {
+		t.Fatalf("Expected only the changed rule port to differ, got %d: %v", len(diffs), diffs)
+	}
+	if diffs[0].Path != "rules[1].port" || diffs[0].Value2 != float64(2222) {
+		t.Errorf("Expected rules[1].port to change to 2222, got %+v", diffs[0])
+	}
+}
+
+func TestCompare_MapKeysDifferWithoutNormalization(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+
+	diff := d.Compare(
+		map[string]interface{}{"backends": map[string]interface{}{"id123": "ig-a"}},
+		map[string]interface{}{"backends": map[string]interface{}{"id999": "ig-a"}},
+	)
+
+	if len(GetAllDiffs(diff)) != 2 {
+		t.Errorf("Expected generated keys to show as added and removed, got %v", GetAllDiffs(diff))
+	}
+}
+
+func TestDiff_IsEmptyAndCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		diff  *Diff
+		empty bool
+		count int
+	}{
+		{
+			name:  "empty",
+			diff:  &Diff{Type: DiffTypeEqual},
+			empty: true,
+			count: 0,
+		},
+		{
+			name:  "single leaf",
+			diff:  &Diff{Path: "machineType", Type: DiffTypeModified, Value1: "n1-standard-2", Value2: "n1-standard-4"},
+			empty: false,
+			count: 1,
+		},
+		{
+			name: "nested leaves",
+			diff: &Diff{
+				Type: DiffTypeModified,
+				Children: map[string]*Diff{
+					"labels": {
+						Path: "labels",
+						Type: DiffTypeModified,
+						Children: map[string]*Diff{
+							"env":  {Path: "labels.env", Type: DiffTypeModified, Value1: "dev", Value2: "prod"},
+							"team": {Path: "labels.team", Type: DiffTypeAdded, Value2: "web"},
+							"tier": {Path: "labels.tier", Type: DiffTypeEqual, Value1: "1", Value2: "1"},
+						},
+					},
+					"tags": {Path: "tags", Type: DiffTypeRemoved, Value1: []interface{}{"http"}},
+				},
+			},
+			empty: false,
+			count: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.diff.IsEmpty(); got != tt.empty {
+				t.Errorf("IsEmpty() = %v, want %v", got, tt.empty)
+			}
+			if got := tt.diff.Count(); got != tt.count {
+				t.Errorf("Count() = %d, want %d", got, tt.count)
+			}
+			if got := len(GetAllDiffs(tt.diff)); got != tt.count {
+				t.Errorf("Count() should match len(GetAllDiffs()) = %d", got)
+			}
+		})
+	}
+}
+
+func TestCompare_NormalizeJSONStrings(t *testing.T) {
+	cfg := config.Default()
+	cfg.NormalizeJSONStrings = true
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{
+		"policy":  `{"bindings": [{"role": "roles/viewer"}], "version": 1}`,
+		"changed": `{"enabled": true}`,
+		"script":  "echo  hello",
+	}
+	obj2 := map[string]interface{}{
+		"policy":  "{\n  \"version\": 1,\n  \"bindings\": [{\"role\":\"roles/viewer\"}]\n}",
+		"changed": `{"enabled":false}`,
+		"script":  "echo hello",
+	}
+
+	diffs := GetAllDiffs(d.Compare(obj1, obj2))
+
+	paths := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		paths = append(paths, diff.Path)
+	}
+	if len(paths) != 2 || paths[0] != "changed" || paths[1] != "script" {
+		t.Errorf("Expected only changed and script to differ, got %v", paths)
+	}
+}
+
+func TestCompare_JSONStringsLiteralByDefault(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+
+	diff := d.Compare(
+		map[string]interface{}{"policy": `{"version": 1}`},
+		map[string]interface{}{"policy": `{"version":1}`},
+	)
+
+	if diff.IsEmpty() {
+		t.Error("Expected JSON strings to be compared literally without normalize_json_strings")
+	}
+}
+
+func TestDiffer_ComparedFields(t *testing.T) {
+	cfg := config.Default()
+	cfg.IgnoreFields = []string{"id"}
+	d := NewDiffer(cfg, false)
+
+	d.Compare(
+		map[string]interface{}{
+			"id":    "1",
+			"name":  "vm",
+			"tags":  []interface{}{"a", "b"},
+			"disks": map[string]interface{}{"boot": true},
+		},
+		map[string]interface{}{
+			"id":    "2",
+			"name":  "vm",
+			"tags":  []interface{}{"a"},
+			"extra": "x",
+		},
+	)
+
+	// name, tags[0], tags[1], disks and extra; id is ignored
+	if got := d.ComparedFields(); got != 5 {
+		t.Errorf("ComparedFields() = %d, want 5", got)
+	}
+
+	d.Compare(map[string]interface{}{"name": "a"}, map[string]interface{}{"name": "b"})
+	if got := d.ComparedFields(); got != 1 {
+		t.Errorf("ComparedFields() should reset between comparisons, got %d", got)
+	}
+}
+
+func TestCompare_ArrayModes(t *testing.T) {
+	obj1 := map[string]interface{}{"tags": []interface{}{"http", "https", "ssh"}}
+	obj2 := map[string]interface{}{"tags": []interface{}{"http", "rdp", "ssh", "icmp"}}
+
+	granular := GetAllDiffs(NewDiffer(config.Default(), false).Compare(obj1, obj2))
+	if len(granular) != 2 || granular[0].Path != "tags[1]" || granular[1].Path != "tags[3]" {
+		t.Errorf("Expected per-index diffs in granular mode, got %v", granular)
+	}
+
+	cfg := config.Default()
+	cfg.ArrayMode = config.ArrayModeWhole
+	whole := GetAllDiffs(NewDiffer(cfg, false).Compare(obj1, obj2))
+	if len(whole) != 1 {
+		t.Fatalf("Expected a single diff in whole mode, got %v", whole)
+	}
+	if whole[0].Path != "tags" || whole[0].Type != DiffTypeModified || len(whole[0].Children) != 0 {
+		t.Errorf("Expected tags reported as one modified value, got %+v", whole[0])
+	}
+	if !reflect.DeepEqual(whole[0].Value1, obj1["tags"]) || !reflect.DeepEqual(whole[0].Value2, obj2["tags"]) {
+		t.Errorf("Expected the full arrays as values, got %v -> %v", whole[0].Value1, whole[0].Value2)
+	}
+
+	if diff := NewDiffer(cfg, false).Compare(obj1, obj1); !diff.IsEmpty() {
+		t.Errorf("Expected equal arrays to stay equal in whole mode, got %v", GetAllDiffs(diff))
+	}
+}
+
+func TestDiff_Filter(t *testing.T) {
+	newDiff := func() *Diff {
+		return &Diff{
+			Type: DiffTypeModified,
+			Children: map[string]*Diff{
+				"labels": {
+					Path: "labels",
+					Type: DiffTypeModified,
+					Children: map[string]*Diff{
+						"env":  {Path: "labels.env", Type: DiffTypeModified, Value1: "dev", Value2: "prod"},
+						"team": {Path: "labels.team", Type: DiffTypeAdded, Value2: "web"},
+					},
+				},
+				"scheduling": {
+					Path: "scheduling",
+					Type: DiffTypeModified,
+					Children: map[string]*Diff{
+						"preemptible": {Path: "scheduling.preemptible", Type: DiffTypeAdded, Value2: true},
+					},
+				},
+				"machineType": {Path: "machineType", Type: DiffTypeModified, Value1: "n1-standard-2", Value2: "n1-standard-4"},
+			},
+		}
+	}
+
+	t.Run("by type", func(t *testing.T) {
+		diff := newDiff()
+		filtered := diff.Filter(func(d *Diff) bool { return d.Type != DiffTypeAdded })
+
+		var paths []string
+		for _, leaf := range GetAllDiffs(filtered) {
+			paths = append(paths, leaf.Path)
+		}
+		if len(paths) != 2 || paths[0] != "labels.env" || paths[1] != "machineType" {
+			t.Errorf("Expected only the modified leaves, got %v", paths)
+		}
+		if _, ok := filtered.Children["scheduling"]; ok {
+			t.Error("Expected scheduling to be pruned once its only leaf was dropped")
+		}
+		if diff.Count() != 4 {
+			t.Errorf("Expected the original diff to be left intact, got %d leaves", diff.Count())
+		}
+	})
+
+	t.Run("by path prefix", func(t *testing.T) {
+		filtered := newDiff().Filter(func(d *Diff) bool { return strings.HasPrefix(d.Path, "labels.") })
+
+		if len(filtered.Children) != 1 || filtered.Children["labels"] == nil {
+			t.Fatalf("Expected only the labels subtree, got %v", filtered.Children)
+		}
+		if filtered.Count() != 2 || filtered.Type != DiffTypeModified {
+			t.Errorf("Expected the labels leaves under a modified root, got %+v", filtered)
+		}
+	})
+
+	t.Run("nothing kept", func(t *testing.T) {
+		filtered := newDiff().Filter(func(d *Diff) bool { return false })
+
+		if !filtered.IsEmpty() || len(filtered.Children) != 0 {
+			t.Errorf("Expected an empty equal diff, got %+v", filtered)
+		}
+	})
+}
+
+func TestCompare_MetadataItemsByKey(t *testing.T) {
+	items := func(pairs ...string) map[string]interface{} {
+		list := make([]interface{}, 0, len(pairs)/2)
+		for i := 0; i < len(pairs); i += 2 {
+			list = append(list, map[string]interface{}{"key": pairs[i], "value": pairs[i+1]})
+		}
+		return map[string]interface{}{"metadata": map[string]interface{}{"items": list}}
+	}
+	d := NewDiffer(config.Default(), false)
+
+	t.Run("item added", func(t *testing.T) {
+		diffs := GetAllDiffs(d.Compare(
+			items("enable-oslogin", "TRUE", "startup-script", "echo hi"),
+			items("block-project-ssh-keys", "true", "enable-oslogin", "TRUE", "startup-script", "echo hi"),
+		))
+		if len(diffs) != 1 || diffs[0].Path != "metadata.items.block-project-ssh-keys" || diffs[0].Type != DiffTypeAdded {
+			t.Errorf("Expected only the new key to be added, got %v", diffs)
+		}
+	})
+
+	t.Run("value changed", func(t *testing.T) {
+		diffs := GetAllDiffs(d.Compare(
+			items("enable-oslogin", "TRUE", "startup-script", "echo hi"),
+			items("startup-script", "echo bye", "enable-oslogin", "TRUE"),
+		))
+		if len(diffs) != 1 || diffs[0].Path != "metadata.items.startup-script" || diffs[0].Value2 != "echo bye" {
+			t.Errorf("Expected a per-key change to startup-script, got %v", diffs)
+		}
+	})
+}
+
+func TestCompare_ConfiguredKeyValueArray(t *testing.T) {
+	cfg := config.Default()
+	cfg.KeyValueArrays = []string{"env:name:value"}
+	d := NewDiffer(cfg, false)
+
+	diffs := GetAllDiffs(d.Compare(
+		map[string]interface{}{"env": []interface{}{
+			map[string]interface{}{"name": "PORT", "value": "8080"},
+			map[string]interface{}{"name": "MODE", "value": "dev"},
+		}},
+		map[string]interface{}{"env": []interface{}{
+			map[string]interface{}{"name": "MODE", "value": "prod"},
+			map[string]interface{}{"name": "PORT", "value": "8080"},
+		}},
+	))
+
+	if len(diffs) != 1 || diffs[0].Path != "env.MODE" {
+		t.Errorf("Expected only env.MODE to differ, got %v", diffs)
+	}
+}
+
+func TestCompare_KeyValueArrayWithDuplicateKeysFallsBack(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+	dup := []interface{}{
+		map[string]interface{}{"key": "a", "value": "1"},
+		map[string]interface{}{"key": "a", "value": "2"},
+	}
+
+	diffs := GetAllDiffs(d.Compare(
+		map[string]interface{}{"metadata": map[string]interface{}{"items": dup}},
+		map[string]interface{}{"metadata": map[string]interface{}{"items": dup[:1]}},
+	))
+
+	if len(diffs) != 1 || diffs[0].Path != "metadata.items[1]" {
+		t.Errorf("Expected index comparison when keys repeat, got %v", diffs)
+	}
+}
+
+func TestCompare_EntryArrayPaths(t *testing.T) {
+	cfg := config.Default()
+	cfg.EntryArrayPaths = map[string]string{"backends": "name"}
+	d := NewDiffer(cfg, false)
+
+	entries := map[string]interface{}{"backends": []interface{}{
+		map[string]interface{}{"name": "us", "capacity": 0.5, "group": "ig-us"},
+		map[string]interface{}{"name": "eu", "capacity": 1.0, "group": "ig-eu"},
+	}}
+	keyed := map[string]interface{}{"backends": map[string]interface{}{
+		"eu": map[string]interface{}{"capacity": 1.0, "group": "ig-eu"},
+		"us": map[string]interface{}{"capacity": 0.5, "group": "ig-us"},
+	}}
+
+	if diff := d.Compare(entries, keyed); !diff.IsEmpty() {
+		t.Errorf("Expected entries and a keyed map of the same data to be equal, got %v", GetAllDiffs(diff))
+	}
+	if diff := d.Compare(keyed, entries); !diff.IsEmpty() {
+		t.Errorf("Expected the comparison to be symmetric, got %v", GetAllDiffs(diff))
+	}
+
+	changed := map[string]interface{}{"backends": map[string]interface{}{
+		"eu": map[string]interface{}{"capacity": 0.8, "group": "ig-eu"},
+		"us": map[string]interface{}{"capacity": 0.5, "group": "ig-us"},
+	}}
+	diffs := GetAllDiffs(d.Compare(entries, changed))
+	if len(diffs) != 1 || diffs[0].Path != "backends.eu.capacity" {
+		t.Errorf("Expected only backends.eu.capacity to differ, got %v", diffs)
+	}
+
+	// Without the config the two shapes are a type mismatch
+	if diff := NewDiffer(config.Default(), false).Compare(entries, keyed); diff.IsEmpty() {
+		t.Error("Expected the shapes to differ without entry_array_paths")
+	}
+}
+
+func TestCompare_EntryArrayWithDuplicateNamesFallsBack(t *testing.T) {
+	cfg := config.Default()
+	cfg.EntryArrayPaths = map[string]string{"backends": "name"}
+	d := NewDiffer(cfg, false)
+
+	dup := []interface{}{
+		map[string]interface{}{"name": "us", "capacity": 0.5},
+		map[string]interface{}{"name": "us", "capacity": 1.0},
+	}
+	diffs := GetAllDiffs(d.Compare(
+		map[string]interface{}{"backends": dup},
+		map[string]interface{}{"backends": dup[:1]},
+	))
+	if len(diffs) != 1 || diffs[0].Path != "backends[1]" {
+		t.Errorf("Expected index comparison when names repeat, got %v", diffs)
+	}
+}
+
+func TestCompare_KeyAliases(t *testing.T) {
+	cfg := config.Default()
+	cfg.KeyAliases = map[string]string{"self_link": "selfLink"}
+	d := NewDiffer(cfg, false)
+
+	diff := d.Compare(
+		map[string]interface{}{"selfLink": "link-a"},
+		map[string]interface{}{"self_link": "link-a"},
+	)
+
+	if !diff.IsEmpty() {
+		t.Errorf("Expected aliased keys to compare equal, got %v", GetAllDiffs(diff))
+	}
+	if len(d.Warnings()) != 0 {
+		t.Errorf("Expected no warnings, got %v", d.Warnings())
+	}
+}
+
+func TestCompare_KeyAliasCollisionIsDeterministic(t *testing.T) {
+	cfg := config.Default()
+	cfg.KeyAliases = map[string]string{"self_link": "selfLink", "SelfLink": "selfLink"}
+	obj1 := map[string]interface{}{"self_link": "alias", "SelfLink": "other alias", "selfLink": "exact"}
+	obj2 := map[string]interface{}{"self_link": "alias", "SelfLink": "exact"}
+
+	for i := 0; i < 50; i++ {
+		d := NewDiffer(cfg, false)
+		diffs := GetAllDiffs(d.Compare(obj1, obj2))
+
+		// The exact key wins on side 1; "SelfLink" sorts before "self_link" on side 2
+		if len(diffs) != 0 {
+			t.Fatalf("Run %d: expected the exact and first aliased keys to win, got %v", i, diffs)
+		}
+		want := []string{
+			`SelfLink: ignoring key "SelfLink" because "selfLink" also maps to "selfLink"`,
+			`self_link: ignoring key "self_link" because "SelfLink" also maps to "selfLink"`,
+			`self_link: ignoring key "self_link" because "selfLink" also maps to "selfLink"`,
+		}
+		if got := d.Warnings(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Run %d: expected warnings %q, got %q", i, want, got)
+		}
+	}
+}
+
+func TestCompare_SemverPaths(t *testing.T) {
+	cfg := config.Default()
+	cfg.SemverPaths = []string{"*Version"}
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{
+		"minVersion":     "1.2",
+		"nodeVersion":    "v1.27.3-gke.100+build.1",
+		"masterVersion":  "1.27.3",
+		"channelVersion": "stable",
+		"release":        "1.0",
+	}
+	obj2 := map[string]interface{}{
+		"minVersion":     "1.2.0",
+		"nodeVersion":    "1.27.3-gke.100",
+		"masterVersion":  "1.28.0",
+		"channelVersion": "rapid",
+		"release":        "1.0.0",
+	}
+
+	diffs := GetAllDiffs(d.Compare(obj1, obj2))
+
+	paths := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		paths = append(paths, diff.Path)
+	}
+	// channelVersion isn't a version and falls back to string comparison;
+	// release isn't a semver path
+	want := []string{"channelVersion", "masterVersion", "release"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected %v to differ, got %v", want, paths)
+	}
+}
+
+func TestCompare_QuantityPaths(t *testing.T) {
+	cfg := config.Default()
+	cfg.QuantityPaths = []string{"memory", "cpu", "limits.*"}
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{
+		"memory":  "1Gi",
+		"cpu":     "1000m",
+		"limits":  map[string]interface{}{"storage": "1Gi", "ephemeral": "unlimited"},
+		"ratio":   "1000m",
+		"maxPods": float64(110),
+	}
+	obj2 := map[string]interface{}{
+		"memory":  "1024Mi",
+		"cpu":     float64(1),
+		"limits":  map[string]interface{}{"storage": "2Gi", "ephemeral": "none"},
+		"ratio":   "1",
+		"maxPods": float64(110),
+	}
+
+	diffs := GetAllDiffs(d.Compare(obj1, obj2))
+
+	paths := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		paths = append(paths, diff.Path)
+	}
+	// ephemeral isn't a quantity and falls back to string comparison; ratio
+	// isn't a quantity path
+	want := []string{"limits.ephemeral", "limits.storage", "ratio"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected %v to differ, got %v", want, paths)
+	}
+	if diffs[1].Value1 != "1Gi" || diffs[1].Value2 != "2Gi" {
+		t.Errorf("Expected the original values to be reported, got %+v", diffs[1])
+	}
+}
+
+func TestCompare_EnumMaps(t *testing.T) {
+	cfg := config.Default()
+	cfg.EnumMaps = map[string]map[string]string{
+		"status": {"1": "PROVISIONING", "2": "RUNNING"},
+	}
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{
+		"status":   float64(2),
+		"replicas": []interface{}{map[string]interface{}{"status": "PROVISIONING"}},
+		"previous": map[string]interface{}{"status": float64(7)},
+		"state":    float64(2),
+	}
+	obj2 := map[string]interface{}{
+		"status":   "RUNNING",
+		"replicas": []interface{}{map[string]interface{}{"status": float64(2)}},
+		"previous": map[string]interface{}{"status": "7"},
+		"state":    "RUNNING",
+	}
+
+	diffs := GetAllDiffs(d.Compare(obj1, obj2))
+	paths := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		paths = append(paths, diff.Path)
+	}
+	// An unmapped number falls back to typed comparison, and state has no
+	// enum map
+	want := []string{"previous.status", "replicas[0].status", "state"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected %v to differ, got %v", want, paths)
+	}
+	if diffs[1].Value1 != "PROVISIONING" || diffs[1].Value2 != float64(2) {
+		t.Errorf("Expected the original values to be reported, got %+v", diffs[1])
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	for _, tt := range []struct {
+		value interface{}
+		want  string
+	}{
+		{"1Gi", "1073741824"},
+		{"1.5Ki", "1536"},
+		{"250m", "1/4"},
+		{"2k", "2000"},
+		{"1e3", "1000"},
+		{float64(3), "3"},
+	} {
+		q, ok := parseQuantity(tt.value)
+		if !ok || q.RatString() != tt.want {
+			t.Errorf("parseQuantity(%v) = %v, %v; want %s", tt.value, q, ok, tt.want)
+		}
+	}
+	for _, value := range []interface{}{"1GB", "Gi", "", true} {
+		if _, ok := parseQuantity(value); ok {
+			t.Errorf("Expected parseQuantity(%v) to fail", value)
+		}
+	}
+}
+
+func TestCompare_CaseInsensitiveValues(t *testing.T) {
+	cfg := config.Default()
+	cfg.CaseInsensitiveValues = true
+	d := NewDiffer(cfg, false)
+
+	if diff := d.Compare(map[string]interface{}{"status": "RUNNING"}, map[string]interface{}{"status": "running"}); !diff.IsEmpty() {
+		t.Errorf("Expected RUNNING and running to be equal, got %+v", GetAllDiffs(diff))
+	}
+
+	diffs := GetAllDiffs(d.Compare(map[string]interface{}{"status": "RUNNING"}, map[string]interface{}{"status": "STOPPED"}))
+	if len(diffs) != 1 || diffs[0].Type != DiffTypeModified {
+		t.Fatalf("Expected status to be modified, got %+v", diffs)
+	}
+	if diffs[0].Value1 != "RUNNING" || diffs[0].Value2 != "STOPPED" {
+		t.Errorf("Expected the original values, got %v -> %v", diffs[0].Value1, diffs[0].Value2)
+	}
+}
+
+func TestCompare_CaseInsensitiveValuePaths(t *testing.T) {
+	cfg := config.Default()
+	cfg.CaseInsensitiveValues = true
+	cfg.CaseInsensitiveValuePaths = []string{"status"}
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{"status": "RUNNING", "name": "Web"}
+	obj2 := map[string]interface{}{"status": "running", "name": "web"}
+
+	diffs := GetAllDiffs(d.Compare(obj1, obj2))
+	if len(diffs) != 1 || diffs[0].Path != "name" {
+		t.Errorf("Expected only name to differ, got %+v", diffs)
+	}
+
+	// Without the flag, the paths alone change nothing
+	cfg.CaseInsensitiveValues = false
+	if diffs := GetAllDiffs(NewDiffer(cfg, false).Compare(obj1, obj2)); len(diffs) != 2 {
+		t.Errorf("Expected both fields to differ, got %+v", diffs)
+	}
+}
+
+func TestCompare_AllowedDelta(t *testing.T) {
+	cfg := config.Default()
+	cfg.AllowedDelta = map[string]float64{"quotas[*].usage": 10}
+	d := NewDiffer(cfg, false)
+
+	quotas := func(usage, limit float64) map[string]interface{} {
+		return map[string]interface{}{"quotas": []interface{}{
+			map[string]interface{}{"usage": usage, "limit": limit},
+		}}
+	}
+
+	if diff := d.Compare(quotas(40, 100), quotas(50, 100)); !diff.IsEmpty() {
+		t.Errorf("Expected a change within the allowed delta to be ignored, got %+v", GetAllDiffs(diff))
+	}
+
+	diffs := GetAllDiffs(d.Compare(quotas(40, 100), quotas(51, 101)))
+	if len(diffs) != 2 || diffs[0].Path != "quotas[0].limit" || diffs[1].Path != "quotas[0].usage" {
+		t.Errorf("Expected a change beyond the delta and the unlisted field to be reported, got %+v", diffs)
+	}
+}
+
+func TestCompare_URLCanonicalize(t *testing.T) {
+	cfg := config.Default()
+	cfg.URLCanonicalize = true
+	cfg.NormalizeURLPaths = []string{"*Url"}
+	d := NewDiffer(cfg, false)
+
+	equal := map[string][2]string{
+		"trailing slash": {"https://example.com/hooks/", "https://example.com/hooks"},
+		"query order":    {"https://example.com/hooks?b=2&a=1", "https://example.com/hooks?a=1&b=2"},
+		"scheme":         {"http://Example.COM/hooks", "https://example.com/hooks"},
+	}
+	for name, urls := range equal {
+		t.Run(name, func(t *testing.T) {
+			diff := d.Compare(map[string]interface{}{"callbackUrl": urls[0]}, map[string]interface{}{"callbackUrl": urls[1]})
+			if !diff.IsEmpty() {
+				t.Errorf("Expected %s and %s to be equal, got %+v", urls[0], urls[1], GetAllDiffs(diff))
+			}
+		})
+	}
+
+	diffs := GetAllDiffs(d.Compare(
+		map[string]interface{}{"callbackUrl": "https://example.com/hooks/", "name": "web"},
+		map[string]interface{}{"callbackUrl": "https://example.com/other", "name": "web/"},
+	))
+	if len(diffs) != 2 {
+		t.Fatalf("Expected a different URL and the unlisted field to differ, got %+v", diffs)
+	}
+	if diffs[0].Value1 != "https://example.com/hooks/" || diffs[0].Value2 != "https://example.com/other" {
+		t.Errorf("Expected the original values, got %v -> %v", diffs[0].Value1, diffs[0].Value2)
+	}
+
+	// Values that aren't URLs are compared as strings
+	if diff := d.Compare(map[string]interface{}{"logUrl": "none/"}, map[string]interface{}{"logUrl": "none"}); diff.IsEmpty() {
+		t.Error("Expected non-URL values to be compared as strings")
+	}
+}
+
+func TestCanonicalSemver(t *testing.T) {
+	tests := map[string]string{
+		"1":               "1.0.0",
+		"01.2":            "1.2.0",
+		"v2.3.4-rc.1":     "2.3.4-rc.1",
+		"1.0.0+20240101":  "1.0.0",
+		"1.27.3-gke.1200": "1.27.3-gke.1200",
+	}
+	for input, want := range tests {
+		if got, ok := canonicalSemver(input); !ok || got != want {
+			t.Errorf("canonicalSemver(%q) = %q, %v; want %q", input, got, ok, want)
+		}
+	}
+
+	for _, input := range []interface{}{"latest", "1.2.3.4", 1.2, ""} {
+		if _, ok := canonicalSemver(input); ok {
+			t.Errorf("Expected %v not to parse as a version", input)
+		}
+	}
+}
+
+func TestCompareValues_TopLevelArrays(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+
+	val1 := []interface{}{map[string]interface{}{"name": "a", "size": float64(10)}, "x"}
+	val2 := []interface{}{map[string]interface{}{"name": "a", "size": float64(20)}, "x", "y"}
+
+	diffs := GetAllDiffs(d.CompareValues(val1, val2))
+
+	if len(diffs) != 2 {
+		t.Fatalf("Expected 2 differences, got %v", diffs)
+	}
+	if diffs[0].Path != "[0].size" || diffs[0].Type != DiffTypeModified {
+		t.Errorf("Expected [0].size to be modified, got %+v", diffs[0])
+	}
+	if diffs[1].Path != "[2]" || diffs[1].Type != DiffTypeAdded {
+		t.Errorf("Expected [2] to be added, got %+v", diffs[1])
+	}
+}
+
+func TestCompareValues_TopLevelScalars(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+
+	if diff := d.CompareValues("RUNNING", "RUNNING"); !diff.IsEmpty() {
+		t.Errorf("Expected equal scalars to produce no differences, got %+v", diff)
+	}
+
+	diff := d.CompareValues(float64(1), float64(2))
+	if diff.Type != DiffTypeModified || diff.Value1 != float64(1) || diff.Value2 != float64(2) {
+		t.Errorf("Expected a modified root diff, got %+v", diff)
+	}
+	if d.ComparedFields() != 1 {
+		t.Errorf("Expected 1 compared field, got %d", d.ComparedFields())
+	}
+}
+
+func TestCompare_IgnoreEmptyString(t *testing.T) {
+	obj1 := map[string]interface{}{
+		"description": "",
+		"hostname":    "",
+		"zone":        "us-central1-a",
+		"network":     "",
+	}
+	obj2 := map[string]interface{}{
+		"hostname": "",
+		"zone":     "us-central1-b",
+		"network":  "default",
+	}
+
+	cfg := config.Default()
+	cfg.IncludeEqual = true
+	if diffs := GetAllDiffs(NewDiffer(cfg, false).Compare(obj1, obj2)); len(diffs) != 3 {
+		t.Errorf("Expected description, zone and network to differ by default, got %v", diffs)
+	}
+
+	cfg.IgnoreEmptyString = true
+	diff := NewDiffer(cfg, false).Compare(obj1, obj2)
+
+	diffs := GetAllDiffs(diff)
+	if len(diffs) != 2 || diffs[0].Path != "network" || diffs[1].Path != "zone" {
+		t.Errorf("Expected only network and zone to differ, got %v", diffs)
+	}
+	for _, key := range []string{"description", "hostname"} {
+		if _, exists := diff.Children[key]; exists {
+			t.Errorf("Expected empty field %s to be left out of the full tree", key)
+		}
+	}
+}
+
+func TestCompare_ArrayKeysPattern(t *testing.T) {
+	cfg := config.Default()
+	cfg.ArrayKeys = map[string][]string{"nodePools[*].disks": {"name"}}
+	d := NewDiffer(cfg, false)
+
+	pool := func(disks ...interface{}) map[string]interface{} {
+		return map[string]interface{}{"nodePools": []interface{}{map[string]interface{}{"disks": disks}}}
+	}
+	boot := map[string]interface{}{"name": "boot", "size": float64(10)}
+	data := map[string]interface{}{"name": "data", "size": float64(100)}
+
+	if diff := d.Compare(pool(boot, data), pool(data, boot)); !diff.IsEmpty() {
+		t.Errorf("Expected reordered disks matched by name to be equal, got %v", GetAllDiffs(diff))
+	}
+}
@@ -0,0 +1,81 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// dotColors are the node fill colors for each change type
+var dotColors = map[DiffType]string{
+	DiffTypeAdded:    "palegreen",
+	DiffTypeRemoved:  "lightpink",
+	DiffTypeModified: "khaki",
+	DiffTypeEqual:    "white",
+}
+
+// maxDOTValueLen caps how much of a value a leaf label shows
+const maxDOTValueLen = 40
+
+// WriteDOT writes the changed part of a diff tree as a GraphViz DOT graph,
+// with a node per changed path colored by change type and leaves labeled
+// with their old and new values. Render it with e.g. `dot -Tsvg`.
+func WriteDOT(w io.Writer, diff *Diff) {
+	fmt.Fprintln(w, "digraph diff {")
+	fmt.Fprintln(w, `  node [shape=box, style=filled, fontname="monospace"];`)
+
+	ids := 0
+	var writeNode func(d *Diff, label string) string
+	writeNode = func(d *Diff, label string) string {
+		id := fmt.Sprintf("n%d", ids)
+		ids++
+
+		if len(d.Children) == 0 && d.Path != "" {
+			label += "\n" + dotLeafValues(d)
+		}
+		fmt.Fprintf(w, "  %s [label=%s, tooltip=%s, fillcolor=%s];\n",
+			id, dotQuote(label), dotQuote(d.Path), dotColors[d.Type])
+
+		for _, key := range getSortedKeys(d.Children) {
+			child := d.Children[key]
+			if child.Type == DiffTypeEqual {
+				continue
+			}
+			childID := writeNode(child, key)
+			fmt.Fprintf(w, "  %s -> %s;\n", id, childID)
+		}
+		return id
+	}
+	writeNode(diff, "(resource)")
+
+	fmt.Fprintln(w, "}")
+}
+
+// dotLeafValues describes a leaf change as "old -> new"
+func dotLeafValues(d *Diff) string {
+	switch d.Type {
+	case DiffTypeAdded:
+		return "+ " + dotValue(d.Value2)
+	case DiffTypeRemoved:
+		return "- " + dotValue(d.Value1)
+	default:
+		return dotValue(d.Value1) + " -> " + dotValue(d.Value2)
+	}
+}
+
+// dotValue renders a value as compact JSON, truncated to maxDOTValueLen runes
+func dotValue(v interface{}) string {
+	data, _ := json.Marshal(v)
+	s := []rune(string(data))
+	if len(s) > maxDOTValueLen {
+		return string(s[:maxDOTValueLen-3]) + "..."
+	}
+	return string(s)
+}
+
+// dotQuote returns s as a DOT quoted string, with newlines as line breaks
+func dotQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
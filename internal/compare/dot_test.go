@@ -0,0 +1,62 @@
+package compare
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"scheduling": {
+				Path: "scheduling",
+				Type: DiffTypeModified,
+				Children: map[string]*Diff{
+					"preemptible": {Path: "scheduling.preemptible", Type: DiffTypeModified, Value1: false, Value2: true},
+				},
+			},
+			"labels":      {Path: "labels", Type: DiffTypeAdded, Value2: map[string]interface{}{"env": "prod"}},
+			"description": {Path: "description", Type: DiffTypeRemoved, Value1: "web"},
+			"name":        {Path: "name", Type: DiffTypeEqual, Value1: "vm", Value2: "vm"},
+		},
+	}
+
+	var buf bytes.Buffer
+	WriteDOT(&buf, diff)
+	output := buf.String()
+
+	if !strings.HasPrefix(output, "digraph diff {\n") || !strings.HasSuffix(output, "}\n") {
+		t.Errorf("Expected a digraph, got:\n%s", output)
+	}
+
+	expected := []string{
+		`[label="description\n- \"web\"", tooltip="description", fillcolor=lightpink]`,
+		`[label="labels\n+ {\"env\":\"prod\"}", tooltip="labels", fillcolor=palegreen]`,
+		`[label="scheduling", tooltip="scheduling", fillcolor=khaki]`,
+		`[label="preemptible\nfalse -> true", tooltip="scheduling.preemptible", fillcolor=khaki]`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %s, got:\n%s", want, output)
+		}
+	}
+
+	if strings.Contains(output, `tooltip="name"`) {
+		t.Errorf("Expected no node for an equal field, got:\n%s", output)
+	}
+	if nodes := strings.Count(output, "fillcolor="); nodes != 5 {
+		t.Errorf("Expected 5 nodes (root and 4 changed paths), got %d", nodes)
+	}
+	if edges := strings.Count(output, " -> n"); edges != 4 {
+		t.Errorf("Expected 4 edges, got %d", edges)
+	}
+}
+
+func TestDotValueTruncates(t *testing.T) {
+	got := dotValue(strings.Repeat("x", 100))
+	if len([]rune(got)) != maxDOTValueLen || !strings.HasSuffix(got, "...") {
+		t.Errorf("Expected a truncated value, got %q", got)
+	}
+}
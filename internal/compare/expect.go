@@ -0,0 +1,62 @@
+package compare
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// LoadExpectedDiff loads the leaf differences of a diff saved with
+// --format=json, or of a baseline file written by SaveBaseline
+func LoadExpectedDiff(path string) ([]*Diff, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Baselines are a list of leaves; json output is the whole tree
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var entries []*Diff
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse expected diff %s: %w", path, err)
+		}
+		return entries, nil
+	}
+
+	var diff Diff
+	if err := json.Unmarshal(data, &diff); err != nil {
+		return nil, fmt.Errorf("failed to parse expected diff %s: %w", path, err)
+	}
+	return GetAllDiffs(&diff), nil
+}
+
+// CompareExpected matches the leaf differences of diff against expected by
+// path, type and values. It returns the expected differences that were not
+// found and the differences found that were not expected, both in path order.
+func CompareExpected(diff *Diff, expected []*Diff) (missing, unexpected []*Diff) {
+	want := make(map[string]bool, len(expected))
+	for _, entry := range expected {
+		want[baselineKey(entry)] = true
+	}
+
+	got := make(map[string]bool)
+	for _, entry := range GetAllDiffs(diff) {
+		key := baselineKey(entry)
+		got[key] = true
+		if !want[key] {
+			unexpected = append(unexpected, entry)
+		}
+	}
+
+	for _, entry := range expected {
+		if !got[baselineKey(entry)] {
+			missing = append(missing, entry)
+		}
+	}
+	sort.SliceStable(missing, func(i, j int) bool {
+		return lessPath(missing[i].Path, missing[j].Path)
+	})
+	return missing, unexpected
+}
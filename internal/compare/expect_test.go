@@ -0,0 +1,79 @@
+package compare
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func expectTestDiff() *Diff {
+	return &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"machineType": {Path: "machineType", Type: DiffTypeModified, Value1: "n1-standard-2", Value2: "n1-standard-4"},
+			"labels": {
+				Path: "labels",
+				Type: DiffTypeModified,
+				Children: map[string]*Diff{
+					"env": {Path: "labels.env", Type: DiffTypeAdded, Value2: "prod"},
+				},
+			},
+		},
+	}
+}
+
+func TestLoadExpectedDiff_Formats(t *testing.T) {
+	dir := t.TempDir()
+
+	// A diff saved with --format=json
+	tree, _ := json.MarshalIndent(expectTestDiff(), "", "  ")
+	treePath := filepath.Join(dir, "expected.json")
+	if err := os.WriteFile(treePath, tree, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A baseline file
+	baselinePath := filepath.Join(dir, "baseline.json")
+	if err := SaveBaseline(baselinePath, expectTestDiff()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{treePath, baselinePath} {
+		expected, err := LoadExpectedDiff(path)
+		if err != nil {
+			t.Fatalf("LoadExpectedDiff(%s) failed: %v", path, err)
+		}
+		missing, unexpected := CompareExpected(expectTestDiff(), expected)
+		if len(missing) != 0 || len(unexpected) != 0 {
+			t.Errorf("%s: expected a match, got missing %v and unexpected %v", path, missing, unexpected)
+		}
+	}
+}
+
+func TestCompareExpected_Mismatch(t *testing.T) {
+	expected := GetAllDiffs(expectTestDiff())
+
+	actual := expectTestDiff()
+	actual.Children["machineType"].Value2 = "e2-medium"
+	actual.Children["zone"] = &Diff{Path: "zone", Type: DiffTypeRemoved, Value1: "us-central1-a"}
+
+	missing, unexpected := CompareExpected(actual, expected)
+
+	if len(missing) != 1 || missing[0].Path != "machineType" || missing[0].Value2 != "n1-standard-4" {
+		t.Errorf("Expected the original machineType change to be missing, got %v", missing)
+	}
+	if len(unexpected) != 2 || unexpected[0].Path != "machineType" || unexpected[1].Path != "zone" {
+		t.Errorf("Expected unexpected machineType and zone changes, got %v", unexpected)
+	}
+}
+
+func TestLoadExpectedDiff_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadExpectedDiff(path); err == nil {
+		t.Error("Expected a parse error")
+	}
+}
@@ -0,0 +1,97 @@
+package compare
+
+import (
+	"strconv"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+// CompareFields diffs only the values at the listed field paths, such as
+// disks[0].type, ignoring the rest of both resources. A path present on one
+// side only is reported as added or removed; paths present on neither side
+// are returned as absent. The diff holds each compared path beneath its
+// parent fields, so it renders like a full diff.
+func (d *Differ) CompareFields(obj1, obj2 map[string]interface{}, fields []string) (diff *Diff, absent []string) {
+	d.compared = 0
+	d.warnings = nil
+	obj1, _ = d.migrateFields(obj1).(map[string]interface{})
+	obj2, _ = d.migrateFields(obj2).(map[string]interface{})
+
+	diff = &Diff{Type: DiffTypeEqual, Children: make(map[string]*Diff)}
+	for _, field := range fields {
+		val1, ok1 := lookupField(obj1, field)
+		val2, ok2 := lookupField(obj2, field)
+		if !ok1 && !ok2 {
+			absent = append(absent, field)
+			continue
+		}
+		insertFieldDiff(diff, fieldKeys(field), d.compareValues(val1, val2, field))
+	}
+
+	// As in compareObjects, equal fields are only kept next to a change
+	if !d.config.IncludeEqual {
+		pruneEqual(diff)
+	}
+	return diff, absent
+}
+
+// pruneEqual drops the children of every unchanged node of diff
+func pruneEqual(diff *Diff) {
+	if diff.Type == DiffTypeEqual {
+		if len(diff.Children) > 0 {
+			diff.Children = make(map[string]*Diff)
+		}
+		return
+	}
+	for _, child := range diff.Children {
+		pruneEqual(child)
+	}
+}
+
+// fieldKeys splits a field path into the keys of its diff tree nodes: object
+// keys, and "[n]" for array indices
+func fieldKeys(field string) []string {
+	tokens, err := config.SplitPath(field)
+	if err != nil {
+		return []string{field}
+	}
+	keys := make([]string, len(tokens))
+	for i, token := range tokens {
+		keys[i] = token.Text
+		if token.Index {
+			keys[i] = "[" + token.Text + "]"
+		}
+	}
+	return keys
+}
+
+// insertFieldDiff adds child to diff under the path of keys, creating the
+// parent nodes in between and marking them modified when child differs
+func insertFieldDiff(diff *Diff, keys []string, child *Diff) {
+	for _, key := range keys[:len(keys)-1] {
+		if child.Type != DiffTypeEqual {
+			diff.Type = DiffTypeModified
+		}
+		next, ok := diff.Children[key]
+		if !ok || next.Children == nil {
+			next = &Diff{Path: childPath(diff.Path, key), Type: DiffTypeEqual, Children: make(map[string]*Diff)}
+			diff.Children[key] = next
+		}
+		diff = next
+	}
+	if child.Type != DiffTypeEqual {
+		diff.Type = DiffTypeModified
+	}
+	diff.Children[keys[len(keys)-1]] = child
+}
+
+// childPath returns the path of the node under key, an object key or an
+// array index like "[0]"
+func childPath(path, key string) string {
+	if n := len(key); n > 2 && key[0] == '[' && key[n-1] == ']' {
+		if _, err := strconv.Atoi(key[1 : n-1]); err == nil {
+			return path + key
+		}
+	}
+	return joinPath(path, key)
+}
@@ -0,0 +1,83 @@
+package compare
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+func TestCompareFields(t *testing.T) {
+	obj1 := map[string]interface{}{
+		"machineType": "n1-standard-2",
+		"disks": []interface{}{
+			map[string]interface{}{"type": "pd-standard", "sizeGb": float64(10)},
+			map[string]interface{}{"type": "pd-ssd"},
+		},
+		"labels":                 map[string]interface{}{"env": "dev", "team": "web"},
+		"serviceAccountEmail":    "sa@example.com",
+		"shieldedInstanceConfig": map[string]interface{}{"enableSecureBoot": true},
+	}
+	obj2 := map[string]interface{}{
+		"machineType": "n1-standard-4",
+		"disks": []interface{}{
+			map[string]interface{}{"type": "pd-ssd", "sizeGb": float64(20)},
+			map[string]interface{}{"type": "pd-ssd"},
+		},
+		"labels":              map[string]interface{}{"env": "prod", "team": "web"},
+		"serviceAccountEmail": "sa@example.com",
+	}
+
+	d := NewDiffer(config.Default(), false)
+	diff, absent := d.CompareFields(obj1, obj2, []string{
+		"disks[0].type",
+		"disks[1].type",
+		"labels.team",
+		"serviceAccountEmail",
+		"shieldedInstanceConfig.enableSecureBoot",
+		"scheduling.preemptible",
+	})
+
+	// Only the listed paths are compared: machineType, disks[0].sizeGb and
+	// labels.env differ but aren't listed
+	var paths []string
+	for _, change := range GetAllDiffs(diff) {
+		paths = append(paths, change.Path+":"+string(change.Type))
+	}
+	want := []string{"disks[0].type:modified", "shieldedInstanceConfig.enableSecureBoot:removed"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected changes %v, got %v", want, paths)
+	}
+	if !reflect.DeepEqual(absent, []string{"scheduling.preemptible"}) {
+		t.Errorf("Expected scheduling.preemptible to be absent, got %v", absent)
+	}
+
+	// The changes sit beneath their parent fields
+	disk := diff.Children["disks"].Children["[0]"]
+	if disk == nil || disk.Path != "disks[0]" || disk.Type != DiffTypeModified {
+		t.Fatalf("Expected a modified disks[0] node, got %+v", disk)
+	}
+	if labels := diff.Children["labels"]; labels.Type != DiffTypeEqual || len(labels.Children) != 0 {
+		t.Errorf("Expected the unchanged labels to be pruned, got %+v", labels)
+	}
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2WithOptions(&buf, diff, "a", "b", OutputOptions{Color: ColorNever})
+	if !strings.Contains(buf.String(), "pd-standard") || strings.Contains(buf.String(), "machineType") {
+		t.Errorf("Expected only the listed fields in the output, got:\n%s", buf.String())
+	}
+}
+
+func TestCompareFields_QuotedKey(t *testing.T) {
+	obj1 := map[string]interface{}{"labels": map[string]interface{}{"app.kubernetes.io/name": "web"}}
+	obj2 := map[string]interface{}{"labels": map[string]interface{}{"app.kubernetes.io/name": "api"}}
+
+	d := NewDiffer(config.Default(), false)
+	diff, _ := d.CompareFields(obj1, obj2, []string{`labels."app.kubernetes.io/name"`})
+	child := diff.Children["labels"].Children["app.kubernetes.io/name"]
+	if child == nil || child.Type != DiffTypeModified {
+		t.Errorf("Expected the quoted key to be compared, got %+v", diff.Children["labels"])
+	}
+}
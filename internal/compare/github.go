@@ -0,0 +1,62 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteGitHubAnnotations writes each leaf difference as a GitHub Actions
+// ::warning workflow command, so changes surface as annotations in the run
+func WriteGitHubAnnotations(w io.Writer, diff *Diff) {
+	diffs := GetAllDiffs(diff)
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, "::notice::No differences")
+		return
+	}
+
+	for _, d := range diffs {
+		var title string
+		switch d.Type {
+		case DiffTypeAdded:
+			title = "Field added"
+		case DiffTypeRemoved:
+			title = "Field removed"
+		default:
+			title = "Field changed"
+		}
+
+		message := fmt.Sprintf("%s %s -> %s", d.Path, annotationValue(d.Value1, d.Type == DiffTypeAdded),
+			annotationValue(d.Value2, d.Type == DiffTypeRemoved))
+		if d.Note != "" {
+			message += " (" + d.Note + ")"
+		}
+		fmt.Fprintf(w, "::warning title=%s::%s\n", escapeAnnotationProperty(title), escapeAnnotationData(message))
+	}
+}
+
+// annotationValue renders a value for an annotation message: strings as is,
+// anything else as JSON, and a missing side as <absent>
+func annotationValue(v interface{}, absent bool) string {
+	if absent {
+		return "<absent>"
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// escapeAnnotationData escapes a workflow command message, which GitHub
+// reads up to the end of the line
+func escapeAnnotationData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeAnnotationProperty escapes a workflow command property value, where
+// ':' and ',' also delimit the command
+func escapeAnnotationProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,60 @@
+package compare
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteGitHubAnnotations(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"machineType": {Path: "machineType", Type: DiffTypeModified, Value1: "n1-standard-2", Value2: "n1-standard-4"},
+			"labels":      {Path: "labels", Type: DiffTypeAdded, Value2: map[string]interface{}{"env": "prod"}},
+			"description": {Path: "description", Type: DiffTypeRemoved, Value1: "web"},
+		},
+	}
+
+	var buf bytes.Buffer
+	WriteGitHubAnnotations(&buf, diff)
+
+	expected := "::warning title=Field removed::description web -> <absent>\n" +
+		"::warning title=Field added::labels <absent> -> {\"env\":\"prod\"}\n" +
+		"::warning title=Field changed::machineType n1-standard-2 -> n1-standard-4\n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteGitHubAnnotations_Escaping(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"startupScript": {Path: "startupScript", Type: DiffTypeModified, Value1: "echo 50%\nexit", Value2: "url: http://x"},
+		},
+	}
+
+	var buf bytes.Buffer
+	WriteGitHubAnnotations(&buf, diff)
+
+	expected := "::warning title=Field changed::startupScript echo 50%25%0Aexit -> url: http://x\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestEscapeAnnotationProperty(t *testing.T) {
+	if got := escapeAnnotationProperty("a:b,c%\n"); got != "a%3Ab%2Cc%25%0A" {
+		t.Errorf("Unexpected escaping: %q", got)
+	}
+}
+
+func TestWriteGitHubAnnotations_NoDifferences(t *testing.T) {
+	var buf bytes.Buffer
+	WriteGitHubAnnotations(&buf, &Diff{Type: DiffTypeEqual})
+
+	if !strings.HasPrefix(buf.String(), "::notice::No differences") {
+		t.Errorf("Expected a notice, got %q", buf.String())
+	}
+}
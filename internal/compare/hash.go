@@ -0,0 +1,49 @@
+package compare
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+// HashResource returns the SHA-256 of resource's canonical JSON, in hex.
+// Fields that cfg ignores or leaves out of only_fields are dropped first, as
+// comparison would skip them, so two resources hash equal exactly when they
+// differ only in fields a diff wouldn't report. Object keys are sorted, so key
+// order doesn't matter.
+func HashResource(resource map[string]interface{}, cfg *config.Config) (string, error) {
+	data, err := json.Marshal(canonicalValue(resource, "", cfg))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalValue returns a copy of value at path without the object fields
+// that comparison skips
+func canonicalValue(value interface{}, path string, cfg *config.Config) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		kept := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			fieldPath := joinPath(path, key)
+			if cfg.ShouldIgnore(fieldPath) || !cfg.IsAllowed(fieldPath) {
+				continue
+			}
+			kept[key] = canonicalValue(child, fieldPath, cfg)
+		}
+		return kept
+	case []interface{}:
+		elements := make([]interface{}, len(v))
+		for i, element := range v {
+			elements[i] = canonicalValue(element, fmt.Sprintf("%s[%d]", path, i), cfg)
+		}
+		return elements
+	default:
+		return value
+	}
+}
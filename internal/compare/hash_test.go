@@ -0,0 +1,73 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+func hashTestResource() map[string]interface{} {
+	return map[string]interface{}{
+		"id":          "123",
+		"machineType": "n1-standard-2",
+		"disks": []interface{}{
+			map[string]interface{}{"deviceName": "boot", "sizeGb": float64(10)},
+		},
+		"labels": map[string]interface{}{"env": "prod", "team": "web"},
+	}
+}
+
+func TestHashResource(t *testing.T) {
+	cfg := config.Default()
+	hash := func(resource map[string]interface{}) string {
+		t.Helper()
+		h, err := HashResource(resource, cfg)
+		if err != nil {
+			t.Fatalf("HashResource failed: %v", err)
+		}
+		return h
+	}
+
+	base := hash(hashTestResource())
+	if len(base) != 64 {
+		t.Errorf("Expected a hex SHA-256, got %q", base)
+	}
+	if got := hash(hashTestResource()); got != base {
+		t.Errorf("Expected identical resources to hash equal, got %s and %s", base, got)
+	}
+
+	// Ignored fields don't affect the hash
+	ignored := hashTestResource()
+	ignored["id"] = "456"
+	if got := hash(ignored); got != base {
+		t.Errorf("Expected a change to ignored fields to keep the hash, got %s", got)
+	}
+
+	changed := hashTestResource()
+	changed["labels"].(map[string]interface{})["env"] = "staging"
+	if got := hash(changed); got == base {
+		t.Error("Expected a change to a compared field to change the hash")
+	}
+
+	// Fields ignored under a path are left out beneath array elements too
+	cfg.IgnoreUnderPath = []string{"disks[*]"}
+	base = hash(hashTestResource())
+	ignored = hashTestResource()
+	ignored["disks"].([]interface{})[0].(map[string]interface{})["sizeGb"] = float64(20)
+	if got := hash(ignored); got != base {
+		t.Errorf("Expected a change beneath an ignored path to keep the hash, got %s", got)
+	}
+}
+
+func TestHashResource_OnlyFields(t *testing.T) {
+	cfg := config.Default()
+	cfg.OnlyFields = []string{"machineType"}
+
+	changed := hashTestResource()
+	changed["labels"] = map[string]interface{}{}
+	h1, _ := HashResource(hashTestResource(), cfg)
+	h2, _ := HashResource(changed, cfg)
+	if h1 != h2 {
+		t.Error("Expected fields outside only_fields to be left out of the hash")
+	}
+}
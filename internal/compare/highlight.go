@@ -0,0 +1,120 @@
+package compare
+
+import (
+	"regexp"
+	"strings"
+)
+
+// styledText is a value already formatted and colored for display, printed
+// as is
+type styledText string
+
+// maxHighlightTokens bounds the token diff of highlight; longer strings are
+// only matched by their common prefix and suffix
+const maxHighlightTokens = 256
+
+// highlightTokenPattern splits strings into runs of letters and digits and
+// single other characters, so an IP address diffs by octet
+var highlightTokenPattern = regexp.MustCompile(`[\pL\pN]+|.`)
+
+// highlight renders the old and new values of a modified single-line string
+// with the tokens they share dimmed and the differing tokens in red and green
+// respectively, so a small change to a long value stands out. Other values,
+// and strings with nothing in common, are returned unchanged.
+func (o OutputOptions) highlight(val1, val2 interface{}) (interface{}, interface{}) {
+	s1, ok1 := val1.(string)
+	s2, ok2 := val2.(string)
+	if !ok1 || !ok2 || strings.Contains(s1, "\n") || strings.Contains(s2, "\n") {
+		return val1, val2
+	}
+
+	tokens1 := highlightTokenPattern.FindAllString(formatString(s1, o), -1)
+	tokens2 := highlightTokenPattern.FindAllString(formatString(s2, o), -1)
+	common1, common2 := commonTokens(tokens1, tokens2)
+
+	// Shared quotes alone don't make the values similar
+	shared := 0
+	for i, common := range common1 {
+		if common && tokens1[i] != `"` {
+			shared++
+		}
+	}
+	if shared == 0 {
+		return val1, val2
+	}
+	return o.styleTokens(tokens1, common1, o.colors.red), o.styleTokens(tokens2, common2, o.colors.green)
+}
+
+// styleTokens joins tokens, dimming the common ones and coloring the rest
+// with colorFunc. Runs of tokens in the same style are colored together.
+func (o OutputOptions) styleTokens(tokens []string, common []bool, colorFunc func(...interface{}) string) styledText {
+	var b strings.Builder
+	for start := 0; start < len(tokens); {
+		end := start + 1
+		for end < len(tokens) && common[end] == common[start] {
+			end++
+		}
+		run := strings.Join(tokens[start:end], "")
+		if common[start] {
+			b.WriteString(o.colors.gray(run))
+		} else {
+			b.WriteString(colorFunc(run))
+		}
+		start = end
+	}
+	return styledText(b.String())
+}
+
+// commonTokens marks the tokens of each side that belong to their longest
+// common subsequence. Beyond maxHighlightTokens only the common prefix and
+// suffix are marked.
+func commonTokens(tokens1, tokens2 []string) ([]bool, []bool) {
+	common1 := make([]bool, len(tokens1))
+	common2 := make([]bool, len(tokens2))
+
+	prefix := 0
+	for prefix < len(tokens1) && prefix < len(tokens2) && tokens1[prefix] == tokens2[prefix] {
+		common1[prefix], common2[prefix] = true, true
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(tokens1)-prefix && suffix < len(tokens2)-prefix &&
+		tokens1[len(tokens1)-1-suffix] == tokens2[len(tokens2)-1-suffix] {
+		common1[len(tokens1)-1-suffix], common2[len(tokens2)-1-suffix] = true, true
+		suffix++
+	}
+
+	middle1 := tokens1[prefix : len(tokens1)-suffix]
+	middle2 := tokens2[prefix : len(tokens2)-suffix]
+	if len(middle1) > maxHighlightTokens || len(middle2) > maxHighlightTokens {
+		return common1, common2
+	}
+
+	// lcs[i][j] holds the LCS length of middle1[i:] and middle2[j:]
+	lcs := make([][]int, len(middle1)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(middle2)+1)
+	}
+	for i := len(middle1) - 1; i >= 0; i-- {
+		for j := len(middle2) - 1; j >= 0; j-- {
+			if middle1[i] == middle2[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	for i, j := 0, 0; i < len(middle1) && j < len(middle2); {
+		switch {
+		case middle1[i] == middle2[j]:
+			common1[prefix+i], common2[prefix+j] = true, true
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return common1, common2
+}
@@ -0,0 +1,67 @@
+package compare
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHighlight_ChangedSegments(t *testing.T) {
+	opts := OutputOptions{Color: ColorAlways}.withColors()
+	c := opts.colors
+
+	value1, value2 := opts.highlight("35.123.45.67", "35.234.56.78")
+	want1 := c.gray(`"35.`) + c.red("123") + c.gray(".") + c.red("45") + c.gray(".") + c.red("67") + c.gray(`"`)
+	want2 := c.gray(`"35.`) + c.green("234") + c.gray(".") + c.green("56") + c.gray(".") + c.green("78") + c.gray(`"`)
+	if !strings.Contains(want1, "\x1b[") {
+		t.Fatalf("Expected colored output, got %q", want1)
+	}
+	if value1 != styledText(want1) {
+		t.Errorf("Unexpected old value %q, want %q", value1, want1)
+	}
+	if value2 != styledText(want2) {
+		t.Errorf("Unexpected new value %q, want %q", value2, want2)
+	}
+}
+
+func TestHighlight_Unchanged(t *testing.T) {
+	opts := OutputOptions{Color: ColorAlways}.withColors()
+	for _, tt := range []struct {
+		name           string
+		value1, value2 interface{}
+	}{
+		{"nothing in common", "alpha", "beta"},
+		{"not strings", float64(1), float64(2)},
+		{"multiline", "a\nb", "a\nc"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			value1, value2 := opts.highlight(tt.value1, tt.value2)
+			if value1 != tt.value1 || value2 != tt.value2 {
+				t.Errorf("Expected the values unchanged, got %q and %q", value1, value2)
+			}
+		})
+	}
+}
+
+func TestPrintGitStyleDiffV2_Highlight(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"natIP": {Path: "natIP", Type: DiffTypeModified, Value1: "35.123.45.67", Value2: "35.234.56.78"},
+		},
+	}
+
+	// Without colors the values print as before
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2WithOptions(&buf, diff, "a", "b", OutputOptions{Color: ColorNever})
+	if !strings.Contains(buf.String(), "    - \"35.123.45.67\"\n    + \"35.234.56.78\"\n") {
+		t.Errorf("Expected plain values, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	opts := OutputOptions{Color: ColorAlways}.withColors()
+	PrintGitStyleDiffV2WithOptions(&buf, diff, "a", "b", opts)
+	if !strings.Contains(buf.String(), opts.colors.gray(`"35.`)+opts.colors.red("123")) {
+		t.Errorf("Expected the changed octets highlighted, got:\n%q", buf.String())
+	}
+}
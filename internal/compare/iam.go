@@ -0,0 +1,158 @@
+package compare
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// iamBindingsPath is where --iam merges a resource's policy bindings
+const iamBindingsPath = "iamPolicy.bindings"
+
+// compareIAMBindings compares IAM policy bindings matched by role rather than
+// by index, treating each binding's members as an unordered set. Children are
+// keyed by role. It returns nil if the bindings can't be matched by role (an
+// element isn't an object with a role, or a role repeats, as it can for
+// conditional bindings), in which case callers fall back to the index-based
+// comparison.
+func (d *Differ) compareIAMBindings(arr1, arr2 []interface{}, path string) *Diff {
+	bindings1, ok1 := bindingsByRole(arr1)
+	bindings2, ok2 := bindingsByRole(arr2)
+	if !ok1 || !ok2 {
+		return nil
+	}
+
+	diff := &Diff{
+		Path:     path,
+		Type:     DiffTypeEqual,
+		Children: make(map[string]*Diff),
+	}
+
+	roles := make(map[string]bool)
+	for role := range bindings1 {
+		roles[role] = true
+	}
+	for role := range bindings2 {
+		roles[role] = true
+	}
+
+	for role := range roles {
+		rolePath := fmt.Sprintf("%s[role=%s]", path, role)
+		binding1, exists1 := bindings1[role]
+		binding2, exists2 := bindings2[role]
+
+		switch {
+		case !exists1:
+			diff.Children[role] = &Diff{Path: rolePath, Type: DiffTypeAdded, Value2: binding2}
+			diff.Type = DiffTypeModified
+		case !exists2:
+			diff.Children[role] = &Diff{Path: rolePath, Type: DiffTypeRemoved, Value1: binding1}
+			diff.Type = DiffTypeModified
+		default:
+			childDiff := d.compareIAMBinding(binding1, binding2, rolePath)
+			if childDiff.Type != DiffTypeEqual {
+				diff.Children[role] = childDiff
+				diff.Type = DiffTypeModified
+			} else if d.config.IncludeEqual {
+				diff.Children[role] = childDiff
+			}
+		}
+	}
+
+	return diff
+}
+
+// compareIAMBinding compares two bindings for the same role, comparing
+// members as a set and any other fields (e.g. condition) as usual
+func (d *Differ) compareIAMBinding(binding1, binding2 map[string]interface{}, path string) *Diff {
+	rest1 := withoutKey(binding1, "members")
+	rest2 := withoutKey(binding2, "members")
+	diff := d.compareObjects(rest1, rest2, path)
+
+	membersPath := path + ".members"
+	if !d.showAll && d.config.ShouldIgnore(membersPath) {
+		return diff
+	}
+
+	members := compareMemberSets(toSlice(binding1["members"]), toSlice(binding2["members"]), membersPath)
+	if members.Type != DiffTypeEqual {
+		diff.Children["members"] = members
+		diff.Type = DiffTypeModified
+	}
+
+	return diff
+}
+
+// compareMemberSets reports members present on only one side. Added members
+// are keyed by their index in members2 and removed members by their index in
+// members1 as in addRemovedElement.
+func compareMemberSets(members1, members2 []interface{}, path string) *Diff {
+	diff := &Diff{
+		Path:     path,
+		Type:     DiffTypeEqual,
+		Children: make(map[string]*Diff),
+	}
+
+	for j, member := range members2 {
+		if !containsValue(members1, member) {
+			diff.Children[fmt.Sprintf("[%d]", j)] = &Diff{
+				Path:   fmt.Sprintf("%s[%d]", path, j),
+				Type:   DiffTypeAdded,
+				Value2: member,
+			}
+			diff.Type = DiffTypeModified
+		}
+	}
+
+	for i, member := range members1 {
+		if !containsValue(members2, member) {
+			addRemovedElement(diff, i, member)
+		}
+	}
+
+	return diff
+}
+
+// bindingsByRole indexes bindings by role, reporting false if any binding
+// lacks a role or a role appears more than once
+func bindingsByRole(bindings []interface{}) (map[string]map[string]interface{}, bool) {
+	byRole := make(map[string]map[string]interface{}, len(bindings))
+	for _, b := range bindings {
+		binding, ok := b.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		role, ok := binding["role"].(string)
+		if !ok {
+			return nil, false
+		}
+		if _, duplicate := byRole[role]; duplicate {
+			return nil, false
+		}
+		byRole[role] = binding
+	}
+	return byRole, true
+}
+
+func withoutKey(obj map[string]interface{}, key string) map[string]interface{} {
+	result := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		if k != key {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func toSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, candidate := range values {
+		if reflect.DeepEqual(candidate, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package compare
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+func iamResource(bindings ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"iamPolicy": map[string]interface{}{"bindings": bindings},
+	}
+}
+
+func binding(role string, members ...interface{}) map[string]interface{} {
+	return map[string]interface{}{"role": role, "members": members}
+}
+
+func TestCompare_IAMBindingsIgnoreOrdering(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+
+	obj1 := iamResource(
+		binding("roles/viewer", "user:a@example.com", "user:b@example.com"),
+		binding("roles/editor", "group:devs@example.com"),
+	)
+	obj2 := iamResource(
+		binding("roles/editor", "group:devs@example.com"),
+		binding("roles/viewer", "user:b@example.com", "user:a@example.com"),
+	)
+
+	diff := d.Compare(obj1, obj2)
+
+	if diff.Type != DiffTypeEqual {
+		t.Errorf("Expected reordered bindings and members to be equal, got %v", GetAllDiffs(diff))
+	}
+}
+
+func TestCompare_IAMBindingsAddedMember(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+
+	obj1 := iamResource(
+		binding("roles/viewer", "user:a@example.com"),
+		binding("roles/editor", "group:devs@example.com"),
+	)
+	obj2 := iamResource(
+		binding("roles/editor", "group:devs@example.com"),
+		binding("roles/viewer", "user:c@example.com", "user:a@example.com"),
+	)
+
+	diffs := GetAllDiffs(d.Compare(obj1, obj2))
+
+	if len(diffs) != 1 {
+		t.Fatalf("Expected exactly one difference, got %d: %v", len(diffs), diffs)
+	}
+	added := diffs[0]
+	if added.Type != DiffTypeAdded || added.Value2 != "user:c@example.com" {
+		t.Errorf("Expected added member user:c@example.com, got %+v", added)
+	}
+	if added.Path != "iamPolicy.bindings[role=roles/viewer].members[0]" {
+		t.Errorf("Expected member to be reported under roles/viewer, got path %s", added.Path)
+	}
+}
+
+func TestCompare_IAMBindingsReplacedMemberHasDistinctPaths(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+
+	diffs := GetAllDiffs(d.Compare(
+		iamResource(binding("roles/viewer", "user:a@example.com")),
+		iamResource(binding("roles/viewer", "user:b@example.com")),
+	))
+
+	if len(diffs) != 2 {
+		t.Fatalf("Expected one added and one removed member, got %v", diffs)
+	}
+	paths := map[DiffType]string{}
+	for _, diff := range diffs {
+		paths[diff.Type] = diff.Path
+	}
+	if paths[DiffTypeAdded] != "iamPolicy.bindings[role=roles/viewer].members[0]" {
+		t.Errorf("Expected added member at members[0], got %v", paths)
+	}
+	if paths[DiffTypeRemoved] != "iamPolicy.bindings[role=roles/viewer].members[removed:0]" {
+		t.Errorf("Expected removed member at members[removed:0], got %v", paths)
+	}
+}
+
+func TestCompare_IAMBindingsAddedAndRemovedRoles(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+
+	diff := d.Compare(
+		iamResource(binding("roles/viewer", "user:a@example.com")),
+		iamResource(binding("roles/owner", "user:a@example.com")),
+	)
+
+	bindings := diff.Children["iamPolicy"].Children["bindings"]
+	if got := bindings.Children["roles/owner"]; got == nil || got.Type != DiffTypeAdded {
+		t.Errorf("Expected roles/owner binding to be added, got %+v", got)
+	}
+	if got := bindings.Children["roles/viewer"]; got == nil || got.Type != DiffTypeRemoved {
+		t.Errorf("Expected roles/viewer binding to be removed, got %+v", got)
+	}
+}
+
+func TestCompare_IAMBindingsDuplicateRolesFallBackToIndex(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+
+	diff := d.Compare(
+		iamResource(binding("roles/viewer", "user:a@example.com"), binding("roles/viewer", "user:b@example.com")),
+		iamResource(binding("roles/viewer", "user:a@example.com"), binding("roles/viewer", "user:c@example.com")),
+	)
+
+	bindings := diff.Children["iamPolicy"].Children["bindings"]
+	if _, ok := bindings.Children["[1]"]; !ok {
+		t.Errorf("Expected index-based comparison for repeated roles, got %v", bindings.Children)
+	}
+}
+
+func TestPrintGitStyleDiffV2_IAMMemberUnderRole(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+	diff := d.Compare(
+		iamResource(binding("roles/viewer", "user:a@example.com")),
+		iamResource(binding("roles/viewer", "user:a@example.com", "user:b@example.com")),
+	)
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2(&buf, diff, "sub-1", "sub-2")
+	output := buf.String()
+
+	if !strings.Contains(output, "~ roles/viewer") {
+		t.Errorf("Expected binding to be labelled by role, got:\n%s", output)
+	}
+	if !strings.Contains(output, `+ [1] "user:b@example.com"`) {
+		t.Errorf("Expected added member in output, got:\n%s", output)
+	}
+}
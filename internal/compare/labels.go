@@ -0,0 +1,68 @@
+package compare
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LabelReport lists the required labels a resource lacks
+type LabelReport struct {
+	// Missing are required labels the resource doesn't have at all
+	Missing []string `json:"missing"`
+
+	// Empty are required labels set to an empty value
+	Empty []string `json:"empty"`
+}
+
+// Violations returns the number of missing and empty labels
+func (r LabelReport) Violations() int {
+	return len(r.Missing) + len(r.Empty)
+}
+
+// CheckLabels reports which of the required labels are missing or empty in
+// the labels map at labelsPath (e.g. "labels") of resource. A resource
+// without the map at all is missing every label.
+func CheckLabels(resource map[string]interface{}, labelsPath string, required []string) (LabelReport, error) {
+	report := LabelReport{Missing: []string{}, Empty: []string{}}
+
+	var labels map[string]interface{}
+	if value := fieldValue(resource, labelsPath); value != nil {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return report, fmt.Errorf("%s is not a map of labels (got %T)", labelsPath, value)
+		}
+		labels = m
+	}
+
+	for _, name := range required {
+		value, ok := labels[name]
+		switch {
+		case !ok:
+			report.Missing = append(report.Missing, name)
+		case value == nil || strings.TrimSpace(fmt.Sprint(value)) == "":
+			report.Empty = append(report.Empty, name)
+		}
+	}
+	return report, nil
+}
+
+// PrintLabelReport prints the violations found by CheckLabels
+func PrintLabelReport(w io.Writer, report LabelReport, name string, opts OutputOptions) {
+	opts = opts.withColors()
+	fmt.Fprintf(w, "%s\n", opts.colors.bold(fmt.Sprintf("Checking labels: %s", name)))
+	fmt.Fprintln(w, strings.Repeat("-", 80))
+
+	if report.Violations() == 0 {
+		fmt.Fprintf(w, "%s\n", opts.colors.green("✓ All required labels are set"))
+		return
+	}
+
+	fmt.Fprintf(w, "\n%s\n\n", opts.colors.bold(fmt.Sprintf("%d violation(s) found:", report.Violations())))
+	for _, label := range report.Missing {
+		fmt.Fprintf(w, "  %s %s %s\n", opts.colors.red("✗"), opts.colors.cyan(label), opts.colors.red("<missing>"))
+	}
+	for _, label := range report.Empty {
+		fmt.Fprintf(w, "  %s %s %s\n", opts.colors.red("✗"), opts.colors.cyan(label), opts.colors.red("<empty>"))
+	}
+}
@@ -0,0 +1,29 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckLabels(t *testing.T) {
+	resource := map[string]interface{}{
+		"labels": map[string]interface{}{"owner": "web", "team": " ", "env": nil},
+	}
+
+	report, err := CheckLabels(resource, "labels", []string{"owner", "team", "env", "cost-center"})
+	if err != nil {
+		t.Fatalf("CheckLabels failed: %v", err)
+	}
+	if !reflect.DeepEqual(report.Missing, []string{"cost-center"}) || !reflect.DeepEqual(report.Empty, []string{"team", "env"}) {
+		t.Errorf("Unexpected report %+v", report)
+	}
+
+	report, _ = CheckLabels(map[string]interface{}{}, "labels", []string{"owner"})
+	if report.Violations() != 1 || report.Missing[0] != "owner" {
+		t.Errorf("Expected every label missing without a labels map, got %+v", report)
+	}
+
+	if _, err := CheckLabels(map[string]interface{}{"labels": "owner=web"}, "labels", []string{"owner"}); err == nil {
+		t.Error("Expected an error when labels is not a map")
+	}
+}
@@ -0,0 +1,61 @@
+package compare
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// MetricLabels identify the comparison a metrics file describes
+type MetricLabels struct {
+	ResourceType string
+	Name1, Name2 string
+}
+
+// metricFamilies are the metrics written by WriteMetrics, in order, with
+// the change type each counts (empty counts every difference)
+var metricFamilies = []struct {
+	name     string
+	help     string
+	diffType DiffType
+}{
+	{"gcdiff_differences_total", "Number of differences between the two resources.", ""},
+	{"gcdiff_added_total", "Number of fields only the second resource has.", DiffTypeAdded},
+	{"gcdiff_removed_total", "Number of fields only the first resource has.", DiffTypeRemoved},
+	{"gcdiff_modified_total", "Number of fields whose value changed.", DiffTypeModified},
+}
+
+// WriteMetrics writes the leaf difference counts of diff in the Prometheus
+// text exposition format, e.g. for pushing to a Pushgateway
+func WriteMetrics(w io.Writer, diff *Diff, labels MetricLabels) {
+	counts := make(map[DiffType]int)
+	all := GetAllDiffs(diff)
+	for _, d := range all {
+		counts[d.Type]++
+	}
+
+	labelSet := fmt.Sprintf(`{resource_type="%s",resource1="%s",resource2="%s"}`,
+		escapeLabelValue(labels.ResourceType), escapeLabelValue(labels.Name1), escapeLabelValue(labels.Name2))
+	for _, family := range metricFamilies {
+		value := len(all)
+		if family.diffType != "" {
+			value = counts[family.diffType]
+		}
+		fmt.Fprintf(w, "# HELP %s %s\n", family.name, family.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", family.name)
+		fmt.Fprintf(w, "%s%s %d\n", family.name, labelSet, value)
+	}
+}
+
+// SaveMetrics writes the metrics of diff to a file, replacing it
+func SaveMetrics(path string, diff *Diff, labels MetricLabels) error {
+	var b strings.Builder
+	WriteMetrics(&b, diff, labels)
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// escapeLabelValue escapes a label value as the text format requires
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
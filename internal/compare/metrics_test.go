@@ -0,0 +1,86 @@
+package compare
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// parseMetrics reads the samples of a Prometheus text file into a map of
+// metric name with labels to value
+func parseMetrics(t *testing.T, text string) map[string]string {
+	t.Helper()
+	samples := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		idx := strings.LastIndex(line, " ")
+		if idx == -1 {
+			t.Fatalf("Malformed sample line %q", line)
+		}
+		samples[line[:idx]] = line[idx+1:]
+	}
+	return samples
+}
+
+func TestSaveMetrics(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"machineType": {Path: "machineType", Type: DiffTypeModified, Value1: "a", Value2: "b"},
+			"labels": {Path: "labels", Type: DiffTypeModified, Children: map[string]*Diff{
+				"env":  {Path: "labels.env", Type: DiffTypeAdded, Value2: "prod"},
+				"team": {Path: "labels.team", Type: DiffTypeAdded, Value2: "web"},
+				"old":  {Path: "labels.old", Type: DiffTypeRemoved, Value1: "x"},
+				"same": {Path: "labels.same", Type: DiffTypeEqual, Value1: "y"},
+			}},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "gcdiff.prom")
+	labels := MetricLabels{ResourceType: "compute instances", Name1: "vm-1", Name2: `vm-"2"`}
+	if err := SaveMetrics(path, diff, labels); err != nil {
+		t.Fatalf("SaveMetrics failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labelSet := `{resource_type="compute instances",resource1="vm-1",resource2="vm-\"2\""}`
+	want := map[string]string{
+		"gcdiff_differences_total" + labelSet: "4",
+		"gcdiff_added_total" + labelSet:       "2",
+		"gcdiff_removed_total" + labelSet:     "1",
+		"gcdiff_modified_total" + labelSet:    "1",
+	}
+	got := parseMetrics(t, string(data))
+	if len(got) != len(want) {
+		t.Errorf("Expected %d samples, got %v", len(want), got)
+	}
+	for metric, value := range want {
+		if got[metric] != value {
+			t.Errorf("Expected %s %s, got %q", metric, value, got[metric])
+		}
+	}
+
+	if !strings.Contains(string(data), "# TYPE gcdiff_added_total gauge\n") {
+		t.Errorf("Expected TYPE lines, got:\n%s", data)
+	}
+}
+
+func TestWriteMetrics_NoDifferences(t *testing.T) {
+	var b strings.Builder
+	WriteMetrics(&b, &Diff{Type: DiffTypeEqual}, MetricLabels{ResourceType: "storage buckets", Name1: "a", Name2: "b"})
+
+	for metric, value := range parseMetrics(t, b.String()) {
+		if value != "0" {
+			t.Errorf("Expected %s to be 0, got %s", metric, value)
+		}
+	}
+}
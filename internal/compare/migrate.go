@@ -0,0 +1,105 @@
+package compare
+
+import "github.com/tflynn3/gcdiff/internal/config"
+
+// migrateFields returns v with the FieldMigrations applied in order, so a
+// resource from an older API version uses the newer field paths. The input
+// is not modified. A migration whose From path is missing, or whose To path
+// is already set, leaves the resource alone.
+func (d *Differ) migrateFields(v interface{}) interface{} {
+	obj, ok := v.(map[string]interface{})
+	if !ok || len(d.config.FieldMigrations) == 0 {
+		return v
+	}
+	for _, migration := range d.config.FieldMigrations {
+		obj = migrateField(obj, migration)
+	}
+	return obj
+}
+
+func migrateField(obj map[string]interface{}, migration config.FieldMigration) map[string]interface{} {
+	from, to := fieldPathKeys(migration.From), fieldPathKeys(migration.To)
+	if from == nil || to == nil {
+		return obj
+	}
+	if _, exists := lookupKeys(obj, to); exists {
+		return obj
+	}
+	value, exists := lookupKeys(obj, from)
+	if !exists {
+		return obj
+	}
+	return setKeys(removeKeys(obj, from), to, value)
+}
+
+// fieldPathKeys splits a dotted path of object keys, which may be quoted,
+// into its keys. It returns nil for an empty path or one that indexes into
+// an array.
+func fieldPathKeys(path string) []string {
+	if path == "" {
+		return nil
+	}
+	tokens, err := config.SplitPath(path)
+	if err != nil {
+		return nil
+	}
+	keys := make([]string, len(tokens))
+	for i, token := range tokens {
+		if token.Index {
+			return nil
+		}
+		keys[i] = token.Text
+	}
+	return keys
+}
+
+func lookupKeys(obj map[string]interface{}, keys []string) (interface{}, bool) {
+	value, exists := obj[keys[0]]
+	if !exists || len(keys) == 1 {
+		return value, exists
+	}
+	child, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupKeys(child, keys[1:])
+}
+
+// removeKeys returns a copy of obj without the value at keys, copying only
+// the objects along the path. Objects left empty by the removal are removed
+// too, so a moved field leaves no {} behind.
+func removeKeys(obj map[string]interface{}, keys []string) map[string]interface{} {
+	result := copyObject(obj)
+	if len(keys) == 1 {
+		delete(result, keys[0])
+		return result
+	}
+	child := removeKeys(obj[keys[0]].(map[string]interface{}), keys[1:])
+	if len(child) == 0 {
+		delete(result, keys[0])
+	} else {
+		result[keys[0]] = child
+	}
+	return result
+}
+
+// setKeys returns a copy of obj with value at keys, creating missing objects
+// along the path. A non-object in the way is replaced.
+func setKeys(obj map[string]interface{}, keys []string, value interface{}) map[string]interface{} {
+	result := copyObject(obj)
+	if len(keys) == 1 {
+		result[keys[0]] = value
+		return result
+	}
+	child, _ := obj[keys[0]].(map[string]interface{})
+	result[keys[0]] = setKeys(child, keys[1:], value)
+	return result
+}
+
+func copyObject(obj map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(obj)+1)
+	for key, value := range obj {
+		result[key] = value
+	}
+	return result
+}
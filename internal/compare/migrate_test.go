@@ -0,0 +1,86 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+func TestCompare_FieldMigrations(t *testing.T) {
+	cfg := config.Default()
+	cfg.FieldMigrations = []config.FieldMigration{
+		{From: "scheduling.preemptible", To: "scheduling.provisioning.spot"},
+		{From: "legacyNetwork", To: "networking.network"},
+	}
+	d := NewDiffer(cfg, false)
+
+	v1 := map[string]interface{}{
+		"scheduling":    map[string]interface{}{"preemptible": true, "automaticRestart": false},
+		"legacyNetwork": "default",
+	}
+	v2 := map[string]interface{}{
+		"scheduling": map[string]interface{}{
+			"provisioning":     map[string]interface{}{"spot": true},
+			"automaticRestart": false,
+		},
+		"networking": map[string]interface{}{"network": "default"},
+	}
+
+	if diff := d.Compare(v1, v2); !diff.IsEmpty() {
+		t.Errorf("Expected the migrated resource to equal the newer one, got %+v", GetAllDiffs(diff))
+	}
+	if diff := d.Compare(v2, v1); !diff.IsEmpty() {
+		t.Errorf("Expected migration to apply to either side, got %+v", GetAllDiffs(diff))
+	}
+	if _, moved := v1["legacyNetwork"]; !moved {
+		t.Error("Expected the compared resource not to be modified")
+	}
+
+	v2["networking"] = map[string]interface{}{"network": "custom"}
+	diffs := GetAllDiffs(d.Compare(v1, v2))
+	if len(diffs) != 1 || diffs[0].Path != "networking.network" || diffs[0].Value1 != "default" {
+		t.Errorf("Expected a difference under the newer path, got %+v", diffs)
+	}
+}
+
+func TestMigrateField(t *testing.T) {
+	obj := map[string]interface{}{
+		"a": map[string]interface{}{"b": 1},
+		"c": 2,
+	}
+
+	tests := []struct {
+		name      string
+		migration config.FieldMigration
+		want      map[string]interface{}
+	}{
+		{
+			name:      "empty parent is removed",
+			migration: config.FieldMigration{From: "a.b", To: "x.y"},
+			want:      map[string]interface{}{"x": map[string]interface{}{"y": 1}, "c": 2},
+		},
+		{
+			name:      "existing target is kept",
+			migration: config.FieldMigration{From: "a.b", To: "c"},
+			want:      obj,
+		},
+		{
+			name:      "missing source",
+			migration: config.FieldMigration{From: "a.z", To: "x"},
+			want:      obj,
+		},
+		{
+			name:      "array paths are not supported",
+			migration: config.FieldMigration{From: "a.b", To: "x[0]"},
+			want:      obj,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := migrateField(obj, tt.migration); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package compare
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// lineOp is a single line of a line-level diff
+type lineOp struct {
+	Type DiffType
+	Text string
+}
+
+// isMultilineChange reports whether both values are strings and at least one spans multiple lines
+func isMultilineChange(val1, val2 interface{}) bool {
+	s1, ok1 := val1.(string)
+	s2, ok2 := val2.(string)
+	if !ok1 || !ok2 {
+		return false
+	}
+	return strings.Contains(s1, "\n") || strings.Contains(s2, "\n")
+}
+
+// diffLines computes a line-level diff of two strings using the longest
+// common subsequence. Lines shared at the start and end are matched up front,
+// and the rest is diffed in linear space, so whole documents such as the JSON
+// of a resource can be diffed too.
+func diffLines(s1, s2 string) []lineOp {
+	lines1 := strings.Split(s1, "\n")
+	lines2 := strings.Split(s2, "\n")
+
+	prefix := 0
+	for prefix < len(lines1) && prefix < len(lines2) && lines1[prefix] == lines2[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(lines1)-prefix && suffix < len(lines2)-prefix &&
+		lines1[len(lines1)-1-suffix] == lines2[len(lines2)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]lineOp, 0, len(lines1)+len(lines2))
+	for _, line := range lines1[:prefix] {
+		ops = append(ops, lineOp{Type: DiffTypeEqual, Text: line})
+	}
+	ops = appendLineDiff(ops, lines1[prefix:len(lines1)-suffix], lines2[prefix:len(lines2)-suffix])
+	for _, line := range lines1[len(lines1)-suffix:] {
+		ops = append(ops, lineOp{Type: DiffTypeEqual, Text: line})
+	}
+	return ops
+}
+
+// appendLineDiff appends the ops turning lines1 into lines2 to ops, splitting
+// lines1 in half and lines2 where their LCS crosses that point (Hirschberg's
+// algorithm). Removed lines come before added ones where the LCS allows both.
+func appendLineDiff(ops []lineOp, lines1, lines2 []string) []lineOp {
+	switch {
+	case len(lines1) == 0:
+		for _, line := range lines2 {
+			ops = append(ops, lineOp{Type: DiffTypeAdded, Text: line})
+		}
+		return ops
+	case len(lines2) == 0:
+		for _, line := range lines1 {
+			ops = append(ops, lineOp{Type: DiffTypeRemoved, Text: line})
+		}
+		return ops
+	case len(lines1) == 1:
+		for j, line := range lines2 {
+			if line == lines1[0] {
+				ops = appendLineDiff(ops, nil, lines2[:j])
+				ops = append(ops, lineOp{Type: DiffTypeEqual, Text: line})
+				return appendLineDiff(ops, nil, lines2[j+1:])
+			}
+		}
+		ops = append(ops, lineOp{Type: DiffTypeRemoved, Text: lines1[0]})
+		return appendLineDiff(ops, nil, lines2)
+	}
+
+	mid := len(lines1) / 2
+	forward := lcsLengths(lines1[:mid], lines2, false)
+	backward := lcsLengths(lines1[mid:], lines2, true)
+	split := 0
+	for j := range forward {
+		if forward[j]+backward[j] > forward[split]+backward[split] {
+			split = j
+		}
+	}
+	ops = appendLineDiff(ops, lines1[:mid], lines2[:split])
+	return appendLineDiff(ops, lines1[mid:], lines2[split:])
+}
+
+// lcsLengths returns, for each j, the LCS length of lines1 and lines2[:j],
+// or of lines1 and lines2[j:] when fromEnd is set, keeping one row at a time
+func lcsLengths(lines1, lines2 []string, fromEnd bool) []int {
+	at := func(lines []string, i int) string {
+		if fromEnd {
+			return lines[len(lines)-1-i]
+		}
+		return lines[i]
+	}
+
+	row := make([]int, len(lines2)+1)
+	for i := range lines1 {
+		diagonal := 0
+		for j := range lines2 {
+			above := row[j+1]
+			if at(lines1, i) == at(lines2, j) {
+				row[j+1] = diagonal + 1
+			} else if row[j] > above {
+				row[j+1] = row[j]
+			}
+			diagonal = above
+		}
+	}
+
+	if fromEnd {
+		slices.Reverse(row)
+	}
+	return row
+}
+
+// printMultilineDiff prints a per-line diff of two multi-line strings
+func printMultilineDiff(w io.Writer, colors *renderer, indent string, s1, s2 string) {
+	for _, op := range diffLines(s1, s2) {
+		switch op.Type {
+		case DiffTypeAdded:
+			fmt.Fprintf(w, "%s%s %s\n", indent, colors.green("+"), colors.green(op.Text))
+		case DiffTypeRemoved:
+			fmt.Fprintf(w, "%s%s %s\n", indent, colors.red("-"), colors.red(op.Text))
+		default:
+			fmt.Fprintf(w, "%s  %s\n", indent, op.Text)
+		}
+	}
+}
+
+// isComplexChange reports whether both values are objects or both are arrays
+func isComplexChange(val1, val2 interface{}) bool {
+	switch val1.(type) {
+	case map[string]interface{}:
+		_, ok := val2.(map[string]interface{})
+		return ok
+	case []interface{}:
+		_, ok := val2.([]interface{})
+		return ok
+	}
+	return false
+}
+
+// maxCompactValueLines bounds the JSON of each value printCompactValueDiff
+// diffs; longer values are printed in full instead
+const maxCompactValueLines = 1000
+
+// printCompactValueDiff prints a line diff of the JSON of two values showing
+// only changed lines and up to context unchanged lines around each change. It
+// prints nothing and returns false when either value's JSON is longer than
+// maxCompactValueLines, so the caller prints both values.
+func printCompactValueDiff(w io.Writer, colors *renderer, indent string, val1, val2 interface{}, context int) bool {
+	json1, _ := json.MarshalIndent(val1, "", "  ")
+	json2, _ := json.MarshalIndent(val2, "", "  ")
+	if bytes.Count(json1, []byte("\n")) >= maxCompactValueLines || bytes.Count(json2, []byte("\n")) >= maxCompactValueLines {
+		return false
+	}
+	ops := diffLines(string(json1), string(json2))
+
+	keep := make([]bool, len(ops))
+	for i, op := range ops {
+		if op.Type == DiffTypeEqual {
+			continue
+		}
+		for j := max(0, i-context); j <= min(len(ops)-1, i+context); j++ {
+			keep[j] = true
+		}
+	}
+
+	skipping := false
+	for i, op := range ops {
+		if !keep[i] {
+			if !skipping {
+				fmt.Fprintf(w, "%s  %s\n", indent, colors.gray("..."))
+				skipping = true
+			}
+			continue
+		}
+		skipping = false
+		switch op.Type {
+		case DiffTypeAdded:
+			fmt.Fprintf(w, "%s%s %s\n", indent, colors.green("+"), colors.green(op.Text))
+		case DiffTypeRemoved:
+			fmt.Fprintf(w, "%s%s %s\n", indent, colors.red("-"), colors.red(op.Text))
+		default:
+			fmt.Fprintf(w, "%s  %s\n", indent, op.Text)
+		}
+	}
+	return true
+}
@@ -0,0 +1,235 @@
+package compare
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines_MiddleLineChanged(t *testing.T) {
+	before := "#!/bin/bash\napt-get install nginx\nsystemctl start nginx"
+	after := "#!/bin/bash\napt-get install apache2\nsystemctl start nginx"
+
+	ops := diffLines(before, after)
+
+	var added, removed, equal []string
+	for _, op := range ops {
+		switch op.Type {
+		case DiffTypeAdded:
+			added = append(added, op.Text)
+		case DiffTypeRemoved:
+			removed = append(removed, op.Text)
+		case DiffTypeEqual:
+			equal = append(equal, op.Text)
+		}
+	}
+
+	if len(removed) != 1 || removed[0] != "apt-get install nginx" {
+		t.Errorf("Expected only the middle line removed, got %v", removed)
+	}
+	if len(added) != 1 || added[0] != "apt-get install apache2" {
+		t.Errorf("Expected only the middle line added, got %v", added)
+	}
+	if len(equal) != 2 {
+		t.Errorf("Expected 2 unchanged lines, got %v", equal)
+	}
+}
+
+func TestDiffLines_LargeDocument(t *testing.T) {
+	// Changes spread through a long document leave little common prefix or
+	// suffix to trim, so most of it goes through the LCS
+	var lines1, lines2 []string
+	for i := 0; i < 5000; i++ {
+		line := fmt.Sprintf("  \"field%d\": %d,", i, i)
+		lines1 = append(lines1, line)
+		if i%100 == 50 {
+			line = fmt.Sprintf("  \"field%d\": \"changed\",", i)
+		}
+		lines2 = append(lines2, line)
+	}
+
+	var got1, got2 []string
+	changed := 0
+	for _, op := range diffLines(strings.Join(lines1, "\n"), strings.Join(lines2, "\n")) {
+		if op.Type != DiffTypeAdded {
+			got1 = append(got1, op.Text)
+		}
+		if op.Type != DiffTypeRemoved {
+			got2 = append(got2, op.Text)
+		}
+		if op.Type != DiffTypeEqual {
+			changed++
+		}
+	}
+	if !slices.Equal(got1, lines1) || !slices.Equal(got2, lines2) {
+		t.Fatal("Expected the ops to rebuild both documents")
+	}
+	if changed != 100 {
+		t.Errorf("Expected 50 lines removed and 50 added, got %d changed lines", changed)
+	}
+}
+
+func TestPrintGitStyleDiffV2_MultilineString(t *testing.T) {
+	diff := &Diff{
+		Path: "",
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"startupScript": {
+				Path:   "startupScript",
+				Type:   DiffTypeModified,
+				Value1: "echo start\nrun --port=80",
+				Value2: "echo start\nrun --port=8080",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2(&buf, diff, "instance-1", "instance-2")
+	output := buf.String()
+
+	if !strings.Contains(output, "- run --port=80\n") {
+		t.Errorf("Expected removed line in output, got:\n%s", output)
+	}
+	if !strings.Contains(output, "+ run --port=8080\n") {
+		t.Errorf("Expected added line in output, got:\n%s", output)
+	}
+	if strings.Contains(output, "- echo start") || strings.Contains(output, "+ echo start") {
+		t.Errorf("Unchanged line should not be marked as changed, got:\n%s", output)
+	}
+	if strings.Contains(output, `"echo start\n`) {
+		t.Errorf("Multi-line values should not be dumped as quoted strings, got:\n%s", output)
+	}
+}
+
+func TestPrintGitStyleDiffV2_SingleLineString(t *testing.T) {
+	diff := &Diff{
+		Path: "",
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"machineType": {
+				Path:   "machineType",
+				Type:   DiffTypeModified,
+				Value1: "n1-standard-2",
+				Value2: "n1-standard-4",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2(&buf, diff, "instance-1", "instance-2")
+	output := buf.String()
+
+	if !strings.Contains(output, `- "n1-standard-2"`) || !strings.Contains(output, `+ "n1-standard-4"`) {
+		t.Errorf("Single-line strings should keep old->new rendering, got:\n%s", output)
+	}
+}
+
+func TestPrintGitStyleDiffV2_ContextLines(t *testing.T) {
+	before := map[string]interface{}{}
+	after := map[string]interface{}{}
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		before[key] = key + "-value"
+		after[key] = key + "-value"
+	}
+	after["e"] = "changed"
+
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"labels": {Path: "labels", Type: DiffTypeModified, Value1: before, Value2: after},
+		},
+	}
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2WithOptions(&buf, diff, "a", "b", OutputOptions{CompactValues: true, ContextLines: 1})
+	output := buf.String()
+
+	for _, want := range []string{`-   "e": "e-value",`, `+   "e": "changed",`, `"d": "d-value",`, `"f": "f-value",`, "..."} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+	for _, unwanted := range []string{`"a": "a-value"`, `"h": "h-value"`} {
+		if strings.Contains(output, unwanted) {
+			t.Errorf("Expected %q to be elided, got:\n%s", unwanted, output)
+		}
+	}
+}
+
+func TestPrintGitStyleDiffV2_CompactValuesNested(t *testing.T) {
+	before := map[string]interface{}{}
+	after := map[string]interface{}{}
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		before[key] = key + "-value"
+		after[key] = key + "-value"
+	}
+	after["e"] = "changed"
+
+	tests := map[string]*Diff{
+		// Inside an array element, printed by printNestedChange
+		"nested": {Type: DiffTypeModified, Children: map[string]*Diff{
+			"disks": {Path: "disks", Type: DiffTypeModified, Children: map[string]*Diff{
+				"[0]": {Path: "disks[0]", Type: DiffTypeModified, Children: map[string]*Diff{
+					"labels": {Path: "disks[0].labels", Type: DiffTypeModified, Value1: before, Value2: after},
+				}},
+			}},
+		}},
+		"element": {Type: DiffTypeModified, Children: map[string]*Diff{
+			"disks": {Path: "disks", Type: DiffTypeModified, Children: map[string]*Diff{
+				"[0]": {Path: "disks[0]", Type: DiffTypeModified, Value1: before, Value2: after},
+			}},
+		}},
+	}
+	for name, diff := range tests {
+		var buf bytes.Buffer
+		PrintGitStyleDiffV2WithOptions(&buf, diff, "a", "b", OutputOptions{CompactValues: true, ContextLines: 1})
+		output := buf.String()
+		if !strings.Contains(output, `+   "e": "changed",`) || strings.Contains(output, `"a": "a-value"`) {
+			t.Errorf("%s: expected a compact line diff, got:\n%s", name, output)
+		}
+	}
+}
+
+func TestPrintGitStyleDiffV2_CompactValuesTooLarge(t *testing.T) {
+	before := make([]interface{}, maxCompactValueLines)
+	for i := range before {
+		before[i] = i
+	}
+	after := append([]interface{}{}, before...)
+	after[0] = "changed"
+
+	diff := &Diff{
+		Type:     DiffTypeModified,
+		Children: map[string]*Diff{"items": {Path: "items", Type: DiffTypeModified, Value1: before, Value2: after}},
+	}
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2WithOptions(&buf, diff, "a", "b", OutputOptions{CompactValues: true, ContextLines: 1})
+	output := buf.String()
+	if strings.Contains(output, "...") || !strings.Contains(output, "999") {
+		t.Errorf("Expected both values printed in full, got:\n%.300s", output)
+	}
+}
+
+func TestPrintGitStyleDiffV2_FullValuesByDefault(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"labels": {
+				Path:   "labels",
+				Type:   DiffTypeModified,
+				Value1: map[string]interface{}{"a": "1", "b": "2"},
+				Value2: map[string]interface{}{"a": "1", "b": "3"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2(&buf, diff, "a", "b")
+
+	if !strings.Contains(buf.String(), `"a": "1"`) || strings.Contains(buf.String(), "...") {
+		t.Errorf("Expected both values in full, got:\n%s", buf.String())
+	}
+}
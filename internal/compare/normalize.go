@@ -0,0 +1,362 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+// mapToListField returns the inner sort field configured for a
+// normalize_map_to_list path, and whether the path is configured at all.
+// Entries take the form "path" or "path:field".
+func (d *Differ) mapToListField(path string) (string, bool) {
+	for _, entry := range d.config.NormalizeMapToList {
+		entryPath, field, _ := strings.Cut(entry, ":")
+		if config.MatchPath(entryPath, path) {
+			return field, true
+		}
+	}
+	return "", false
+}
+
+// mapValuesToList converts a map keyed by generated IDs into a list of its
+// values, ordered by the inner field when one is given and by their JSON
+// encoding otherwise, so the same entries line up regardless of their keys
+func mapValuesToList(m map[string]interface{}, field string) []interface{} {
+	type entry struct {
+		sortKey string
+		encoded string
+		value   interface{}
+	}
+
+	entries := make([]entry, 0, len(m))
+	for _, value := range m {
+		encoded, _ := json.Marshal(value)
+		e := entry{encoded: string(encoded), value: value}
+		if obj, ok := value.(map[string]interface{}); ok && field != "" {
+			if inner, exists := obj[field]; exists {
+				e.sortKey = fmt.Sprint(inner)
+			}
+		}
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].sortKey != entries[j].sortKey {
+			return entries[i].sortKey < entries[j].sortKey
+		}
+		return entries[i].encoded < entries[j].encoded
+	})
+
+	list := make([]interface{}, len(entries))
+	for i, e := range entries {
+		list[i] = e.value
+	}
+	return list
+}
+
+// canonicalJSON re-encodes a string holding valid JSON with compact
+// whitespace and sorted object keys
+func canonicalJSON(v interface{}) (string, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return "", false
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+		return "", false
+	}
+	encoded, err := json.Marshal(decoded)
+	if err != nil {
+		return "", false
+	}
+	return string(encoded), true
+}
+
+// isCaseInsensitivePath reports whether string values at path are compared
+// ignoring case
+func (d *Differ) isCaseInsensitivePath(path string) bool {
+	if !d.config.CaseInsensitiveValues {
+		return false
+	}
+	if len(d.config.CaseInsensitiveValuePaths) == 0 {
+		return true
+	}
+	for _, pattern := range d.config.CaseInsensitiveValuePaths {
+		if fieldMatches(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// isURLPath reports whether values at path are compared as canonical URLs
+func (d *Differ) isURLPath(path string) bool {
+	if !d.config.URLCanonicalize {
+		return false
+	}
+	if len(d.config.NormalizeURLPaths) == 0 {
+		return true
+	}
+	for _, pattern := range d.config.NormalizeURLPaths {
+		if fieldMatches(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalURL returns an absolute URL string with a lowercase scheme and
+// host, http treated as https, trailing slashes removed from the path and
+// query parameters sorted. It fails for anything that isn't an absolute URL.
+func canonicalURL(v interface{}) (string, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return "", false
+	}
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", false
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme == "http" {
+		u.Scheme = "https"
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+
+	query := u.Query()
+	for _, values := range query {
+		sort.Strings(values)
+	}
+	// Encode sorts the parameters by name
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+	return u.String(), true
+}
+
+// isSemverPath reports whether path matches one of the SemverPaths globs
+func (d *Differ) isSemverPath(path string) bool {
+	for _, pattern := range d.config.SemverPaths {
+		if fieldMatches(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+var semverPattern = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// canonicalSemver returns a version string as major.minor.patch with any
+// pre-release suffix, filling omitted components with 0 and dropping build
+// metadata, which semver ignores for equality. It fails for anything that
+// isn't a version string.
+func canonicalSemver(v interface{}) (string, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return "", false
+	}
+	m := semverPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return "", false
+	}
+
+	parts := make([]string, 3)
+	for i := range parts {
+		n, _ := strconv.Atoi(m[i+1])
+		parts[i] = strconv.Itoa(n)
+	}
+	version := strings.Join(parts, ".")
+	if m[4] != "" {
+		version += "-" + m[4]
+	}
+	return version, true
+}
+
+// isQuantityPath reports whether path matches one of the QuantityPaths globs
+func (d *Differ) isQuantityPath(path string) bool {
+	for _, pattern := range d.config.QuantityPaths {
+		if fieldMatches(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// enumMap returns the enum value mapping EnumMaps configures for path
+func (d *Differ) enumMap(path string) (map[string]string, bool) {
+	return firstMatch(d.config.EnumMaps, path, fieldMatches)
+}
+
+// allowedDelta returns the AllowedDelta configured for path
+func (d *Differ) allowedDelta(path string) (float64, bool) {
+	return firstMatch(d.config.AllowedDelta, path, fieldMatches)
+}
+
+// canonicalEnum returns the canonical name of an enum value: its mapped name
+// if values maps it, or the value itself if it is already a string. Other
+// unmapped values have no canonical name.
+func canonicalEnum(v interface{}, values map[string]string) (string, bool) {
+	if !isLeafValue(v) {
+		return "", false
+	}
+	if name, ok := values[fmt.Sprint(v)]; ok {
+		return name, true
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+var quantityPattern = regexp.MustCompile(`^([+-]?(?:\d+\.?\d*|\.\d+)(?:[eE][+-]?\d+)?)([KMGTPE]i|[numkMGTPE])?$`)
+
+// quantitySuffixes are the multipliers of Kubernetes-style quantity
+// suffixes: binary (Ki, Mi, ...) and decimal (m for milli, k, M, ...)
+var quantitySuffixes = map[string]string{
+	"":   "1",
+	"n":  "1/1000000000",
+	"u":  "1/1000000",
+	"m":  "1/1000",
+	"k":  "1000",
+	"M":  "1000000",
+	"G":  "1000000000",
+	"T":  "1000000000000",
+	"P":  "1000000000000000",
+	"E":  "1000000000000000000",
+	"Ki": "1024",
+	"Mi": "1048576",
+	"Gi": "1073741824",
+	"Ti": "1099511627776",
+	"Pi": "1125899906842624",
+	"Ei": "1152921504606846976",
+}
+
+// parseQuantity returns the exact amount of a quantity string like 1Gi or
+// 250m, or of a plain number. It fails for anything else.
+func parseQuantity(v interface{}) (*big.Rat, bool) {
+	if n, ok := toFloat64(v); ok {
+		q := new(big.Rat)
+		if q.SetFloat64(n) == nil {
+			return nil, false
+		}
+		return q, true
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return nil, false
+	}
+	m := quantityPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return nil, false
+	}
+	q, ok := new(big.Rat).SetString(m[1])
+	if !ok {
+		return nil, false
+	}
+	multiplier, _ := new(big.Rat).SetString(quantitySuffixes[m[2]])
+	return q.Mul(q, multiplier), true
+}
+
+// arrayKeyFields returns the key fields array_keys configures for the array
+// at path, whose patterns match its whole path
+func (d *Differ) arrayKeyFields(path string) []string {
+	fields, _ := firstMatch(d.config.ArrayKeys, path, config.MatchPath)
+	return fields
+}
+
+// schemaType returns the type the schema declares for path, matched like
+// arrayKeyFields
+func (d *Differ) schemaType(path string) (string, bool) {
+	return firstMatch(d.config.Schema, path, config.MatchPath)
+}
+
+// entryNameField returns the name field entry_array_paths configures for the
+// array at path, matched like arrayKeyFields
+func (d *Differ) entryNameField(path string) (string, bool) {
+	return firstMatch(d.config.EntryArrayPaths, path, config.MatchPath)
+}
+
+// entryMap returns v as a map of entries keyed by name. A map is returned as
+// is; an array of objects is keyed by their nameField, which is dropped from
+// each entry. It fails if an element isn't an object with a string name or a
+// name repeats.
+func entryMap(v interface{}, nameField string) (map[string]interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return v, true
+	case []interface{}:
+		m := make(map[string]interface{}, len(v))
+		for _, element := range v {
+			obj, ok := element.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			name, ok := obj[nameField].(string)
+			if !ok {
+				return nil, false
+			}
+			if _, exists := m[name]; exists {
+				return nil, false
+			}
+			entry := make(map[string]interface{}, len(obj)-1)
+			for key, value := range obj {
+				if key != nameField {
+					entry[key] = value
+				}
+			}
+			m[name] = entry
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+// defaultKeyValueArrays are compared as key-value maps without configuration
+var defaultKeyValueArrays = []string{"metadata.items"}
+
+// keyValueFields returns the key and value field names of a key-value array
+// path, and whether the path is one at all
+func (d *Differ) keyValueFields(path string) (string, string, bool) {
+	for _, entry := range append(defaultKeyValueArrays, d.config.KeyValueArrays...) {
+		parts := strings.Split(entry, ":")
+		if !config.MatchPath(parts[0], path) {
+			continue
+		}
+		if len(parts) == 3 {
+			return parts[1], parts[2], true
+		}
+		return "key", "value", true
+	}
+	return "", "", false
+}
+
+// keyValueMap converts an array of {key, value} objects into a map from key
+// to value. It fails if an element isn't an object with a string key or a key
+// repeats, since the array then can't be compared by key.
+func keyValueMap(arr []interface{}, keyField, valueField string) (map[string]interface{}, bool) {
+	m := make(map[string]interface{}, len(arr))
+	for _, element := range arr {
+		obj, ok := element.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		key, ok := obj[keyField].(string)
+		if !ok {
+			return nil, false
+		}
+		if _, exists := m[key]; exists {
+			return nil, false
+		}
+		m[key] = obj[valueField]
+	}
+	return m, true
+}
@@ -0,0 +1,59 @@
+package compare
+
+import "github.com/tflynn3/gcdiff/internal/config"
+
+// DifferOption configures a Differ created by NewDifferWithOptions
+type DifferOption func(*Differ)
+
+// NewDifferWithOptions creates a Differ using the default config, then
+// applies opts in order
+func NewDifferWithOptions(opts ...DifferOption) *Differ {
+	d := &Differ{config: config.Default()}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// WithConfig compares using cfg; a nil cfg keeps the default config. Options
+// that change config settings apply to a copy, so pass WithConfig first.
+func WithConfig(cfg *config.Config) DifferOption {
+	return func(d *Differ) {
+		if cfg != nil {
+			d.config = cfg
+		}
+	}
+}
+
+// WithShowAll compares fields the config ignores
+func WithShowAll(showAll bool) DifferOption {
+	return func(d *Differ) {
+		d.showAll = showAll
+	}
+}
+
+// WithNumericTolerance treats numbers as equal when they differ by at most
+// tolerance, e.g. 0.01 for rounded utilization values
+func WithNumericTolerance(tolerance float64) DifferOption {
+	return func(d *Differ) {
+		d.tolerance = tolerance
+	}
+}
+
+// WithArrayKeys matches array elements by key fields, like the array_keys
+// config setting, replacing any keys the config already has
+func WithArrayKeys(keys map[string][]string) DifferOption {
+	return func(d *Differ) {
+		cfg := *d.config
+		cfg.ArrayKeys = keys
+		d.config = &cfg
+	}
+}
+
+// WithParallel compares top-level fields on up to workers goroutines, like
+// SetParallel
+func WithParallel(workers int) DifferOption {
+	return func(d *Differ) {
+		d.parallel = workers
+	}
+}
@@ -0,0 +1,58 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+func TestNewDifferWithOptions_Defaults(t *testing.T) {
+	d := NewDifferWithOptions()
+	if d.config == nil || d.showAll || d.tolerance != 0 {
+		t.Errorf("Expected the default config without show-all or tolerance, got %+v", d)
+	}
+
+	// ignore_fields from the default config still apply
+	diff := d.Compare(map[string]interface{}{"etag": "a"}, map[string]interface{}{"etag": "b"})
+	if !diff.IsEmpty() {
+		t.Errorf("Expected etag to be ignored, got %v", GetAllDiffs(diff))
+	}
+	if diff := NewDifferWithOptions(WithShowAll(true)).Compare(map[string]interface{}{"etag": "a"}, map[string]interface{}{"etag": "b"}); diff.IsEmpty() {
+		t.Error("Expected WithShowAll to compare ignored fields")
+	}
+}
+
+func TestNewDifferWithOptions_NumericTolerance(t *testing.T) {
+	obj1 := map[string]interface{}{"cpu": 0.501, "memory": float64(100)}
+	obj2 := map[string]interface{}{"cpu": 0.509, "memory": float64(101)}
+
+	diffs := GetAllDiffs(NewDifferWithOptions(WithNumericTolerance(0.01)).Compare(obj1, obj2))
+	if len(diffs) != 1 || diffs[0].Path != "memory" {
+		t.Errorf("Expected only memory to differ beyond the tolerance, got %v", diffs)
+	}
+	if diffs := GetAllDiffs(NewDifferWithOptions().Compare(obj1, obj2)); len(diffs) != 2 {
+		t.Errorf("Expected both fields to differ without a tolerance, got %v", diffs)
+	}
+}
+
+func TestNewDifferWithOptions_ArrayKeys(t *testing.T) {
+	cfg := config.Default()
+	d := NewDifferWithOptions(WithConfig(cfg), WithArrayKeys(map[string][]string{"disks": {"name"}}))
+
+	obj1 := map[string]interface{}{"disks": []interface{}{
+		map[string]interface{}{"name": "boot", "size": float64(10)},
+		map[string]interface{}{"name": "data", "size": float64(100)},
+	}}
+	obj2 := map[string]interface{}{"disks": []interface{}{
+		map[string]interface{}{"name": "data", "size": float64(200)},
+		map[string]interface{}{"name": "boot", "size": float64(10)},
+	}}
+
+	diffs := GetAllDiffs(d.Compare(obj1, obj2))
+	if len(diffs) != 1 || diffs[0].Type != DiffTypeModified || diffs[0].Value2 != float64(200) {
+		t.Errorf("Expected only the data disk's size to differ, got %v", diffs)
+	}
+	if cfg.ArrayKeys != nil {
+		t.Error("Expected WithArrayKeys not to modify the caller's config")
+	}
+}
@@ -1,36 +1,140 @@
 package compare
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
-	"github.com/fatih/color"
+	"github.com/tflynn3/gcdiff/internal/config"
 )
 
-var (
-	green  = color.New(color.FgGreen).SprintFunc()
-	red    = color.New(color.FgRed).SprintFunc()
-	yellow = color.New(color.FgYellow).SprintFunc()
-	cyan   = color.New(color.FgCyan).SprintFunc()
-	bold   = color.New(color.Bold).SprintFunc()
-)
+// OutputOptions controls how values are rendered by the text output formats
+type OutputOptions struct {
+	// DecodeBase64 shows the decoded text of string values that look like base64
+	DecodeBase64 bool
+
+	// UnitFields maps a glob, matched against a field's name or full path, to
+	// a unit suffix appended to that field's numeric values (e.g. "GB")
+	UnitFields map[string]string
+
+	// DeprecatedFields are globs, matched like UnitFields, of fields whose
+	// changes are annotated "(deprecated)"
+	DeprecatedFields []string
+
+	// Width wraps long values so lines fit in this many columns; zero
+	// disables wrapping
+	Width int
+
+	// CompactValues renders a modified object or array value as a line diff
+	// of its JSON, showing only the changed lines and ContextLines unchanged
+	// lines around each, instead of both values in full
+	CompactValues bool
+	ContextLines  int
+
+	// CompactArrays marks the runs of unchanged elements between the changed
+	// elements of an array, e.g. "[3..7] 5 unchanged elements"
+	CompactArrays bool
+
+	// Reference is a third resource whose value of each changed field, when
+	// it has one, is shown below the old and new values
+	Reference map[string]interface{}
+
+	// Limit, when positive, renders at most this many leaf changes, in
+	// GetAllDiffs order, followed by a count of the rest
+	Limit int
+
+	// ShowTypes, when non-empty, limits the rendered changes to these kinds.
+	// Summaries still count every change.
+	ShowTypes []DiffType
+
+	// RiskWeights, when set, maps field globs to the weight of changing
+	// them; the summary then shows the total risk score of the diff, with
+	// unmatched changes weighing 1
+	RiskWeights map[string]int
+
+	// Color selects when output is colorized; empty means ColorAuto
+	Color ColorMode
+
+	// colors renders output in the Color mode; set by withColors
+	colors *renderer
+}
+
+// withColors returns o with the renderer for its Color mode attached
+func (o OutputOptions) withColors() OutputOptions {
+	if o.colors == nil {
+		o.colors = newRenderer(o.Color)
+	}
+	return o
+}
+
+// fieldMatches reports whether a glob matches a field's name (the last path
+// segment without indices), its full path, or its path with array indices
+// removed (so "networkInterfaces.network" matches networkInterfaces[0].network)
+func fieldMatches(pattern, fieldPath string) bool {
+	return config.MatchField(pattern, fieldPath)
+}
+
+// annotate returns the note shown after a changed field's name, if any
+func (o OutputOptions) annotate(fieldPath string) string {
+	for _, pattern := range o.DeprecatedFields {
+		if fieldMatches(pattern, fieldPath) {
+			return " " + o.colors.gray("(deprecated)")
+		}
+	}
+	return ""
+}
+
+// unitValue is a numeric value rendered with a unit suffix
+type unitValue struct {
+	value interface{}
+	unit  string
+}
+
+func (u unitValue) String() string {
+	return fmt.Sprintf("%v %s", u.value, u.unit)
+}
+
+// display returns the value to render for the field at fieldPath, attaching a
+// unit to numeric values of fields listed in UnitFields
+func (o OutputOptions) display(fieldPath string, value interface{}) interface{} {
+	if len(o.UnitFields) == 0 {
+		return value
+	}
+	if _, ok := toFloat64(value); !ok {
+		return value
+	}
+
+	if unit, ok := firstMatch(o.UnitFields, fieldPath, fieldMatches); ok {
+		return unitValue{value: value, unit: unit}
+	}
+	return value
+}
 
 // PrintGitStyleDiff prints a git-style diff to the writer
 func PrintGitStyleDiff(w io.Writer, diff *Diff, name1, name2 string) {
-	fmt.Fprintf(w, "%s\n", bold(fmt.Sprintf("Comparing: %s <-> %s", name1, name2)))
+	PrintGitStyleDiffWithOptions(w, diff, name1, name2, OutputOptions{})
+}
+
+// PrintGitStyleDiffWithOptions prints a diff like PrintGitStyleDiff,
+// rendering values according to opts
+func PrintGitStyleDiffWithOptions(w io.Writer, diff *Diff, name1, name2 string, opts OutputOptions) {
+	opts = opts.withColors()
+	fmt.Fprintf(w, "%s\n", opts.colors.bold(fmt.Sprintf("Comparing: %s <-> %s", name1, name2)))
 	fmt.Fprintln(w, strings.Repeat("-", 80))
 
 	if diff.Type == DiffTypeEqual {
-		fmt.Fprintf(w, "%s\n", green("✓ No differences found"))
+		fmt.Fprintf(w, "%s\n", opts.colors.green("✓ No differences found"))
 		return
 	}
 
 	diffs := GetAllDiffs(diff)
 	if len(diffs) == 0 {
-		fmt.Fprintf(w, "%s\n", green("✓ No differences found"))
+		fmt.Fprintf(w, "%s\n", opts.colors.green("✓ No differences found"))
 		return
 	}
 
@@ -52,57 +156,107 @@ func PrintGitStyleDiff(w io.Writer, diff *Diff, name1, name2 string) {
 
 	// Print summary
 	total := len(added) + len(removed) + len(modified)
-	fmt.Fprintf(w, "\n%s\n", bold(fmt.Sprintf("Summary: %d difference(s) found", total)))
+	fmt.Fprintf(w, "\n%s\n", opts.colors.bold(fmt.Sprintf("Summary: %d difference(s) found", total)))
 	if len(added) > 0 {
-		fmt.Fprintf(w, "  %s %d field(s)\n", green("+"), len(added))
+		fmt.Fprintf(w, "  %s %d field(s)\n", opts.colors.green("+"), len(added))
 	}
 	if len(removed) > 0 {
-		fmt.Fprintf(w, "  %s %d field(s)\n", red("-"), len(removed))
+		fmt.Fprintf(w, "  %s %d field(s)\n", opts.colors.red("-"), len(removed))
 	}
 	if len(modified) > 0 {
-		fmt.Fprintf(w, "  %s %d field(s)\n", yellow("~"), len(modified))
+		fmt.Fprintf(w, "  %s %d field(s)\n", opts.colors.yellow("~"), len(modified))
 	}
+	printRiskScore(w, opts, diff)
 	fmt.Fprintln(w)
 
 	// Print differences
-	printDiffSection(w, "Added Fields", added, DiffTypeAdded)
-	printDiffSection(w, "Removed Fields", removed, DiffTypeRemoved)
-	printDiffSection(w, "Modified Fields", modified, DiffTypeModified)
+	rendered, hidden := opts.rendered(diffs)
+	addedTitle, removedTitle, modifiedTitle := sideTitles(name1, name2)
+	printDiffSection(w, opts, addedTitle, added, DiffTypeAdded, rendered)
+	printDiffSection(w, opts, removedTitle, removed, DiffTypeRemoved, rendered)
+	printDiffSection(w, opts, modifiedTitle, modified, DiffTypeModified, rendered)
+	printMoreChanges(w, opts, hidden)
 }
 
-func printDiffSection(w io.Writer, title string, diffs []*Diff, diffType DiffType) {
+// sideTitles returns the section titles of added, removed and modified
+// fields, naming the resource that has them. Resources that share a name fall
+// back to "Added Fields", "Removed Fields" and "Modified Fields", relative to
+// the first resource.
+func sideTitles(name1, name2 string) (added, removed, modified string) {
+	if name1 == "" || name2 == "" || name1 == name2 {
+		return "Added Fields", "Removed Fields", "Modified Fields"
+	}
+	return "Only in " + name2, "Only in " + name1, "Different"
+}
+
+// printSideLegend prints what the +, - and ~ markers mean for renderers that
+// mark changes inline instead of titling sections with sideTitles. Resources
+// that share a name get no legend.
+func printSideLegend(w io.Writer, opts OutputOptions, name1, name2 string) {
+	if name1 == "" || name2 == "" || name1 == name2 {
+		return
+	}
+	added, removed, modified := sideTitles(name1, name2)
+	fmt.Fprintf(w, "%s %s   %s %s   %s %s\n", opts.colors.green("+"), added, opts.colors.red("-"), removed, opts.colors.yellow("~"), modified)
+}
+
+func printDiffSection(w io.Writer, opts OutputOptions, title string, diffs []*Diff, diffType DiffType, rendered map[*Diff]bool) {
+	var shown []*Diff
+	for _, d := range diffs {
+		if rendered[d] {
+			shown = append(shown, d)
+		}
+	}
+	diffs = shown
 	if len(diffs) == 0 {
 		return
 	}
 
 	// Sort by path for consistent output
 	sort.Slice(diffs, func(i, j int) bool {
-		return diffs[i].Path < diffs[j].Path
+		return lessPath(diffs[i].Path, diffs[j].Path)
 	})
 
-	fmt.Fprintf(w, "%s\n", bold(title+":"))
+	fmt.Fprintf(w, "%s\n", opts.colors.bold(title+":"))
 	fmt.Fprintln(w)
 
 	for _, d := range diffs {
-		switch diffType {
-		case DiffTypeAdded:
-			fmt.Fprintf(w, "  %s %s\n", green("+"), cyan(d.Path))
-			printValue(w, "      ", d.Value2, green)
-		case DiffTypeRemoved:
-			fmt.Fprintf(w, "  %s %s\n", red("-"), cyan(d.Path))
-			printValue(w, "      ", d.Value1, red)
-		case DiffTypeModified:
-			fmt.Fprintf(w, "  %s %s\n", yellow("~"), cyan(d.Path))
-			fmt.Fprintf(w, "      %s ", red("-"))
-			printValue(w, "        ", d.Value1, red)
-			fmt.Fprintf(w, "      %s ", green("+"))
-			printValue(w, "        ", d.Value2, green)
-		}
+		printDiffEntry(w, opts, d, diffType)
 		fmt.Fprintln(w)
 	}
 }
 
-func printValue(w io.Writer, indent string, value interface{}, colorFunc func(...interface{}) string) {
+// printDiffEntry prints a single leaf difference with its full path
+func printDiffEntry(w io.Writer, opts OutputOptions, d *Diff, diffType DiffType) {
+	switch diffType {
+	case DiffTypeAdded:
+		fmt.Fprintf(w, "  %s %s%s\n", opts.colors.green("+"), opts.colors.cyan(d.Path), opts.annotate(d.Path))
+		printValue(w, opts, "      ", opts.display(d.Path, d.Value2), opts.colors.green)
+		printReferenceValue(w, opts, "      ", d.Path)
+	case DiffTypeRemoved:
+		fmt.Fprintf(w, "  %s %s%s\n", opts.colors.red("-"), opts.colors.cyan(d.Path), opts.annotate(d.Path))
+		printValue(w, opts, "      ", opts.display(d.Path, d.Value1), opts.colors.red)
+		printReferenceValue(w, opts, "      ", d.Path)
+	case DiffTypeModified:
+		fmt.Fprintf(w, "  %s %s%s\n", opts.colors.yellow("~"), opts.colors.cyan(d.Path), opts.annotate(d.Path))
+		if opts.CompactValues && isComplexChange(d.Value1, d.Value2) &&
+			printCompactValueDiff(w, opts.colors, "      ", d.Value1, d.Value2, opts.ContextLines) {
+			printReferenceValue(w, opts, "      ", d.Path)
+			return
+		}
+		value1, value2 := opts.highlight(opts.display(d.Path, d.Value1), opts.display(d.Path, d.Value2))
+		fmt.Fprintf(w, "      %s ", opts.colors.red("-"))
+		printValue(w, opts, "        ", value1, opts.colors.red)
+		fmt.Fprintf(w, "      %s ", opts.colors.green("+"))
+		printValue(w, opts, "        ", value2, opts.colors.green)
+		printReferenceValue(w, opts, "      ", d.Path)
+		if d.Note != "" {
+			fmt.Fprintf(w, "      %s\n", opts.colors.gray("("+d.Note+")"))
+		}
+	}
+}
+
+func printValue(w io.Writer, opts OutputOptions, indent string, value interface{}, colorFunc func(...interface{}) string) {
 	if value == nil {
 		fmt.Fprintf(w, "%s\n", colorFunc("<nil>"))
 		return
@@ -118,15 +272,58 @@ func printValue(w io.Writer, indent string, value interface{}, colorFunc func(..
 			lines := strings.Split(string(jsonBytes), "\n")
 			for i, line := range lines {
 				if i == 0 {
-					fmt.Fprintf(w, "%s\n", colorFunc(line))
+					writeWrapped(w, opts, len(indent), indent+"  ", line, colorFunc)
 				} else {
-					fmt.Fprintf(w, "%s%s\n", indent, colorFunc(line))
+					// Continuations line up under the element they belong to
+					nested := line[:len(line)-len(strings.TrimLeft(line, " "))]
+					fmt.Fprint(w, indent)
+					writeWrapped(w, opts, len(indent), indent+nested+"  ", line, colorFunc)
 				}
 			}
 		}
 	case string:
-		fmt.Fprintf(w, "%s\n", colorFunc(fmt.Sprintf("%q", v)))
+		writeWrapped(w, opts, len(indent), indent+"  ", formatString(v, opts), colorFunc)
+	case styledText:
+		writeWrapped(w, opts, len(indent), indent+"  ", string(v), fmt.Sprint)
 	default:
-		fmt.Fprintf(w, "%v\n", colorFunc(fmt.Sprintf("%v", value)))
+		writeWrapped(w, opts, len(indent), indent+"  ", fmt.Sprintf("%v", value), colorFunc)
+	}
+}
+
+// formatString quotes a string value for display, decoding it first when
+// DecodeBase64 is set and the value looks like base64-encoded text
+func formatString(s string, opts OutputOptions) string {
+	if opts.DecodeBase64 {
+		if decoded, ok := decodeBase64Text(s); ok {
+			return fmt.Sprintf("<base64> decoded: %q", decoded)
+		}
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+// minBase64Length is the shortest string considered for base64 decoding;
+// shorter values are too likely to be ordinary identifiers
+const minBase64Length = 16
+
+// decodeBase64Text decodes s if it is padded standard base64 of printable
+// UTF-8 text. Values that decode to binary are left alone, which keeps
+// ordinary alphanumeric strings from being misclassified.
+func decodeBase64Text(s string) (string, bool) {
+	if len(s) < minBase64Length || len(s)%4 != 0 {
+		return "", false
+	}
+
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(data) == 0 || !utf8.Valid(data) {
+		return "", false
 	}
+
+	text := string(data)
+	for _, r := range text {
+		if !unicode.IsPrint(r) && r != '\n' && r != '\r' && r != '\t' {
+			return "", false
+		}
+	}
+
+	return text, true
 }
@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+
+	"github.com/fatih/color"
+	"github.com/tflynn3/gcdiff/internal/config"
 )
 
 func TestPrintGitStyleDiff_NoDifferences(t *testing.T) {
@@ -57,7 +60,7 @@ func TestPrintGitStyleDiff_WithDifferences(t *testing.T) {
 		t.Error("Output should show differences count")
 	}
 
-	if !strings.Contains(output, "Modified Fields") {
+	if !strings.Contains(output, "Different:") {
 		t.Error("Output should have modified fields section")
 	}
 
@@ -84,7 +87,7 @@ func TestPrintGitStyleDiff_AddedFields(t *testing.T) {
 
 	output := buf.String()
 
-	if !strings.Contains(output, "Added Fields") {
+	if !strings.Contains(output, "Only in instance-2:") {
 		t.Error("Output should have added fields section")
 	}
 
@@ -116,7 +119,7 @@ func TestPrintGitStyleDiff_RemovedFields(t *testing.T) {
 
 	output := buf.String()
 
-	if !strings.Contains(output, "Removed Fields") {
+	if !strings.Contains(output, "Only in instance-1:") {
 		t.Error("Output should have removed fields section")
 	}
 
@@ -149,7 +152,7 @@ func TestPrintGitStyleDiff_ModifiedFields(t *testing.T) {
 
 	output := buf.String()
 
-	if !strings.Contains(output, "Modified Fields") {
+	if !strings.Contains(output, "Different:") {
 		t.Error("Output should have modified fields section")
 	}
 
@@ -201,16 +204,16 @@ func TestPrintGitStyleDiff_MixedDifferences(t *testing.T) {
 	output := buf.String()
 
 	// Check all sections are present
-	if !strings.Contains(output, "Added Fields") {
-		t.Error("Should have Added Fields section")
+	if !strings.Contains(output, "Only in instance-2:") {
+		t.Error("Should have an Only in instance-2 section")
 	}
 
-	if !strings.Contains(output, "Removed Fields") {
-		t.Error("Should have Removed Fields section")
+	if !strings.Contains(output, "Only in instance-1:") {
+		t.Error("Should have an Only in instance-1 section")
 	}
 
-	if !strings.Contains(output, "Modified Fields") {
-		t.Error("Should have Modified Fields section")
+	if !strings.Contains(output, "Different:") {
+		t.Error("Should have a Different section")
 	}
 
 	// Check summary counts
@@ -219,6 +222,66 @@ func TestPrintGitStyleDiff_MixedDifferences(t *testing.T) {
 	}
 }
 
+func TestPrintGitStyleDiff_SectionsNamedAfterResources(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"canary":      {Path: "canary", Type: DiffTypeAdded, Value2: true},
+			"backup":      {Path: "backup", Type: DiffTypeRemoved, Value1: "daily"},
+			"machineType": {Path: "machineType", Type: DiffTypeModified, Value1: "n1", Value2: "n2"},
+		},
+	}
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffWithOptions(&buf, diff, "prod-web-01", "staging-web-01", OutputOptions{Color: ColorNever})
+	output := buf.String()
+	for _, want := range []string{
+		"Only in staging-web-01:\n\n  + canary",
+		"Only in prod-web-01:\n\n  - backup",
+		"Different:\n\n  ~ machineType",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	// Sections can't be told apart by a shared name
+	buf.Reset()
+	PrintGitStyleDiffWithOptions(&buf, diff, "web", "web", OutputOptions{Color: ColorNever})
+	for _, want := range []string{"Added Fields:", "Removed Fields:", "Modified Fields:"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q for resources with the same name, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestPrintGitStyleDiffV2_SideLegend(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"canary": {Path: "canary", Type: DiffTypeAdded, Value2: true},
+		},
+	}
+	legend := "+ Only in staging-web-01   - Only in prod-web-01   ~ Different\n"
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2WithOptions(&buf, diff, "prod-web-01", "staging-web-01", OutputOptions{Color: ColorNever})
+	if !strings.Contains(buf.String(), legend) {
+		t.Errorf("Expected the marker legend, got:\n%s", buf.String())
+	}
+	buf.Reset()
+	PrintSectionedDiff(&buf, diff, "prod-web-01", "staging-web-01", nil, OutputOptions{Color: ColorNever})
+	if !strings.Contains(buf.String(), legend) {
+		t.Errorf("Expected the marker legend in sectioned output, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	PrintGitStyleDiffV2WithOptions(&buf, diff, "web", "web", OutputOptions{Color: ColorNever})
+	if strings.Contains(buf.String(), "Only in") {
+		t.Errorf("Expected no legend for resources with the same name, got:\n%s", buf.String())
+	}
+}
+
 func TestPrintGitStyleDiff_ComplexValues(t *testing.T) {
 	diff := &Diff{
 		Path: "",
@@ -377,3 +440,278 @@ func TestGetAllDiffs_Ordering(t *testing.T) {
 		t.Error("Fields should be sorted alphabetically in output")
 	}
 }
+
+func TestPrintGitStyleDiffV2_ContextDimmed(t *testing.T) {
+	original := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = original }()
+
+	cfg := config.Default()
+	cfg.ShowContext = true
+	diff := NewDiffer(cfg, false).Compare(
+		map[string]interface{}{"scheduling": map[string]interface{}{"preemptible": false, "automaticRestart": true}},
+		map[string]interface{}{"scheduling": map[string]interface{}{"preemptible": true, "automaticRestart": true}},
+	)
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2(&buf, diff, "vm-1", "vm-2")
+	output := buf.String()
+
+	if !strings.Contains(output, newRenderer(ColorAuto).gray("automaticRestart:")) {
+		t.Errorf("Expected dimmed context line for automaticRestart, got:\n%q", output)
+	}
+	if !strings.Contains(output, "\x1b[90m") {
+		t.Errorf("Expected gray ANSI code in output, got:\n%q", output)
+	}
+}
+
+func TestPrintGitStyleDiffV2_NoContextWithoutFlag(t *testing.T) {
+	diff := NewDiffer(config.Default(), false).Compare(
+		map[string]interface{}{"scheduling": map[string]interface{}{"preemptible": false, "automaticRestart": true}},
+		map[string]interface{}{"scheduling": map[string]interface{}{"preemptible": true, "automaticRestart": true}},
+	)
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2(&buf, diff, "vm-1", "vm-2")
+
+	if strings.Contains(buf.String(), "automaticRestart") {
+		t.Errorf("Context should be absent without the flag, got:\n%s", buf.String())
+	}
+}
+
+func TestDecodeBase64Text(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		decoded string
+		ok      bool
+	}{
+		{"startup script", "IyEvYmluL2Jhc2gKZWNobyBoZWxsbw==", "#!/bin/bash\necho hello", true},
+		{"plain string", "n1-standard-2", "", false},
+		{"alphanumeric id", "abcdefghijklmnop", "", false},
+		{"too short", "aGVsbG8=", "", false},
+		{"binary payload", "AAECAwQFBgcICQoLDA0ODw==", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, ok := decodeBase64Text(tt.input)
+			if ok != tt.ok || decoded != tt.decoded {
+				t.Errorf("decodeBase64Text(%q) = %q, %v; want %q, %v", tt.input, decoded, ok, tt.decoded, tt.ok)
+			}
+		})
+	}
+}
+
+func TestPrintGitStyleDiffV2_DecodeBase64(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"userData": {
+				Path:   "userData",
+				Type:   DiffTypeModified,
+				Value1: "IyEvYmluL2Jhc2gKZWNobyBoZWxsbw==",
+				Value2: "IyEvYmluL2Jhc2gKZWNobyB3b3JsZA==",
+			},
+			"machineType": {
+				Path:   "machineType",
+				Type:   DiffTypeModified,
+				Value1: "n1-standard-2",
+				Value2: "n1-standard-4",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2WithOptions(&buf, diff, "vm-1", "vm-2", OutputOptions{DecodeBase64: true})
+	output := buf.String()
+
+	if !strings.Contains(output, `<base64> decoded: "#!/bin/bash\necho hello"`) {
+		t.Errorf("Expected decoded base64 value, got:\n%s", output)
+	}
+	if !strings.Contains(output, `"n1-standard-2"`) || strings.Contains(output, `decoded: "n1`) {
+		t.Errorf("Plain strings should be left alone, got:\n%s", output)
+	}
+
+	buf.Reset()
+	PrintGitStyleDiffV2(&buf, diff, "vm-1", "vm-2")
+	if strings.Contains(buf.String(), "<base64>") {
+		t.Error("Base64 values should not be decoded unless enabled")
+	}
+}
+
+func TestPrintGitStyleDiffV2_UnitFields(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"disks": {
+				Path: "disks",
+				Type: DiffTypeModified,
+				Children: map[string]*Diff{
+					"[0]": {
+						Path: "disks[0]",
+						Type: DiffTypeModified,
+						Children: map[string]*Diff{
+							"diskSizeGb": {Path: "disks[0].diskSizeGb", Type: DiffTypeModified, Value1: 50, Value2: 100},
+						},
+					},
+				},
+			},
+			"cpuCount": {Path: "cpuCount", Type: DiffTypeModified, Value1: 2, Value2: 4},
+		},
+	}
+
+	opts := OutputOptions{UnitFields: map[string]string{"diskSizeGb": "GB"}}
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2WithOptions(&buf, diff, "vm-1", "vm-2", opts)
+	output := buf.String()
+
+	if !strings.Contains(output, "50 GB") || !strings.Contains(output, "100 GB") {
+		t.Errorf("Expected diskSizeGb values with GB unit, got:\n%s", output)
+	}
+	if strings.Contains(output, "2 GB") || strings.Contains(output, "4 GB") {
+		t.Errorf("Other numbers should not get a unit, got:\n%s", output)
+	}
+}
+
+func TestOutputOptionsDisplay(t *testing.T) {
+	opts := OutputOptions{UnitFields: map[string]string{"*Mb": "MB", "disks*": "GB"}}
+
+	if got := opts.display("memoryMb", 2048); got != (unitValue{value: 2048, unit: "MB"}) {
+		t.Errorf("Expected name glob to match, got %v", got)
+	}
+	if got := opts.display("scheduling.memoryMb", "2048"); got != "2048" {
+		t.Errorf("Non-numeric values should not get a unit, got %v", got)
+	}
+	if got := opts.display("name", 5); got != 5 {
+		t.Errorf("Unmatched fields should be unchanged, got %v", got)
+	}
+}
+
+func TestPrintGitStyleDiffV2_DeprecatedFields(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"networkInterfaces": {
+				Path: "networkInterfaces",
+				Type: DiffTypeModified,
+				Children: map[string]*Diff{
+					"[0]": {
+						Path: "networkInterfaces[0]",
+						Type: DiffTypeModified,
+						Children: map[string]*Diff{
+							"network":    {Path: "networkInterfaces[0].network", Type: DiffTypeModified, Value1: "default", Value2: "vpc-1"},
+							"subnetwork": {Path: "networkInterfaces[0].subnetwork", Type: DiffTypeModified, Value1: "sub-1", Value2: "sub-2"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	opts := OutputOptions{DeprecatedFields: []string{"networkInterfaces.network"}}
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2WithOptions(&buf, diff, "vm-1", "vm-2", opts)
+	output := buf.String()
+
+	if !strings.Contains(output, "~ network (deprecated)\n") {
+		t.Errorf("Expected deprecated note on network, got:\n%s", output)
+	}
+	if strings.Contains(output, "subnetwork (deprecated)") {
+		t.Errorf("Expected no note on subnetwork, got:\n%s", output)
+	}
+}
+
+func TestFieldMatches(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		fieldPath string
+		expected  bool
+	}{
+		{"network", "networkInterfaces[0].network", true},
+		{"networkInterfaces.network", "networkInterfaces[2].network", true},
+		{"networkInterfaces.*", "networkInterfaces[0].subnetwork", true},
+		{"networkInterfaces.network", "networkInterfaces[0].subnetwork", false},
+		{"*Mb", "memoryMb", true},
+	}
+
+	for _, tt := range tests {
+		if got := fieldMatches(tt.pattern, tt.fieldPath); got != tt.expected {
+			t.Errorf("fieldMatches(%q, %q) = %v, want %v", tt.pattern, tt.fieldPath, got, tt.expected)
+		}
+	}
+}
+
+func TestPrintGitStyleDiffV2_ReferenceValue(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"machineType": {Path: "machineType", Type: DiffTypeModified, Value1: "n1-standard-2", Value2: "n1-standard-4"},
+			"labels": {Path: "labels", Type: DiffTypeModified, Children: map[string]*Diff{
+				"env":  {Path: "labels.env", Type: DiffTypeAdded, Value2: "staging"},
+				"team": {Path: "labels.team", Type: DiffTypeRemoved, Value1: "web"},
+			}},
+		},
+	}
+	reference := map[string]interface{}{
+		"machineType": "n1-standard-4",
+		"labels":      map[string]interface{}{"env": "prod"},
+	}
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2WithOptions(&buf, diff, "a", "b", OutputOptions{Color: ColorNever, Reference: reference})
+	output := buf.String()
+
+	for _, want := range []string{
+		"    + \"n1-standard-4\"\n    = reference: \"n1-standard-4\"\n",
+		"\"staging\"\n      = reference: \"prod\"\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+	// labels.team is missing from the reference
+	if strings.Count(output, "reference:") != 2 {
+		t.Errorf("Expected 2 reference values, got:\n%s", output)
+	}
+
+	// The other renderers show the same reference values
+	buf.Reset()
+	PrintGitStyleDiffWithOptions(&buf, diff, "a", "b", OutputOptions{Color: ColorNever, Reference: reference})
+	if got := strings.Count(buf.String(), "reference:"); got != 2 {
+		t.Errorf("Expected 2 reference values, got:\n%s", buf.String())
+	}
+	buf.Reset()
+	PrintSectionedDiff(&buf, diff, "a", "b", nil, OutputOptions{Color: ColorNever, Reference: reference})
+	if got := strings.Count(buf.String(), "reference:"); got != 2 {
+		t.Errorf("Expected 2 reference values in sectioned output, got:\n%s", buf.String())
+	}
+}
+
+func TestPrintGitStyleDiffV2_ReferenceValueOfArrayElement(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"tags": {Path: "tags", Type: DiffTypeModified, Children: map[string]*Diff{
+				"[0]": {Path: "tags[0]", Type: DiffTypeModified, Value1: "http", Value2: "https"},
+				"[1]": {Path: "tags[1]", Type: DiffTypeAdded, Value2: "ssh"},
+			}},
+		},
+	}
+	reference := map[string]interface{}{"tags": []interface{}{"https", "rdp"}}
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2WithOptions(&buf, diff, "a", "b", OutputOptions{Color: ColorNever, Reference: reference})
+	output := buf.String()
+
+	for _, want := range []string{
+		"+ \"https\"\n        = reference: \"https\"\n",
+		"+ [1] \"ssh\"\n        = reference: \"rdp\"\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
@@ -6,23 +6,46 @@ import (
 	"io"
 	"sort"
 	"strings"
+
+	"github.com/tflynn3/gcdiff/internal/config"
 )
 
 // PrintGitStyleDiffV2 prints a diff with arrays shown inline with markers
 func PrintGitStyleDiffV2(w io.Writer, diff *Diff, name1, name2 string) {
-	fmt.Fprintf(w, "%s\n", bold(fmt.Sprintf("Comparing: %s <-> %s", name1, name2)))
+	PrintGitStyleDiffV2WithOptions(w, diff, name1, name2, OutputOptions{})
+}
+
+// PrintGitStyleDiffV2WithOptions prints a diff like PrintGitStyleDiffV2,
+// rendering values according to opts
+func PrintGitStyleDiffV2WithOptions(w io.Writer, diff *Diff, name1, name2 string, opts OutputOptions) {
+	opts = opts.withColors()
+	fmt.Fprintf(w, "%s\n", opts.colors.bold(fmt.Sprintf("Comparing: %s <-> %s", name1, name2)))
 	fmt.Fprintln(w, strings.Repeat("-", 80))
 
 	if diff.Type == DiffTypeEqual {
-		fmt.Fprintf(w, "%s\n", green("✓ No differences found"))
+		fmt.Fprintf(w, "%s\n", opts.colors.green("✓ No differences found"))
 		return
 	}
+	printSideLegend(w, opts, name1, name2)
+
+	if len(opts.ShowTypes) > 0 {
+		printShowSummary(w, opts, diff)
+	} else if opts.RiskWeights != nil {
+		fmt.Fprintln(w)
+	}
+	printRiskScore(w, opts, diff)
+	rendered, hidden := opts.rendered(GetAllDiffs(diff))
+	if len(opts.ShowTypes) > 0 || hidden > 0 {
+		if diff = diff.Filter(func(d *Diff) bool { return rendered[d] }); diff.IsEmpty() {
+			return
+		}
+	}
 
 	// Group top-level differences
 	topLevelDiffs := getTopLevelDiffs(diff)
 
 	if len(topLevelDiffs) == 0 {
-		fmt.Fprintf(w, "%s\n", green("✓ No differences found"))
+		fmt.Fprintf(w, "%s\n", opts.colors.green("✓ No differences found"))
 		return
 	}
 
@@ -30,9 +53,10 @@ func PrintGitStyleDiffV2(w io.Writer, diff *Diff, name1, name2 string) {
 	fmt.Fprintln(w)
 	for _, fieldName := range getSortedKeys(topLevelDiffs) {
 		fieldDiff := topLevelDiffs[fieldName]
-		printFieldDiff(w, fieldName, fieldDiff, 0)
+		printFieldDiff(w, opts, fieldName, fieldDiff, 0)
 		fmt.Fprintln(w)
 	}
+	printMoreChanges(w, opts, hidden)
 }
 
 // getTopLevelDiffs groups diffs by their top-level field name
@@ -41,7 +65,7 @@ func getTopLevelDiffs(diff *Diff) map[string]*Diff {
 
 	for key, child := range diff.Children {
 		// Extract top-level field name (before any brackets or dots)
-		topField := extractTopLevelField(key)
+		topField := extractTopLevelField(child.Path)
 		if existing, ok := result[topField]; ok {
 			// Merge if same top field
 			if existing.Children == nil {
@@ -61,10 +85,14 @@ func getTopLevelDiffs(diff *Diff) map[string]*Diff {
 
 func extractTopLevelField(path string) string {
 	// Extract field name before any [index] or .subfield
-	if idx := strings.IndexAny(path, ".["); idx != -1 {
-		return path[:idx]
+	tokens, err := config.SplitPath(path)
+	if err != nil || len(tokens) == 0 {
+		return path
+	}
+	if tokens[0].Index {
+		return ""
 	}
-	return path
+	return quoteKey(tokens[0].Text)
 }
 
 func getSortedKeys(m map[string]*Diff) []string {
@@ -72,43 +100,66 @@ func getSortedKeys(m map[string]*Diff) []string {
 	for k := range m {
 		keys = append(keys, k)
 	}
-	sort.Strings(keys)
+	sortPaths(keys)
 	return keys
 }
 
-func printFieldDiff(w io.Writer, fieldName string, fieldDiff *Diff, indent int) {
+func printFieldDiff(w io.Writer, opts OutputOptions, fieldName string, fieldDiff *Diff, indent int) {
 	indentStr := strings.Repeat("  ", indent)
 
 	// Check if this is an array diff
 	if isArrayDiff(fieldDiff) {
-		printArrayDiff(w, fieldName, fieldDiff, indent)
+		printArrayDiff(w, opts, fieldName, fieldDiff, indent)
 		return
 	}
 
 	// Check if this is an object diff
 	if len(fieldDiff.Children) > 0 && fieldDiff.Type == DiffTypeModified {
-		fmt.Fprintf(w, "%s%s %s\n", indentStr, yellow("~"), cyan(fieldName))
+		fmt.Fprintf(w, "%s%s %s%s\n", indentStr, opts.colors.yellow("~"), opts.colors.cyan(fieldName), opts.annotate(fieldDiff.Path))
 		for _, childKey := range getSortedKeys(fieldDiff.Children) {
 			childDiff := fieldDiff.Children[childKey]
-			printFieldDiff(w, childKey, childDiff, indent+1)
+			printFieldDiff(w, opts, childKey, childDiff, indent+1)
 		}
 		return
 	}
 
 	// Simple field diff
 	switch fieldDiff.Type {
+	case DiffTypeEqual:
+		// Unchanged sibling kept for context
+		fmt.Fprintf(w, "%s  %s ", indentStr, opts.colors.gray(fieldName+":"))
+		printInlineValue(w, opts, opts.display(fieldDiff.Path, fieldDiff.Value1), opts.colors.gray)
 	case DiffTypeAdded:
-		fmt.Fprintf(w, "%s%s %s\n", indentStr, green("+"), cyan(fieldName))
-		printValue(w, indentStr+"    ", fieldDiff.Value2, green)
+		fmt.Fprintf(w, "%s%s %s%s\n", indentStr, opts.colors.green("+"), opts.colors.cyan(fieldName), opts.annotate(fieldDiff.Path))
+		printValue(w, opts, indentStr+"    ", opts.display(fieldDiff.Path, fieldDiff.Value2), opts.colors.green)
+		printReferenceValue(w, opts, indentStr+"    ", fieldDiff.Path)
 	case DiffTypeRemoved:
-		fmt.Fprintf(w, "%s%s %s\n", indentStr, red("-"), cyan(fieldName))
-		printValue(w, indentStr+"    ", fieldDiff.Value1, red)
+		fmt.Fprintf(w, "%s%s %s%s\n", indentStr, opts.colors.red("-"), opts.colors.cyan(fieldName), opts.annotate(fieldDiff.Path))
+		printValue(w, opts, indentStr+"    ", opts.display(fieldDiff.Path, fieldDiff.Value1), opts.colors.red)
+		printReferenceValue(w, opts, indentStr+"    ", fieldDiff.Path)
 	case DiffTypeModified:
-		fmt.Fprintf(w, "%s%s %s\n", indentStr, yellow("~"), cyan(fieldName))
-		fmt.Fprintf(w, "%s    %s ", indentStr, red("-"))
-		printValue(w, indentStr+"      ", fieldDiff.Value1, red)
-		fmt.Fprintf(w, "%s    %s ", indentStr, green("+"))
-		printValue(w, indentStr+"      ", fieldDiff.Value2, green)
+		fmt.Fprintf(w, "%s%s %s%s\n", indentStr, opts.colors.yellow("~"), opts.colors.cyan(fieldName), opts.annotate(fieldDiff.Path))
+		if isMultilineChange(fieldDiff.Value1, fieldDiff.Value2) {
+			// Show a line-level diff instead of dumping both full strings
+			printMultilineDiff(w, opts.colors, indentStr+"    ", fieldDiff.Value1.(string), fieldDiff.Value2.(string))
+			printReferenceValue(w, opts, indentStr+"    ", fieldDiff.Path)
+			return
+		}
+		// Show only the changed part of large object or array values
+		if opts.CompactValues && isComplexChange(fieldDiff.Value1, fieldDiff.Value2) &&
+			printCompactValueDiff(w, opts.colors, indentStr+"    ", fieldDiff.Value1, fieldDiff.Value2, opts.ContextLines) {
+			printReferenceValue(w, opts, indentStr+"    ", fieldDiff.Path)
+			return
+		}
+		value1, value2 := opts.highlight(opts.display(fieldDiff.Path, fieldDiff.Value1), opts.display(fieldDiff.Path, fieldDiff.Value2))
+		fmt.Fprintf(w, "%s    %s ", indentStr, opts.colors.red("-"))
+		printValue(w, opts, indentStr+"      ", value1, opts.colors.red)
+		fmt.Fprintf(w, "%s    %s ", indentStr, opts.colors.green("+"))
+		printValue(w, opts, indentStr+"      ", value2, opts.colors.green)
+		printReferenceValue(w, opts, indentStr+"    ", fieldDiff.Path)
+		if fieldDiff.Note != "" {
+			fmt.Fprintf(w, "%s    %s\n", indentStr, opts.colors.gray("("+fieldDiff.Note+")"))
+		}
 	}
 }
 
@@ -126,82 +177,155 @@ func isArrayDiff(diff *Diff) bool {
 	return true
 }
 
-func printArrayDiff(w io.Writer, fieldName string, arrayDiff *Diff, indent int) {
+func printArrayDiff(w io.Writer, opts OutputOptions, fieldName string, arrayDiff *Diff, indent int) {
 	indentStr := strings.Repeat("  ", indent)
 
-	fmt.Fprintf(w, "%s%s %s (array with changes)\n", indentStr, yellow("~"), cyan(fieldName))
-
-	// Get all array indices
-	indices := make([]int, 0)
-	childMap := make(map[int]*Diff)
-
-	for key, child := range arrayDiff.Children {
-		var idx int
-		if _, err := fmt.Sscanf(key, "[%d]", &idx); err != nil {
-			continue
-		}
-		indices = append(indices, idx)
-		childMap[idx] = child
-	}
-	sort.Ints(indices)
+	fmt.Fprintf(w, "%s%s %s (array with changes)%s\n", indentStr, opts.colors.yellow("~"), opts.colors.cyan(fieldName), opts.annotate(arrayDiff.Path))
 
 	// Print each array element with diff markers
-	for _, idx := range indices {
-		child := childMap[idx]
-		elementIndent := indentStr + "    "
+	elementIndent := indentStr + "    "
+	next := 0
+	compact := opts.CompactArrays && max(arrayDiff.Length1, arrayDiff.Length2) > 0
+	for _, entry := range sortedArrayEntries(arrayDiff) {
+		idx, child := entry.index, entry.diff
+		if compact && child.Type != DiffTypeEqual {
+			printUnchangedRun(w, opts, elementIndent, next, idx-1)
+			next = max(next, idx+1)
+		}
 
 		switch child.Type {
 		case DiffTypeAdded:
-			fmt.Fprintf(w, "%s%s [%d] ", elementIndent, green("+"), idx)
-			printInlineValue(w, child.Value2, green)
+			fmt.Fprintf(w, "%s%s [%d] ", elementIndent, opts.colors.green("+"), idx)
+			printInlineValue(w, opts, opts.display(child.Path, child.Value2), opts.colors.green)
+			printReferenceValue(w, opts, elementIndent+"    ", child.Path)
 		case DiffTypeRemoved:
-			fmt.Fprintf(w, "%s%s [%d] ", elementIndent, red("-"), idx)
-			printInlineValue(w, child.Value1, red)
+			fmt.Fprintf(w, "%s%s [%d] ", elementIndent, opts.colors.red("-"), idx)
+			printInlineValue(w, opts, opts.display(child.Path, child.Value1), opts.colors.red)
+			printReferenceValue(w, opts, elementIndent+"    ", child.Path)
 		case DiffTypeModified:
 			// Show the element with nested changes
 			if len(child.Children) > 0 {
-				fmt.Fprintf(w, "%s%s [%d] (modified)\n", elementIndent, yellow("~"), idx)
+				fmt.Fprintf(w, "%s%s [%d] (modified)\n", elementIndent, opts.colors.yellow("~"), idx)
 				for _, childKey := range getSortedKeys(child.Children) {
 					childDiff := child.Children[childKey]
-					printNestedChange(w, elementIndent+"  ", childKey, childDiff)
+					printNestedChange(w, opts, elementIndent+"  ", childKey, childDiff)
 				}
 			} else {
 				// Simple value change
-				fmt.Fprintf(w, "%s%s [%d]\n", elementIndent, yellow("~"), idx)
-				fmt.Fprintf(w, "%s    %s ", elementIndent, red("-"))
-				printInlineValue(w, child.Value1, red)
-				fmt.Fprintf(w, "%s    %s ", elementIndent, green("+"))
-				printInlineValue(w, child.Value2, green)
+				fmt.Fprintf(w, "%s%s [%d]\n", elementIndent, opts.colors.yellow("~"), idx)
+				if opts.CompactValues && isComplexChange(child.Value1, child.Value2) &&
+					printCompactValueDiff(w, opts.colors, elementIndent+"    ", child.Value1, child.Value2, opts.ContextLines) {
+					printReferenceValue(w, opts, elementIndent+"    ", child.Path)
+					continue
+				}
+				fmt.Fprintf(w, "%s    %s ", elementIndent, opts.colors.red("-"))
+				printInlineValue(w, opts, opts.display(child.Path, child.Value1), opts.colors.red)
+				fmt.Fprintf(w, "%s    %s ", elementIndent, opts.colors.green("+"))
+				printInlineValue(w, opts, opts.display(child.Path, child.Value2), opts.colors.green)
+				printReferenceValue(w, opts, elementIndent+"    ", child.Path)
 			}
 		}
 	}
+	if compact {
+		printUnchangedRun(w, opts, elementIndent, next, max(arrayDiff.Length1, arrayDiff.Length2)-1)
+	}
 }
 
-func printNestedChange(w io.Writer, indent string, key string, diff *Diff) {
+// printUnchangedRun prints a marker for the unchanged array elements first
+// through last, if there are any
+func printUnchangedRun(w io.Writer, opts OutputOptions, indent string, first, last int) {
+	switch {
+	case last < first:
+		return
+	case last == first:
+		fmt.Fprintf(w, "%s  %s\n", indent, opts.colors.gray(fmt.Sprintf("[%d] 1 unchanged element", first)))
+	default:
+		fmt.Fprintf(w, "%s  %s\n", indent, opts.colors.gray(fmt.Sprintf("[%d..%d] %d unchanged elements", first, last, last-first+1)))
+	}
+}
+
+// arrayEntry is an array diff child with its parsed element index
+type arrayEntry struct {
+	index int
+	key   string
+	diff  *Diff
+}
+
+// sortedArrayEntries returns the children of an array diff ordered by element
+// index. Several children may share an index when elements were matched by
+// similarity (e.g. an element removed from index 0 of the first array and one
+// added at index 0 of the second).
+func sortedArrayEntries(arrayDiff *Diff) []arrayEntry {
+	entries := make([]arrayEntry, 0, len(arrayDiff.Children))
+	for key, child := range arrayDiff.Children {
+		var idx int
+		if _, err := fmt.Sscanf(key, "[%d]", &idx); err != nil {
+			if _, err := fmt.Sscanf(key, "[removed:%d]", &idx); err != nil {
+				continue
+			}
+		}
+		entries = append(entries, arrayEntry{index: idx, key: key, diff: child})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].index != entries[j].index {
+			return entries[i].index < entries[j].index
+		}
+		return entries[i].key < entries[j].key
+	})
+	return entries
+}
+
+func printNestedChange(w io.Writer, opts OutputOptions, indent string, key string, diff *Diff) {
 	switch diff.Type {
+	case DiffTypeEqual:
+		fmt.Fprintf(w, "%s    %s ", indent, opts.colors.gray(key+":"))
+		printInlineValue(w, opts, opts.display(diff.Path, diff.Value1), opts.colors.gray)
 	case DiffTypeAdded:
-		fmt.Fprintf(w, "%s  %s %s: ", indent, green("+"), key)
-		printInlineValue(w, diff.Value2, green)
+		fmt.Fprintf(w, "%s  %s %s%s: ", indent, opts.colors.green("+"), key, opts.annotate(diff.Path))
+		printInlineValue(w, opts, opts.display(diff.Path, diff.Value2), opts.colors.green)
+		printReferenceValue(w, opts, indent+"      ", diff.Path)
 	case DiffTypeRemoved:
-		fmt.Fprintf(w, "%s  %s %s: ", indent, red("-"), key)
-		printInlineValue(w, diff.Value1, red)
+		fmt.Fprintf(w, "%s  %s %s%s: ", indent, opts.colors.red("-"), key, opts.annotate(diff.Path))
+		printInlineValue(w, opts, opts.display(diff.Path, diff.Value1), opts.colors.red)
+		printReferenceValue(w, opts, indent+"      ", diff.Path)
 	case DiffTypeModified:
-		fmt.Fprintf(w, "%s  %s %s\n", indent, yellow("~"), key)
+		fmt.Fprintf(w, "%s  %s %s%s\n", indent, opts.colors.yellow("~"), key, opts.annotate(diff.Path))
 		if len(diff.Children) > 0 {
 			// Nested object changes
 			for _, childKey := range getSortedKeys(diff.Children) {
-				printNestedChange(w, indent+"  ", childKey, diff.Children[childKey])
+				printNestedChange(w, opts, indent+"  ", childKey, diff.Children[childKey])
 			}
 		} else {
-			fmt.Fprintf(w, "%s      %s ", indent, red("-"))
-			printInlineValue(w, diff.Value1, red)
-			fmt.Fprintf(w, "%s      %s ", indent, green("+"))
-			printInlineValue(w, diff.Value2, green)
+			if opts.CompactValues && isComplexChange(diff.Value1, diff.Value2) &&
+				printCompactValueDiff(w, opts.colors, indent+"      ", diff.Value1, diff.Value2, opts.ContextLines) {
+				printReferenceValue(w, opts, indent+"      ", diff.Path)
+				return
+			}
+			value1, value2 := opts.highlight(opts.display(diff.Path, diff.Value1), opts.display(diff.Path, diff.Value2))
+			fmt.Fprintf(w, "%s      %s ", indent, opts.colors.red("-"))
+			printInlineValue(w, opts, value1, opts.colors.red)
+			fmt.Fprintf(w, "%s      %s ", indent, opts.colors.green("+"))
+			printInlineValue(w, opts, value2, opts.colors.green)
+			printReferenceValue(w, opts, indent+"      ", diff.Path)
 		}
 	}
 }
 
-func printInlineValue(w io.Writer, value interface{}, colorFunc func(...interface{}) string) {
+// printReferenceValue prints the reference resource's value at fieldPath, if
+// a reference is set and has the field
+func printReferenceValue(w io.Writer, opts OutputOptions, indent, fieldPath string) {
+	if opts.Reference == nil {
+		return
+	}
+	value, ok := lookupField(opts.Reference, fieldPath)
+	if !ok {
+		return
+	}
+	fmt.Fprintf(w, "%s%s ", indent, opts.colors.cyan("= reference:"))
+	printInlineValue(w, opts, opts.display(fieldPath, value), opts.colors.gray)
+}
+
+func printInlineValue(w io.Writer, opts OutputOptions, value interface{}, colorFunc func(...interface{}) string) {
 	if value == nil {
 		fmt.Fprintf(w, "%s\n", colorFunc("<nil>"))
 		return
@@ -215,7 +339,9 @@ func printInlineValue(w io.Writer, value interface{}, colorFunc func(...interfac
 		jsonBytes, _ := json.Marshal(v)
 		fmt.Fprintf(w, "%s\n", colorFunc(string(jsonBytes)))
 	case string:
-		fmt.Fprintf(w, "%s\n", colorFunc(fmt.Sprintf("%q", v)))
+		fmt.Fprintf(w, "%s\n", colorFunc(formatString(v, opts)))
+	case styledText:
+		fmt.Fprintf(w, "%s\n", v)
 	default:
 		fmt.Fprintf(w, "%s\n", colorFunc(fmt.Sprintf("%v", v)))
 	}
@@ -0,0 +1,47 @@
+package compare
+
+import "sync"
+
+// compareFieldsParallel compares the top-level keys of two objects on up to
+// d.parallel goroutines and merges the child diffs into diff. Each worker is
+// a copy of d that counts fields and collects warnings of its own, which are
+// added to d once it is done.
+func (d *Differ) compareFieldsParallel(obj1, obj2 map[string]interface{}, keys map[string]bool, diff *Diff) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	queue := make(chan string)
+	for i := 0; i < min(d.parallel, len(keys)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker := *d
+			worker.compared, worker.warnings = 0, nil
+			for key := range queue {
+				child := worker.compareField(obj1, obj2, key, "")
+				if child == nil {
+					continue
+				}
+				mu.Lock()
+				diff.Children[key] = child
+				if child.Type != DiffTypeEqual {
+					diff.Type = DiffTypeModified
+				}
+				mu.Unlock()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			d.compared += worker.compared
+			for warning := range worker.warnings {
+				d.warn("%s", warning)
+			}
+		}()
+	}
+
+	for key := range keys {
+		queue <- key
+	}
+	close(queue)
+	wg.Wait()
+}
@@ -0,0 +1,92 @@
+package compare
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+// wideObjects returns two objects with many top-level fields, some changed,
+// some nested and some colliding under a key alias
+func wideObjects() (map[string]interface{}, map[string]interface{}) {
+	obj1 := map[string]interface{}{}
+	obj2 := map[string]interface{}{}
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("field%d", i)
+		obj1[key] = map[string]interface{}{
+			"value": float64(i),
+			"tags":  []interface{}{"a", "b"},
+			"Name":  "x", "name": "y",
+		}
+		obj2[key] = map[string]interface{}{
+			"value": float64(i + i%3),
+			"tags":  []interface{}{"a", fmt.Sprint(i % 2)},
+			"Name":  "x", "name": "y",
+		}
+	}
+	obj1["removed"] = "gone"
+	obj2["added"] = true
+	return obj1, obj2
+}
+
+func TestCompare_ParallelMatchesSequential(t *testing.T) {
+	cfg := config.Default()
+	cfg.ShowContext = true
+	cfg.KeyAliases = map[string]string{"Name": "name"}
+	obj1, obj2 := wideObjects()
+
+	sequential := NewDiffer(cfg, false)
+	expected := sequential.Compare(obj1, obj2)
+
+	parallel := NewDiffer(cfg, false)
+	parallel.SetParallel(8)
+	for run := 0; run < 3; run++ {
+		diff := parallel.Compare(obj1, obj2)
+		if !reflect.DeepEqual(diff, expected) {
+			t.Fatalf("Run %d: parallel diff differs from sequential", run)
+		}
+		if parallel.ComparedFields() != sequential.ComparedFields() {
+			t.Errorf("Expected %d compared fields, got %d", sequential.ComparedFields(), parallel.ComparedFields())
+		}
+		if !reflect.DeepEqual(parallel.Warnings(), sequential.Warnings()) {
+			t.Errorf("Expected warnings %v, got %v", sequential.Warnings(), parallel.Warnings())
+		}
+	}
+
+	if len(sequential.Warnings()) == 0 {
+		t.Error("Expected the key alias collisions to produce warnings")
+	}
+}
+
+func TestCompare_ParallelKeepsNumericTolerance(t *testing.T) {
+	obj1, _ := wideObjects()
+	obj2 := map[string]interface{}{}
+	for key, value := range obj1 {
+		if field, ok := value.(map[string]interface{}); ok {
+			shifted := map[string]interface{}{}
+			for k, v := range field {
+				shifted[k] = v
+			}
+			shifted["value"] = field["value"].(float64) + 0.001
+			value = shifted
+		}
+		obj2[key] = value
+	}
+
+	d := NewDifferWithOptions(WithNumericTolerance(0.01), WithParallel(4))
+	if diff := d.Compare(obj1, obj2); !diff.IsEmpty() {
+		t.Errorf("Expected values within the tolerance to be equal, got %v", GetAllDiffs(diff))
+	}
+}
+
+func TestCompare_ParallelEqualObjects(t *testing.T) {
+	obj1, _ := wideObjects()
+	d := NewDiffer(config.Default(), false)
+	d.SetParallel(4)
+
+	if diff := d.Compare(obj1, obj1); !diff.IsEmpty() {
+		t.Errorf("Expected no differences, got %v", GetAllDiffs(diff))
+	}
+}
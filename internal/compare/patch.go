@@ -0,0 +1,104 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// patchContext is the number of unchanged lines around each hunk, as in
+// diff -u
+const patchContext = 3
+
+// WritePatch writes a unified diff that turns the indented JSON of resource1
+// into that of resource2, for `patch -p1` or `git apply` on fileName: the
+// file resource1 was written to, as --dump-resources writes it. Identical
+// resources produce an empty patch.
+func WritePatch(w io.Writer, fileName string, resource1, resource2 interface{}) error {
+	json1, err := json.MarshalIndent(resource1, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode resource 1: %w", err)
+	}
+	json2, err := json.MarshalIndent(resource2, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode resource 2: %w", err)
+	}
+
+	ops := diffLines(string(json1), string(json2))
+	hunks := patchHunks(ops)
+	if len(hunks) == 0 {
+		return nil
+	}
+
+	// lines1[i] and lines2[i] count the lines of each file before ops[i]
+	lines1 := make([]int, len(ops)+1)
+	lines2 := make([]int, len(ops)+1)
+	for i, op := range ops {
+		lines1[i+1], lines2[i+1] = lines1[i], lines2[i]
+		if op.Type != DiffTypeAdded {
+			lines1[i+1]++
+		}
+		if op.Type != DiffTypeRemoved {
+			lines2[i+1]++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", fileName, fileName)
+	for _, hunk := range hunks {
+		lo, hi := hunk[0], hunk[1]
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n",
+			hunkRange(lines1[lo], lines1[hi]-lines1[lo]), hunkRange(lines2[lo], lines2[hi]-lines2[lo]))
+		for _, op := range ops[lo:hi] {
+			switch op.Type {
+			case DiffTypeAdded:
+				b.WriteString("+")
+			case DiffTypeRemoved:
+				b.WriteString("-")
+			default:
+				b.WriteString(" ")
+			}
+			b.WriteString(op.Text + "\n")
+		}
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// SavePatch writes the patch from resource1 to resource2 to a file
+func SavePatch(path, fileName string, resource1, resource2 interface{}) error {
+	var b strings.Builder
+	if err := WritePatch(&b, fileName, resource1, resource2); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// patchHunks returns the [start, end) ranges of ops shown as hunks: each
+// change with patchContext lines around it, merging ranges that touch
+func patchHunks(ops []lineOp) [][2]int {
+	var hunks [][2]int
+	for i, op := range ops {
+		if op.Type == DiffTypeEqual {
+			continue
+		}
+		lo, hi := max(0, i-patchContext), min(len(ops), i+patchContext+1)
+		if n := len(hunks); n > 0 && lo <= hunks[n-1][1] {
+			hunks[n-1][1] = max(hunks[n-1][1], hi)
+			continue
+		}
+		hunks = append(hunks, [2]int{lo, hi})
+	}
+	return hunks
+}
+
+// hunkRange formats one side of a hunk header given the number of lines
+// before the hunk and its length. An empty side names the line it follows.
+func hunkRange(before, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	return fmt.Sprintf("%d,%d", before+1, length)
+}
@@ -0,0 +1,133 @@
+package compare
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// applyPatch applies a unified diff to original, checking that every
+// context and removed line matches
+func applyPatch(t *testing.T, original, patch string) string {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(original, "\n"), "\n")
+	var result []string
+	next := 0 // index of the next original line to copy
+
+	for _, line := range strings.Split(strings.TrimSuffix(patch, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+		case strings.HasPrefix(line, "@@"):
+			// @@ -start,length +start,length @@
+			old := strings.TrimPrefix(strings.Fields(line)[1], "-")
+			startText, lengthText, _ := strings.Cut(old, ",")
+			start, _ := strconv.Atoi(startText)
+			if lengthText != "0" {
+				start--
+			}
+			result = append(result, lines[next:start]...)
+			next = start
+		case strings.HasPrefix(line, "+"):
+			result = append(result, line[1:])
+		default:
+			if next >= len(lines) || lines[next] != line[1:] {
+				t.Fatalf("Patch line %q does not match original line %d", line, next+1)
+			}
+			if line[0] == ' ' {
+				result = append(result, line[1:])
+			}
+			next++
+		}
+	}
+	result = append(result, lines[next:]...)
+	return strings.Join(result, "\n") + "\n"
+}
+
+func patchTestResources() (map[string]interface{}, map[string]interface{}) {
+	resource1 := map[string]interface{}{
+		"name":        "web-1",
+		"machineType": "n1-standard-2",
+		"disks":       []interface{}{map[string]interface{}{"deviceName": "boot", "sizeGb": 10}},
+		"labels":      map[string]interface{}{"env": "dev", "team": "web"},
+		"a":           1, "b": 2, "c": 3, "d": 4, "e": 5, "f": 6, "g": 7, "h": 8,
+		"zone": "us-central1-a",
+	}
+	resource2 := map[string]interface{}{
+		"name":        "web-2",
+		"machineType": "n1-standard-2",
+		"disks":       []interface{}{map[string]interface{}{"deviceName": "boot", "sizeGb": 10}},
+		"labels":      map[string]interface{}{"env": "prod", "team": "web"},
+		"a":           100, "b": 2, "c": 3, "d": 4, "e": 5, "f": 6, "g": 7, "h": 8,
+		"tags": []interface{}{"http"},
+	}
+	return resource1, resource2
+}
+
+func TestWritePatch(t *testing.T) {
+	resource1, resource2 := patchTestResources()
+
+	var buf bytes.Buffer
+	if err := WritePatch(&buf, "resource1.json", resource1, resource2); err != nil {
+		t.Fatalf("WritePatch failed: %v", err)
+	}
+	patch := buf.String()
+
+	if !strings.HasPrefix(patch, "--- a/resource1.json\n+++ b/resource1.json\n@@ ") {
+		t.Errorf("Expected file headers, got:\n%s", patch)
+	}
+	// The change to a is too far from the rest to share their hunk
+	if got := strings.Count(patch, "\n@@ "); got != 2 {
+		t.Errorf("Expected 2 hunks, got %d:\n%s", got, patch)
+	}
+
+	json1, _ := json.MarshalIndent(resource1, "", "  ")
+	json2, _ := json.MarshalIndent(resource2, "", "  ")
+	if got := applyPatch(t, string(json1)+"\n", patch); got != string(json2)+"\n" {
+		t.Errorf("Expected the patch to produce the second resource, got:\n%s", got)
+	}
+}
+
+func TestWritePatch_GitApply(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	resource1, resource2 := patchTestResources()
+
+	dir := t.TempDir()
+	json1, _ := json.MarshalIndent(resource1, "", "  ")
+	if err := os.WriteFile(filepath.Join(dir, "resource1.json"), append(json1, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+	patchPath := filepath.Join(dir, "diff.patch")
+	if err := SavePatch(patchPath, "resource1.json", resource1, resource2); err != nil {
+		t.Fatalf("SavePatch failed: %v", err)
+	}
+
+	apply := exec.Command("git", "apply", patchPath)
+	apply.Dir = dir
+	if output, err := apply.CombinedOutput(); err != nil {
+		t.Fatalf("git apply failed: %v\n%s", err, output)
+	}
+
+	got, _ := os.ReadFile(filepath.Join(dir, "resource1.json"))
+	json2, _ := json.MarshalIndent(resource2, "", "  ")
+	if string(got) != string(json2)+"\n" {
+		t.Errorf("Expected git apply to produce the second resource, got:\n%s", got)
+	}
+}
+
+func TestWritePatch_Identical(t *testing.T) {
+	resource := map[string]interface{}{"name": "web"}
+	var buf bytes.Buffer
+	if err := WritePatch(&buf, "resource1.json", resource, resource); err != nil {
+		t.Fatalf("WritePatch failed: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected an empty patch, got:\n%s", buf.String())
+	}
+}
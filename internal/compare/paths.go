@@ -0,0 +1,80 @@
+package compare
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// lessPath orders field paths for display: array indices compare numerically
+// (so foo[2] sorts before foo[10]) and everything else lexicographically
+func lessPath(a, b string) bool {
+	for a != "" && b != "" {
+		indexA, restA, okA := leadingIndex(a)
+		indexB, restB, okB := leadingIndex(b)
+		if okA && okB {
+			if indexA != indexB {
+				return indexA < indexB
+			}
+			a, b = restA, restB
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// leadingIndex parses an array index such as "[12]" at the start of s,
+// returning the index and the rest of s
+func leadingIndex(s string) (int, string, bool) {
+	if !strings.HasPrefix(s, "[") {
+		return 0, s, false
+	}
+	end := strings.IndexByte(s, ']')
+	if end < 2 {
+		return 0, s, false
+	}
+	index, err := strconv.Atoi(s[1:end])
+	if err != nil {
+		return 0, s, false
+	}
+	return index, s[end+1:], true
+}
+
+// quoteKey returns key as a field path segment. Keys containing a path
+// separator, such as the annotation app.kubernetes.io/name, are quoted so the
+// path stays unambiguous: metadata.annotations."app.kubernetes.io/name".
+func quoteKey(key string) string {
+	if strings.ContainsAny(key, `.["`) {
+		return strconv.Quote(key)
+	}
+	return key
+}
+
+// firstMatch returns the value patterns configures for path: an exact entry,
+// or else the value of the first pattern, in sorted order so overlapping
+// globs resolve deterministically, that match reports matches path
+func firstMatch[T any](patterns map[string]T, path string, match func(pattern, path string) bool) (T, bool) {
+	if value, ok := patterns[path]; ok {
+		return value, true
+	}
+	for _, pattern := range slices.Sorted(maps.Keys(patterns)) {
+		if match(pattern, path) {
+			return patterns[pattern], true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// sortPaths sorts field paths or keys in display order
+func sortPaths(paths []string) {
+	sort.Slice(paths, func(i, j int) bool {
+		return lessPath(paths[i], paths[j])
+	})
+}
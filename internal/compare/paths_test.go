@@ -0,0 +1,62 @@
+package compare
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSortPaths(t *testing.T) {
+	paths := []string{"tags[10]", "disks[1].type", "tags[2]", "disks[0].type", "disks[10].type", "disks", "alpha"}
+	sortPaths(paths)
+
+	expected := []string{"alpha", "disks", "disks[0].type", "disks[1].type", "disks[10].type", "tags[2]", "tags[10]"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+}
+
+func TestPrintGitStyleDiff_NumericIndexOrder(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"tags": {
+				Path: "tags",
+				Type: DiffTypeModified,
+				Children: map[string]*Diff{
+					"[10]": {Path: "tags[10]", Type: DiffTypeAdded, Value2: "ten"},
+					"[2]":  {Path: "tags[2]", Type: DiffTypeAdded, Value2: "two"},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	PrintGitStyleDiff(&buf, diff, "a", "b")
+	output := buf.String()
+
+	two, ten := strings.Index(output, "tags[2]"), strings.Index(output, "tags[10]")
+	if two == -1 || ten == -1 || two > ten {
+		t.Errorf("Expected tags[2] before tags[10], got:\n%s", output)
+	}
+
+	if diffs := GetAllDiffs(diff); diffs[0].Path != "tags[2]" {
+		t.Errorf("Expected GetAllDiffs to list tags[2] first, got %v", diffs[0].Path)
+	}
+}
+
+func TestSplitPath_QuotedKeys(t *testing.T) {
+	path := joinPath(joinPath("metadata", "annotations"), "app.kubernetes.io/name")
+	if path != `metadata.annotations."app.kubernetes.io/name"` {
+		t.Fatalf("Expected the dotted key to be quoted, got %s", path)
+	}
+
+	expected := []string{"metadata", "annotations", "app.kubernetes.io/name"}
+	if keys := fieldKeys(path); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected %v, got %v", expected, keys)
+	}
+	if got := extractTopLevelField(`"a.b[0]".c`); got != `"a.b[0]"` {
+		t.Errorf("Expected the quoted top-level key, got %s", got)
+	}
+}
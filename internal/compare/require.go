@@ -0,0 +1,14 @@
+package compare
+
+// MissingFields returns the fields of required that resource lacks. A field
+// is a dotted path that may index into arrays, e.g. disks[0].type; a field
+// present with a null value counts as present.
+func MissingFields(resource map[string]interface{}, required []string) []string {
+	var missing []string
+	for _, field := range required {
+		if _, ok := lookupField(resource, field); !ok {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
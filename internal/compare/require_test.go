@@ -0,0 +1,34 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMissingFields(t *testing.T) {
+	resource := map[string]interface{}{
+		"name":        "vm-1",
+		"description": nil,
+		"scheduling":  map[string]interface{}{"preemptible": false},
+		"disks":       []interface{}{map[string]interface{}{"type": "PERSISTENT"}},
+	}
+
+	tests := []struct {
+		name     string
+		required []string
+		want     []string
+	}{
+		{"present", []string{"name", "description"}, nil},
+		{"nested", []string{"scheduling.preemptible", "disks[0].type"}, nil},
+		{"missing", []string{"labels", "scheduling.automaticRestart", "disks[1].type", "name.first"},
+			[]string{"labels", "scheduling.automaticRestart", "disks[1].type", "name.first"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MissingFields(resource, tt.required); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
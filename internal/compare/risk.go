@@ -0,0 +1,41 @@
+package compare
+
+import (
+	"fmt"
+	"io"
+)
+
+// defaultRiskWeight is the weight of a changed path no risk weight matches
+const defaultRiskWeight = 1
+
+// computeRisk sums the risk weight of each changed path. A path takes the
+// highest weight of the globs that match it (by name, full path or path
+// without indices, like fieldMatches), or defaultRiskWeight if none do.
+func computeRisk(diffs []*Diff, weights map[string]int) int {
+	total := 0
+	for _, d := range diffs {
+		if d.Type == DiffTypeEqual {
+			continue
+		}
+		weight, matched := 0, false
+		for pattern, w := range weights {
+			if fieldMatches(pattern, d.Path) && (!matched || w > weight) {
+				weight, matched = w, true
+			}
+		}
+		if !matched {
+			weight = defaultRiskWeight
+		}
+		total += weight
+	}
+	return total
+}
+
+// printRiskScore prints the risk score of diff's changes when opts has risk
+// weights configured
+func printRiskScore(w io.Writer, opts OutputOptions, diff *Diff) {
+	if opts.RiskWeights == nil {
+		return
+	}
+	fmt.Fprintf(w, "%s\n", opts.colors.bold(fmt.Sprintf("Risk score: %d", computeRisk(GetAllDiffs(diff), opts.RiskWeights))))
+}
@@ -0,0 +1,55 @@
+package compare
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+func TestComputeRisk(t *testing.T) {
+	diffs := []*Diff{
+		{Path: "serviceAccounts[0].email", Type: DiffTypeModified},
+		{Path: "networkInterfaces[0].network", Type: DiffTypeModified},
+		{Path: "labels.env", Type: DiffTypeAdded},
+		{Path: "description", Type: DiffTypeRemoved},
+	}
+
+	// Unweighted changes count 1 each
+	if got := computeRisk(diffs, nil); got != 4 {
+		t.Errorf("Expected default-weighted risk 4, got %d", got)
+	}
+
+	weights := map[string]int{
+		"serviceAccounts.email":  20,
+		"networkInterfaces[*].*": 10,
+		"network":                15,
+		"labels.*":               0,
+	}
+	// email 20 + network max(10, 15) + labels 0 + description 1
+	if got := computeRisk(diffs, weights); got != 36 {
+		t.Errorf("Expected weighted risk 36, got %d", got)
+	}
+}
+
+func TestPrintGitStyleDiffV2_RiskScore(t *testing.T) {
+	d := NewDiffer(config.Default(), false)
+	obj1 := map[string]interface{}{"machineType": "n1", "description": "a"}
+	obj2 := map[string]interface{}{"machineType": "n2", "description": "b"}
+	diff := d.Compare(obj1, obj2)
+
+	var buf bytes.Buffer
+	opts := OutputOptions{Color: ColorNever, RiskWeights: map[string]int{"machineType": 41}}
+	PrintGitStyleDiffV2WithOptions(&buf, diff, "a", "b", opts)
+	if !strings.Contains(buf.String(), "Risk score: 42\n") {
+		t.Errorf("Expected a risk score of 42, got:\n%s", buf.String())
+	}
+
+	// Without weights there is no score
+	buf.Reset()
+	PrintGitStyleDiffV2WithOptions(&buf, diff, "a", "b", OutputOptions{Color: ColorNever})
+	if strings.Contains(buf.String(), "Risk score") {
+		t.Errorf("Expected no risk score without weights, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,125 @@
+package compare
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+// OtherSection is the section name for differences that match no configured section
+const OtherSection = "Other"
+
+// groupBySection buckets leaf diffs by the section whose glob patterns match
+// their top-level field. Sections are tried in name order; unmatched diffs go
+// to OtherSection.
+func groupBySection(diffs []*Diff, sections map[string][]string) map[string][]*Diff {
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make(map[string][]*Diff)
+	for _, d := range diffs {
+		topField := extractTopLevelField(d.Path)
+		section := OtherSection
+	match:
+		for _, name := range names {
+			for _, pattern := range sections[name] {
+				if config.MatchPath(pattern, topField) {
+					section = name
+					break match
+				}
+			}
+		}
+		result[section] = append(result[section], d)
+	}
+
+	return result
+}
+
+// sortedSectionNames returns section names alphabetically with OtherSection last
+func sortedSectionNames(grouped map[string][]*Diff) []string {
+	names := make([]string, 0, len(grouped))
+	for name := range grouped {
+		if name != OtherSection {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := grouped[OtherSection]; ok {
+		names = append(names, OtherSection)
+	}
+	return names
+}
+
+// PrintSectionedDiff prints differences grouped under section headings
+func PrintSectionedDiff(w io.Writer, diff *Diff, name1, name2 string, sections map[string][]string, opts OutputOptions) {
+	opts = opts.withColors()
+	fmt.Fprintf(w, "%s\n", opts.colors.bold(fmt.Sprintf("Comparing: %s <-> %s", name1, name2)))
+	fmt.Fprintln(w, strings.Repeat("-", 80))
+
+	diffs := GetAllDiffs(diff)
+	if len(diffs) == 0 {
+		fmt.Fprintf(w, "%s\n", opts.colors.green("✓ No differences found"))
+		return
+	}
+	printSideLegend(w, opts, name1, name2)
+
+	grouped := groupBySection(diffs, sections)
+	printSectionHeatmap(w, opts, grouped, len(diffs))
+	printRiskScore(w, opts, diff)
+	fmt.Fprintln(w)
+	rendered, hidden := opts.rendered(diffs)
+	for _, name := range sortedSectionNames(grouped) {
+		var shown []*Diff
+		for _, d := range grouped[name] {
+			if rendered[d] {
+				shown = append(shown, d)
+			}
+		}
+		if len(shown) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s\n", opts.colors.bold(fmt.Sprintf("%s (%d):", name, len(grouped[name]))))
+		fmt.Fprintln(w)
+		for _, d := range shown {
+			printDiffEntry(w, opts, d, d.Type)
+			fmt.Fprintln(w)
+		}
+	}
+	printMoreChanges(w, opts, hidden)
+}
+
+// heatmapWidth is the bar length of the section with the most differences
+const heatmapWidth = 20
+
+// printSectionHeatmap prints the difference count of each section with a bar
+// scaled to the largest section, so the most divergent sections stand out
+func printSectionHeatmap(w io.Writer, opts OutputOptions, grouped map[string][]*Diff, total int) {
+	names := sortedSectionNames(grouped)
+	maxCount, nameWidth := 0, 0
+	for _, name := range names {
+		maxCount = max(maxCount, len(grouped[name]))
+		nameWidth = max(nameWidth, len(name))
+	}
+
+	fmt.Fprintf(w, "\n%s\n", opts.colors.bold(fmt.Sprintf("Summary: %d difference(s) found", total)))
+	for _, name := range names {
+		count := len(grouped[name])
+		fmt.Fprintf(w, "  %-*s %4d %s\n", nameWidth, name, count, opts.colors.yellow(heatmapBar(count, maxCount)))
+	}
+}
+
+// heatmapBar returns a bar of '#' for count scaled so maxCount fills
+// heatmapWidth. Any non-zero count gets at least one '#'.
+func heatmapBar(count, maxCount int) string {
+	if count == 0 || maxCount == 0 {
+		return ""
+	}
+	return strings.Repeat("#", max(1, count*heatmapWidth/maxCount))
+}
@@ -0,0 +1,142 @@
+package compare
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGroupBySection(t *testing.T) {
+	sections := map[string][]string{
+		"Networking": {"networkInterfaces", "network*"},
+		"Disks":      {"disks"},
+	}
+
+	diffs := []*Diff{
+		{Path: "networkInterfaces[0].networkIP", Type: DiffTypeModified},
+		{Path: "networkTags", Type: DiffTypeAdded},
+		{Path: "disks[0].diskSizeGb", Type: DiffTypeModified},
+		{Path: "machineType", Type: DiffTypeModified},
+	}
+
+	grouped := groupBySection(diffs, sections)
+
+	if len(grouped["Networking"]) != 2 {
+		t.Errorf("Expected 2 networking diffs, got %d", len(grouped["Networking"]))
+	}
+	if len(grouped["Disks"]) != 1 || grouped["Disks"][0].Path != "disks[0].diskSizeGb" {
+		t.Errorf("Expected disks diff in Disks section, got %v", grouped["Disks"])
+	}
+	if len(grouped[OtherSection]) != 1 || grouped[OtherSection][0].Path != "machineType" {
+		t.Errorf("Expected unmatched diff in Other section, got %v", grouped[OtherSection])
+	}
+}
+
+func TestPrintSectionedDiff(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"disks": {
+				Path: "disks",
+				Type: DiffTypeModified,
+				Children: map[string]*Diff{
+					"[0]": {Path: "disks[0]", Type: DiffTypeAdded, Value2: "boot"},
+				},
+			},
+			"machineType": {Path: "machineType", Type: DiffTypeModified, Value1: "a", Value2: "b"},
+		},
+	}
+
+	var buf bytes.Buffer
+	PrintSectionedDiff(&buf, diff, "vm-1", "vm-2", map[string][]string{"Disks": {"disks"}}, OutputOptions{})
+	output := buf.String()
+
+	disksIdx := strings.Index(output, "Disks (1):")
+	otherIdx := strings.Index(output, "Other (1):")
+	if disksIdx == -1 || otherIdx == -1 {
+		t.Fatalf("Expected Disks and Other headings, got:\n%s", output)
+	}
+	if disksIdx > otherIdx {
+		t.Error("Expected Other section to be printed last")
+	}
+	if !strings.Contains(output[disksIdx:otherIdx], "disks[0]") {
+		t.Error("Expected disks[0] under the Disks heading")
+	}
+	if !strings.Contains(output[otherIdx:], "machineType") {
+		t.Error("Expected machineType under the Other heading")
+	}
+}
+
+func TestPrintSectionedDiff_SkipsHiddenSections(t *testing.T) {
+	diff := &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"disks": {
+				Path: "disks",
+				Type: DiffTypeModified,
+				Children: map[string]*Diff{
+					"[0]": {Path: "disks[0]", Type: DiffTypeAdded, Value2: "boot"},
+				},
+			},
+			"machineType": {Path: "machineType", Type: DiffTypeModified, Value1: "a", Value2: "b"},
+		},
+	}
+
+	var buf bytes.Buffer
+	opts := OutputOptions{Color: ColorNever, ShowTypes: []DiffType{DiffTypeModified}}
+	PrintSectionedDiff(&buf, diff, "vm-1", "vm-2", map[string][]string{"Disks": {"disks"}}, opts)
+	output := buf.String()
+
+	if strings.Contains(output, "Disks (1):") {
+		t.Errorf("Expected no heading for a section with no shown changes, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Other (1):") {
+		t.Errorf("Expected the Other heading, got:\n%s", output)
+	}
+}
+
+func TestPrintSectionedDiff_Heatmap(t *testing.T) {
+	children := map[string]*Diff{
+		"machineType": {Path: "machineType", Type: DiffTypeModified, Value1: "a", Value2: "b"},
+	}
+	for _, key := range []string{"a", "b", "c", "d"} {
+		path := "labels." + key
+		children["labels"+key] = &Diff{Path: path, Type: DiffTypeAdded, Value2: key}
+	}
+	for _, key := range []string{"[0]", "[1]"} {
+		children["disks"+key] = &Diff{Path: "disks" + key, Type: DiffTypeAdded, Value2: "disk"}
+	}
+	diff := &Diff{Type: DiffTypeModified, Children: children}
+	sections := map[string][]string{"Disks": {"disks"}, "Labels": {"labels"}}
+
+	var buf bytes.Buffer
+	PrintSectionedDiff(&buf, diff, "vm-1", "vm-2", sections, OutputOptions{Color: ColorNever})
+	output := buf.String()
+
+	if !strings.Contains(output, "Summary: 7 difference(s) found") {
+		t.Errorf("Expected a summary of all differences, got:\n%s", output)
+	}
+
+	bars := make(map[string]int)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && strings.HasPrefix(fields[2], "#") {
+			bars[fields[0]] = len(fields[2])
+		}
+	}
+	if len(bars) != 3 {
+		t.Fatalf("Expected a bar for each of 3 sections, got %v in:\n%s", bars, output)
+	}
+	if bars["Labels"] != heatmapWidth || bars["Labels"] <= bars["Disks"] || bars["Disks"] <= bars["Other"] {
+		t.Errorf("Expected bar lengths ordered by change count, got %v", bars)
+	}
+}
+
+func TestHeatmapBar(t *testing.T) {
+	if got := heatmapBar(1, 100); got != "#" {
+		t.Errorf("Expected a small non-zero count to get one '#', got %q", got)
+	}
+	if got := heatmapBar(0, 10); got != "" {
+		t.Errorf("Expected no bar for zero, got %q", got)
+	}
+}
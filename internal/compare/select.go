@@ -0,0 +1,119 @@
+package compare
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// Selection is a --select expression: a synthetic field computed from each
+// resource before diffing, e.g. diskCount=len(disks)
+type Selection struct {
+	// Field is the top-level key the computed value is stored under
+	Field string
+
+	// Func is the function applied to the value at Path: len, sum, keys, or
+	// empty to copy the value as is
+	Func string
+
+	// Path is the dotted path of the value the function reads
+	Path string
+}
+
+// selectFuncs are the functions a selection can apply
+var selectFuncs = map[string]func(value interface{}) (interface{}, error){
+	"len":  selectLen,
+	"sum":  selectSum,
+	"keys": selectKeys,
+}
+
+var selectionPattern = regexp.MustCompile(`^\s*([\w.-]+)\s*=\s*(?:(\w+)\(\s*([\w.-]+)\s*\)|([\w.-]+))\s*$`)
+
+// ParseSelection parses a "field=func(path)" or "field=path" expression
+func ParseSelection(expr string) (Selection, error) {
+	m := selectionPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return Selection{}, fmt.Errorf("invalid selection %q (expected field=func(path) or field=path)", expr)
+	}
+	if m[4] != "" {
+		return Selection{Field: m[1], Path: m[4]}, nil
+	}
+	if _, ok := selectFuncs[m[2]]; !ok {
+		return Selection{}, fmt.Errorf("unknown function %q in selection %q (expected len, sum or keys)", m[2], expr)
+	}
+	return Selection{Field: m[1], Func: m[2], Path: m[3]}, nil
+}
+
+// ApplySelections evaluates each selection against resource and stores the
+// results under their field names. A missing path evaluates like an empty
+// value, so len and sum give 0.
+func ApplySelections(resource map[string]interface{}, selections []Selection) error {
+	for _, sel := range selections {
+		value := fieldValue(resource, sel.Path)
+		if sel.Func != "" {
+			computed, err := selectFuncs[sel.Func](value)
+			if err != nil {
+				return fmt.Errorf("select %s: %s(%s): %w", sel.Field, sel.Func, sel.Path, err)
+			}
+			value = computed
+		}
+		resource[sel.Field] = value
+	}
+	return nil
+}
+
+func selectLen(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return float64(0), nil
+	case []interface{}:
+		return float64(len(v)), nil
+	case map[string]interface{}:
+		return float64(len(v)), nil
+	case string:
+		return float64(utf8.RuneCountInString(v)), nil
+	default:
+		return nil, fmt.Errorf("expected an array, object or string, got %T", value)
+	}
+}
+
+func selectSum(value interface{}) (interface{}, error) {
+	if value == nil {
+		return float64(0), nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array, got %T", value)
+	}
+	var total float64
+	for i, item := range items {
+		n, ok := toFloat64(item)
+		if !ok {
+			return nil, fmt.Errorf("element %d is not a number", i)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func selectKeys(value interface{}) (interface{}, error) {
+	if value == nil {
+		return []interface{}{}, nil
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %T", value)
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]interface{}, len(keys))
+	for i, key := range keys {
+		result[i] = key
+	}
+	return result, nil
+}
@@ -0,0 +1,133 @@
+package compare
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    Selection
+		wantErr string
+	}{
+		{expr: "diskCount=len(disks)", want: Selection{Field: "diskCount", Func: "len", Path: "disks"}},
+		{expr: " labelKeys = keys(labels) ", want: Selection{Field: "labelKeys", Func: "keys", Path: "labels"}},
+		{expr: "tier=settings.tier", want: Selection{Field: "tier", Path: "settings.tier"}},
+		{expr: "diskCount", wantErr: "invalid selection"},
+		{expr: "x=max(disks)", wantErr: `unknown function "max"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ParseSelection(tt.expr)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSelection failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSelection() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplySelections_LenOverArrays(t *testing.T) {
+	sel, _ := ParseSelection("diskCount=len(disks)")
+	vm1 := map[string]interface{}{"disks": []interface{}{
+		map[string]interface{}{"deviceName": "boot"},
+	}}
+	vm2 := map[string]interface{}{"disks": []interface{}{
+		map[string]interface{}{"deviceName": "boot"},
+		map[string]interface{}{"deviceName": "data"},
+	}}
+	for _, vm := range []map[string]interface{}{vm1, vm2} {
+		if err := ApplySelections(vm, []Selection{sel}); err != nil {
+			t.Fatalf("ApplySelections failed: %v", err)
+		}
+	}
+
+	cfg := config.Default()
+	cfg.OnlyFields = []string{"diskCount"}
+	diffs := GetAllDiffs(NewDiffer(cfg, false).Compare(vm1, vm2))
+
+	if len(diffs) != 1 || diffs[0].Path != "diskCount" || diffs[0].Value1 != float64(1) || diffs[0].Value2 != float64(2) {
+		t.Errorf("Expected diskCount to change from 1 to 2, got %v", diffs)
+	}
+}
+
+func TestApplySelections_KeysOverMaps(t *testing.T) {
+	sel, _ := ParseSelection("labelKeys=keys(labels)")
+	bucket1 := map[string]interface{}{"labels": map[string]interface{}{"team": "web", "env": "dev"}}
+	bucket2 := map[string]interface{}{"labels": map[string]interface{}{"env": "prod", "team": "api"}}
+	for _, bucket := range []map[string]interface{}{bucket1, bucket2} {
+		if err := ApplySelections(bucket, []Selection{sel}); err != nil {
+			t.Fatalf("ApplySelections failed: %v", err)
+		}
+	}
+
+	want := []interface{}{"env", "team"}
+	if !reflect.DeepEqual(bucket1["labelKeys"], want) {
+		t.Errorf("Expected sorted keys %v, got %v", want, bucket1["labelKeys"])
+	}
+
+	// Same label keys with different values compare equal
+	cfg := config.Default()
+	cfg.OnlyFields = []string{"labelKeys"}
+	if diff := NewDiffer(cfg, false).Compare(bucket1, bucket2); !diff.IsEmpty() {
+		t.Errorf("Expected labelKeys to be equal, got %v", GetAllDiffs(diff))
+	}
+}
+
+func TestApplySelections_Sum(t *testing.T) {
+	resource := map[string]interface{}{"sizes": []interface{}{float64(10), 20, float64(30)}}
+	sel, _ := ParseSelection("totalSize=sum(sizes)")
+
+	if err := ApplySelections(resource, []Selection{sel}); err != nil {
+		t.Fatalf("ApplySelections failed: %v", err)
+	}
+	if resource["totalSize"] != float64(60) {
+		t.Errorf("Expected totalSize 60, got %v", resource["totalSize"])
+	}
+
+	bad := map[string]interface{}{"sizes": []interface{}{"ten"}}
+	if err := ApplySelections(bad, []Selection{sel}); err == nil || !strings.Contains(err.Error(), "not a number") {
+		t.Errorf("Expected an error summing non-numbers, got %v", err)
+	}
+}
+
+func TestApplySelections_MissingPath(t *testing.T) {
+	resource := map[string]interface{}{}
+	sel, _ := ParseSelection("diskCount=len(disks)")
+
+	if err := ApplySelections(resource, []Selection{sel}); err != nil {
+		t.Fatalf("ApplySelections failed: %v", err)
+	}
+	if resource["diskCount"] != float64(0) {
+		t.Errorf("Expected a missing array to have length 0, got %v", resource["diskCount"])
+	}
+}
+
+func TestApplySelections_QuotedKey(t *testing.T) {
+	resource := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{"app.kubernetes.io/team": "web"},
+		},
+	}
+	sel := Selection{Field: "team", Path: `metadata.annotations."app.kubernetes.io/team"`}
+
+	if err := ApplySelections(resource, []Selection{sel}); err != nil {
+		t.Fatalf("ApplySelections failed: %v", err)
+	}
+	if resource["team"] != "web" {
+		t.Errorf("Expected the dotted annotation key to be looked up, got %v", resource["team"])
+	}
+}
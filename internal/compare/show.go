@@ -0,0 +1,86 @@
+package compare
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseChangeTypes parses the change kinds named by --show, e.g. "added" and
+// "modified"
+func ParseChangeTypes(names []string) ([]DiffType, error) {
+	types := make([]DiffType, 0, len(names))
+	for _, name := range names {
+		switch t := DiffType(strings.ToLower(strings.TrimSpace(name))); t {
+		case DiffTypeAdded, DiffTypeRemoved, DiffTypeModified:
+			types = append(types, t)
+		default:
+			return nil, fmt.Errorf("unknown change type %q (expected: added, removed, modified)", name)
+		}
+	}
+	return types, nil
+}
+
+// shows reports whether changes of diffType are rendered
+func (o OutputOptions) shows(diffType DiffType) bool {
+	if len(o.ShowTypes) == 0 {
+		return true
+	}
+	for _, t := range o.ShowTypes {
+		if t == diffType {
+			return true
+		}
+	}
+	return false
+}
+
+// rendered returns the leaf changes among diffs that are rendered: those
+// opts shows, at most opts.Limit of them in order. It also returns how many
+// shown changes the limit leaves out.
+func (o OutputOptions) rendered(diffs []*Diff) (map[*Diff]bool, int) {
+	result := make(map[*Diff]bool, len(diffs))
+	hidden := 0
+	for _, d := range diffs {
+		if !o.shows(d.Type) {
+			continue
+		}
+		if o.Limit > 0 && len(result) >= o.Limit {
+			hidden++
+			continue
+		}
+		result[d] = true
+	}
+	return result, hidden
+}
+
+// printMoreChanges prints how many changes the limit left out, if any
+func printMoreChanges(w io.Writer, opts OutputOptions, hidden int) {
+	if hidden > 0 {
+		fmt.Fprintf(w, "%s\n", opts.colors.gray(fmt.Sprintf("… and %d more differences", hidden)))
+	}
+}
+
+// printShowSummary prints the counts of every change kind when opts hides
+// some of them, so the filtered output still accounts for the full diff
+func printShowSummary(w io.Writer, opts OutputOptions, diff *Diff) {
+	var added, removed, modified int
+	for _, d := range GetAllDiffs(diff) {
+		switch d.Type {
+		case DiffTypeAdded:
+			added++
+		case DiffTypeRemoved:
+			removed++
+		case DiffTypeModified:
+			modified++
+		}
+	}
+
+	shown := make([]string, len(opts.ShowTypes))
+	for i, t := range opts.ShowTypes {
+		shown[i] = string(t)
+	}
+	fmt.Fprintf(w, "\n%s\n", opts.colors.bold(fmt.Sprintf("Summary: %d difference(s) found", added+removed+modified)))
+	fmt.Fprintf(w, "  %s %d  %s %d  %s %d  %s\n",
+		opts.colors.green("+"), added, opts.colors.red("-"), removed, opts.colors.yellow("~"), modified,
+		opts.colors.gray("(showing "+strings.Join(shown, ", ")+")"))
+}
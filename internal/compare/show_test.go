@@ -0,0 +1,117 @@
+package compare
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+func showTestDiff() *Diff {
+	obj1 := map[string]interface{}{
+		"machineType": "n1",
+		"labels":      map[string]interface{}{"env": "dev", "team": "web"},
+		"tags":        []interface{}{"a", "b", "c"},
+	}
+	obj2 := map[string]interface{}{
+		"machineType": "n2",
+		"labels":      map[string]interface{}{"env": "dev", "owner": "ops"},
+		"tags":        []interface{}{"a", "x", "c", "d"},
+	}
+	return NewDiffer(config.Default(), false).Compare(obj1, obj2)
+}
+
+func TestParseChangeTypes(t *testing.T) {
+	types, err := ParseChangeTypes([]string{"added", " Removed"})
+	if err != nil || len(types) != 2 || types[0] != DiffTypeAdded || types[1] != DiffTypeRemoved {
+		t.Errorf("Unexpected result %v, %v", types, err)
+	}
+	if _, err := ParseChangeTypes([]string{"equal"}); err == nil {
+		t.Error("Expected an error for an unknown change type")
+	}
+}
+
+func TestPrintGitStyleDiffV2_ShowTypes(t *testing.T) {
+	var buf bytes.Buffer
+	opts := OutputOptions{Color: ColorNever, ShowTypes: []DiffType{DiffTypeAdded}, CompactArrays: true}
+	PrintGitStyleDiffV2WithOptions(&buf, showTestDiff(), "a", "b", opts)
+	output := buf.String()
+
+	for _, want := range []string{
+		"Summary: 5 difference(s) found\n  + 2  - 1  ~ 2  (showing added)",
+		"owner",
+		"+ [3]",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output:\n%s", want, output)
+		}
+	}
+	for _, hidden := range []string{"team", "machineType", "[1]", "unchanged element"} {
+		if strings.Contains(output, hidden) {
+			t.Errorf("Expected %q to be hidden:\n%s", hidden, output)
+		}
+	}
+}
+
+func TestPrintGitStyleDiff_ShowTypes(t *testing.T) {
+	var buf bytes.Buffer
+	opts := OutputOptions{Color: ColorNever, ShowTypes: []DiffType{DiffTypeRemoved, DiffTypeModified}}
+	PrintGitStyleDiffWithOptions(&buf, showTestDiff(), "a", "b", opts)
+	output := buf.String()
+
+	if !strings.Contains(output, "Summary: 5 difference(s) found\n  + 2 field(s)\n  - 1 field(s)\n  ~ 2 field(s)") {
+		t.Errorf("Expected the summary to count every change:\n%s", output)
+	}
+	if strings.Contains(output, "Only in b") || !strings.Contains(output, "Only in a") || !strings.Contains(output, "Different") {
+		t.Errorf("Expected only removed and modified sections:\n%s", output)
+	}
+}
+
+func TestPrintGitStyleDiffV2_ShowTypesNoneMatch(t *testing.T) {
+	diff := NewDiffer(config.Default(), false).Compare(
+		map[string]interface{}{"a": "x"}, map[string]interface{}{"a": "y"})
+
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2WithOptions(&buf, diff, "a", "b", OutputOptions{Color: ColorNever, ShowTypes: []DiffType{DiffTypeRemoved}})
+	output := buf.String()
+
+	if !strings.Contains(output, "~ 1  (showing removed)") || strings.Contains(output, "No differences") {
+		t.Errorf("Expected only the summary, got:\n%s", output)
+	}
+}
+
+func TestPrintGitStyleDiffV2_Limit(t *testing.T) {
+	var buf bytes.Buffer
+	PrintGitStyleDiffV2WithOptions(&buf, showTestDiff(), "a", "b", OutputOptions{Color: ColorNever, Limit: 2})
+	output := buf.String()
+
+	// GetAllDiffs order: labels.owner, labels.team, machineType, tags[1], tags[3]
+	for _, want := range []string{"owner", "team", "… and 3 more differences\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "machineType") || strings.Contains(output, "tags") {
+		t.Errorf("Expected changes past the limit to be left out:\n%s", output)
+	}
+
+	buf.Reset()
+	PrintGitStyleDiffV2WithOptions(&buf, showTestDiff(), "a", "b", OutputOptions{Color: ColorNever, Limit: 5})
+	if strings.Contains(buf.String(), "more differences") {
+		t.Errorf("Expected no truncation at the exact count:\n%s", buf.String())
+	}
+}
+
+func TestPrintGitStyleDiff_Limit(t *testing.T) {
+	var buf bytes.Buffer
+	PrintGitStyleDiffWithOptions(&buf, showTestDiff(), "a", "b", OutputOptions{Color: ColorNever, Limit: 3})
+	output := buf.String()
+
+	if !strings.Contains(output, "Summary: 5 difference(s) found") {
+		t.Errorf("Expected the summary to count every change:\n%s", output)
+	}
+	if !strings.Contains(output, "machineType") || strings.Contains(output, "tags[1]") || !strings.HasSuffix(output, "… and 2 more differences\n") {
+		t.Errorf("Expected the first 3 changes and a count of the rest:\n%s", output)
+	}
+}
@@ -0,0 +1,89 @@
+package compare
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteTerraformPlan writes a diff in the style of `terraform plan` output:
+// an update block for the first resource with +/-/~ attribute lines and
+// nested blocks for changed objects and lists
+func WriteTerraformPlan(w io.Writer, diff *Diff, name1, name2 string) {
+	WriteTerraformPlanWithOptions(w, diff, name1, name2, OutputOptions{})
+}
+
+// WriteTerraformPlanWithOptions writes a plan like WriteTerraformPlan,
+// colorized according to opts
+func WriteTerraformPlanWithOptions(w io.Writer, diff *Diff, name1, name2 string, opts OutputOptions) {
+	colors := opts.withColors().colors
+	fmt.Fprintf(w, "  # %s will be updated in-place to match %s\n", name1, name2)
+	if diff.Type == DiffTypeEqual {
+		fmt.Fprintln(w, "No changes. Both resources match.")
+		return
+	}
+
+	fmt.Fprintf(w, "  %s update %q {\n", colors.yellow("~"), name1)
+	writePlanChildren(w, colors, diff, "      ")
+	fmt.Fprintln(w, "    }")
+}
+
+func writePlanChildren(w io.Writer, colors *renderer, diff *Diff, indent string) {
+	for _, key := range getSortedKeys(diff.Children) {
+		writePlanAttribute(w, colors, key, diff.Children[key], indent)
+	}
+}
+
+func writePlanAttribute(w io.Writer, colors *renderer, key string, diff *Diff, indent string) {
+	switch diff.Type {
+	case DiffTypeAdded:
+		fmt.Fprintf(w, "%s%s %s = %s\n", indent, colors.green("+"), key, hclValue(diff.Value2))
+	case DiffTypeRemoved:
+		fmt.Fprintf(w, "%s%s %s = %s -> null\n", indent, colors.red("-"), key, hclValue(diff.Value1))
+	case DiffTypeModified:
+		if len(diff.Children) > 0 {
+			fmt.Fprintf(w, "%s%s %s {\n", indent, colors.yellow("~"), key)
+			writePlanChildren(w, colors, diff, indent+"    ")
+			fmt.Fprintf(w, "%s  }\n", indent)
+			return
+		}
+		fmt.Fprintf(w, "%s%s %s = %s -> %s", indent, colors.yellow("~"), key, hclValue(diff.Value1), hclValue(diff.Value2))
+		if diff.Note != "" {
+			fmt.Fprintf(w, " %s", colors.gray("# "+diff.Note))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// hclValue renders a value as an HCL literal
+func hclValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("%q", value)
+	case []interface{}:
+		elements := make([]string, len(value))
+		for i, element := range value {
+			elements[i] = hclValue(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case map[string]interface{}:
+		if len(value) == 0 {
+			return "{}"
+		}
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		attributes := make([]string, len(keys))
+		for i, k := range keys {
+			attributes[i] = fmt.Sprintf("%s = %s", k, hclValue(value[k]))
+		}
+		return "{ " + strings.Join(attributes, ", ") + " }"
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
@@ -0,0 +1,106 @@
+package compare
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteTerraformPlan_ModifiedAttribute(t *testing.T) {
+	diff := &Diff{
+		Path: "",
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"machineType": {
+				Path:   "machineType",
+				Type:   DiffTypeModified,
+				Value1: "n1-standard-2",
+				Value2: "n1-standard-4",
+			},
+			"deletionProtection": {
+				Path:   "deletionProtection",
+				Type:   DiffTypeAdded,
+				Value2: true,
+			},
+			"description": {
+				Path:   "description",
+				Type:   DiffTypeRemoved,
+				Value1: "web server",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	WriteTerraformPlan(&buf, diff, "instance-1", "instance-2")
+	output := buf.String()
+
+	expected := []string{
+		`  ~ update "instance-1" {` + "\n",
+		`      ~ machineType = "n1-standard-2" -> "n1-standard-4"` + "\n",
+		`      + deletionProtection = true` + "\n",
+		`      - description = "web server" -> null` + "\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line) {
+			t.Errorf("Expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+	if !strings.HasSuffix(output, "    }\n") {
+		t.Errorf("Expected resource block to be closed, got:\n%s", output)
+	}
+}
+
+func TestWriteTerraformPlan_NestedBlocks(t *testing.T) {
+	diff := &Diff{
+		Path: "",
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"scheduling": {
+				Path: "scheduling",
+				Type: DiffTypeModified,
+				Children: map[string]*Diff{
+					"preemptible": {
+						Path:   "scheduling.preemptible",
+						Type:   DiffTypeModified,
+						Value1: false,
+						Value2: true,
+					},
+				},
+			},
+			"tags": {
+				Path: "tags",
+				Type: DiffTypeModified,
+				Children: map[string]*Diff{
+					"[1]": {
+						Path:   "tags[1]",
+						Type:   DiffTypeAdded,
+						Value2: map[string]interface{}{"name": "https"},
+					},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	WriteTerraformPlan(&buf, diff, "instance-1", "instance-2")
+	output := buf.String()
+
+	expected := "      ~ scheduling {\n" +
+		"          ~ preemptible = false -> true\n" +
+		"        }\n" +
+		"      ~ tags {\n" +
+		"          + [1] = { name = \"https\" }\n" +
+		"        }\n"
+	if !strings.Contains(output, expected) {
+		t.Errorf("Expected nested blocks:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestWriteTerraformPlan_NoChanges(t *testing.T) {
+	var buf bytes.Buffer
+	WriteTerraformPlan(&buf, &Diff{Type: DiffTypeEqual}, "instance-1", "instance-2")
+
+	if !strings.Contains(buf.String(), "No changes.") {
+		t.Errorf("Expected no-changes message, got:\n%s", buf.String())
+	}
+}
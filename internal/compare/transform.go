@@ -0,0 +1,85 @@
+package compare
+
+import (
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// transformFunc normalizes a string value before it is compared
+type transformFunc func(string) string
+
+// transformRegistry holds the named transforms the transforms config can
+// apply. A transform that can't handle a value returns it unchanged.
+var transformRegistry = map[string]transformFunc{
+	"trim":  strings.TrimSpace,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	// basename keeps the last segment of a URL or resource path, e.g.
+	// zones/us-central1-a/machineTypes/n1-standard-2 becomes n1-standard-2
+	"basename": func(s string) string {
+		s = strings.TrimRight(s, "/")
+		return s[strings.LastIndex(s, "/")+1:]
+	},
+	"base64": func(s string) string {
+		if data, err := base64.StdEncoding.DecodeString(s); err == nil {
+			return string(data)
+		}
+		return s
+	},
+	"url": func(s string) string {
+		if u, ok := canonicalURL(s); ok {
+			return u
+		}
+		return s
+	},
+}
+
+// TransformNames returns the names of the built-in transforms in sorted
+// order
+func TransformNames() []string {
+	names := make([]string, 0, len(transformRegistry))
+	for name := range transformRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckTransforms reports the first transform in the transforms config that
+// is not a built-in
+func CheckTransforms(transforms map[string][]string) error {
+	for _, pattern := range slices.Sorted(maps.Keys(transforms)) {
+		for _, name := range transforms[pattern] {
+			if _, ok := transformRegistry[name]; !ok {
+				return fmt.Errorf("unknown transform %q for %s (expected one of: %s)",
+					name, pattern, strings.Join(TransformNames(), ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// transformsFor returns the transforms configured for path
+func (d *Differ) transformsFor(path string) []string {
+	names, _ := firstMatch(d.config.Transforms, path, fieldMatches)
+	return names
+}
+
+// applyTransforms runs the named transforms over a string value in order.
+// It fails for values that aren't strings.
+func applyTransforms(v interface{}, names []string) (string, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return "", false
+	}
+	for _, name := range names {
+		if transform, ok := transformRegistry[name]; ok {
+			s = transform(s)
+		}
+	}
+	return s, true
+}
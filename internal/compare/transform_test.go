@@ -0,0 +1,78 @@
+package compare
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tflynn3/gcdiff/internal/config"
+)
+
+func TestCompare_Transforms(t *testing.T) {
+	cfg := config.Default()
+	cfg.Transforms = map[string][]string{"machineType": {"basename", "lower"}}
+	d := NewDiffer(cfg, false)
+
+	obj1 := map[string]interface{}{
+		"machineType": "https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-a/machineTypes/N1-STANDARD-2",
+		"description": "Web",
+	}
+	obj2 := map[string]interface{}{
+		"machineType": "n1-standard-2",
+		"description": "web",
+	}
+	diffs := GetAllDiffs(d.Compare(obj1, obj2))
+	if len(diffs) != 1 || diffs[0].Path != "description" {
+		t.Errorf("Expected only the untransformed description to differ, got %+v", diffs)
+	}
+
+	// Transformed values that still differ are reported as they are
+	diffs = GetAllDiffs(d.Compare(obj1, map[string]interface{}{"machineType": "n1-standard-4", "description": "Web"}))
+	if len(diffs) != 1 || diffs[0].Value2 != "n1-standard-4" || !strings.HasSuffix(diffs[0].Value1.(string), "/N1-STANDARD-2") {
+		t.Errorf("Expected machineType to differ with its original values, got %+v", diffs)
+	}
+
+	// The order of transforms matters: lower first leaves the path in place
+	cfg.Transforms = map[string][]string{"machineType": {"lower"}}
+	if diff := NewDiffer(cfg, false).Compare(obj1, obj2); len(GetAllDiffs(diff)) != 2 {
+		t.Errorf("Expected lower alone not to strip the path, got %+v", GetAllDiffs(diff))
+	}
+}
+
+func TestCompare_TransformsLeaveOtherTypes(t *testing.T) {
+	cfg := config.Default()
+	cfg.Transforms = map[string][]string{"size": {"trim"}}
+	d := NewDiffer(cfg, false)
+
+	if diff := d.Compare(map[string]interface{}{"size": 10}, map[string]interface{}{"size": 10.0}); !diff.IsEmpty() {
+		t.Errorf("Expected numbers to be compared as usual, got %+v", GetAllDiffs(diff))
+	}
+}
+
+func TestTransformRegistry(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"trim", "  10.0.0.1\n", "10.0.0.1"},
+		{"upper", "running", "RUNNING"},
+		{"basename", "projects/p/global/networks/default/", "default"},
+		{"basename", "default", "default"},
+		{"base64", "aGVsbG8=", "hello"},
+		{"base64", "not base64!", "not base64!"},
+		{"url", "HTTP://Example.com/a/", "https://example.com/a"},
+	}
+	for _, tt := range tests {
+		if got := transformRegistry[tt.name](tt.in); got != tt.want {
+			t.Errorf("%s(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCheckTransforms(t *testing.T) {
+	if err := CheckTransforms(map[string][]string{"natIP": {"trim", "lower"}}); err != nil {
+		t.Errorf("Expected built-in transforms to be accepted, got %v", err)
+	}
+	err := CheckTransforms(map[string][]string{"natIP": {"trim", "reverse"}})
+	if err == nil || !strings.Contains(err.Error(), `unknown transform "reverse" for natIP`) {
+		t.Errorf("Expected an unknown transform error, got %v", err)
+	}
+}
@@ -0,0 +1,294 @@
+package compare
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TreeNode is one path of a diff shown in the interactive tree browser
+type TreeNode struct {
+	// Key is the node's name under its parent, e.g. "disks" or "[0]"
+	Key  string
+	Diff *Diff
+
+	Parent   *TreeNode
+	Children []*TreeNode
+
+	// Expanded shows the node's children
+	Expanded bool
+
+	// Depth is the distance from the root, which is depth 0
+	Depth int
+}
+
+// BuildTree converts diff into a tree of nodes with children in path order.
+// Only the root starts out expanded.
+func BuildTree(diff *Diff) *TreeNode {
+	root := &TreeNode{Diff: diff, Expanded: true}
+	addTreeChildren(root)
+	return root
+}
+
+func addTreeChildren(node *TreeNode) {
+	for _, key := range getSortedKeys(node.Diff.Children) {
+		child := &TreeNode{Key: key, Diff: node.Diff.Children[key], Parent: node, Depth: node.Depth + 1}
+		addTreeChildren(child)
+		node.Children = append(node.Children, child)
+	}
+}
+
+// IsChange reports whether the node is a leaf difference, the stops of
+// NextChange and PrevChange
+func (n *TreeNode) IsChange() bool {
+	return len(n.Children) == 0 && n.Diff.Type != DiffTypeEqual
+}
+
+// walk returns n and its descendants in display order
+func (n *TreeNode) walk() []*TreeNode {
+	nodes := []*TreeNode{n}
+	for _, child := range n.Children {
+		nodes = append(nodes, child.walk()...)
+	}
+	return nodes
+}
+
+// TreeView is the state of the interactive tree browser: the diff tree with
+// its expanded nodes, the selected row and the scroll offset
+type TreeView struct {
+	root   *TreeNode
+	rows   []*TreeNode
+	cursor int
+	offset int
+}
+
+// NewTreeView creates a view of diff with its top-level fields listed and
+// the first one selected
+func NewTreeView(diff *Diff) *TreeView {
+	v := &TreeView{root: BuildTree(diff)}
+	v.refresh()
+	return v
+}
+
+// refresh recomputes the visible rows after nodes are expanded or collapsed,
+// keeping the cursor in range
+func (v *TreeView) refresh() {
+	v.rows = v.rows[:0]
+	var visit func(n *TreeNode)
+	visit = func(n *TreeNode) {
+		for _, child := range n.Children {
+			v.rows = append(v.rows, child)
+			if child.Expanded {
+				visit(child)
+			}
+		}
+	}
+	visit(v.root)
+	v.cursor = max(0, min(v.cursor, len(v.rows)-1))
+}
+
+// Rows returns the visible nodes, excluding the root
+func (v *TreeView) Rows() []*TreeNode {
+	return v.rows
+}
+
+// Selected returns the node under the cursor, or nil when the tree is empty
+func (v *TreeView) Selected() *TreeNode {
+	if len(v.rows) == 0 {
+		return nil
+	}
+	return v.rows[v.cursor]
+}
+
+// Move moves the cursor by delta rows, stopping at the first and last row
+func (v *TreeView) Move(delta int) {
+	v.cursor = max(0, min(v.cursor+delta, len(v.rows)-1))
+}
+
+// Toggle expands or collapses the selected node
+func (v *TreeView) Toggle() {
+	if n := v.Selected(); n != nil && len(n.Children) > 0 {
+		n.Expanded = !n.Expanded
+		v.refresh()
+	}
+}
+
+// Expand expands the selected node, or selects its first child if it is
+// already expanded
+func (v *TreeView) Expand() {
+	n := v.Selected()
+	if n == nil || len(n.Children) == 0 {
+		return
+	}
+	if n.Expanded {
+		v.Move(1)
+		return
+	}
+	n.Expanded = true
+	v.refresh()
+}
+
+// Collapse collapses the selected node, or selects its parent if it is a
+// leaf or already collapsed
+func (v *TreeView) Collapse() {
+	n := v.Selected()
+	if n == nil {
+		return
+	}
+	if n.Expanded {
+		n.Expanded = false
+		v.refresh()
+		return
+	}
+	if n.Parent != v.root {
+		v.selectNode(n.Parent)
+	}
+}
+
+// SetExpanded expands or collapses every node
+func (v *TreeView) SetExpanded(expanded bool) {
+	selected := v.Selected()
+	for _, n := range v.root.walk()[1:] {
+		n.Expanded = expanded && len(n.Children) > 0
+	}
+	v.refresh()
+	if selected != nil {
+		// Keep the selection on the nearest node still visible
+		for selected.Parent != v.root && !selected.Parent.Expanded {
+			selected = selected.Parent
+		}
+		v.selectNode(selected)
+	}
+}
+
+// NextChange selects the next leaf difference after the cursor, expanding
+// its parents. It reports false when there is none.
+func (v *TreeView) NextChange() bool {
+	return v.jumpToChange(1)
+}
+
+// PrevChange selects the leaf difference before the cursor, expanding its
+// parents. It reports false when there is none.
+func (v *TreeView) PrevChange() bool {
+	return v.jumpToChange(-1)
+}
+
+func (v *TreeView) jumpToChange(step int) bool {
+	nodes := v.root.walk()[1:]
+	start := -1
+	if step < 0 {
+		start = len(nodes)
+	}
+	if selected := v.Selected(); selected != nil {
+		for i, n := range nodes {
+			if n == selected {
+				start = i
+			}
+		}
+	}
+
+	for i := start + step; i >= 0 && i < len(nodes); i += step {
+		if nodes[i].IsChange() {
+			v.selectNode(nodes[i])
+			return true
+		}
+	}
+	return false
+}
+
+// selectNode moves the cursor to n, expanding its parents so it is visible
+func (v *TreeView) selectNode(n *TreeNode) {
+	for p := n.Parent; p != nil; p = p.Parent {
+		p.Expanded = true
+	}
+	v.refresh()
+	for i, row := range v.rows {
+		if row == n {
+			v.cursor = i
+		}
+	}
+}
+
+// Render returns the lines of the rows that fit in height lines, scrolled so
+// the cursor is visible, with the selected row highlighted. Lines are cut to
+// width columns when width is positive.
+func (v *TreeView) Render(width, height int, opts OutputOptions) []string {
+	opts = opts.withColors()
+	if len(v.rows) == 0 {
+		return []string{opts.colors.green("✓ No differences found")}
+	}
+
+	height = max(1, height)
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+height {
+		v.offset = v.cursor - height + 1
+	}
+	v.offset = max(0, min(v.offset, len(v.rows)-height))
+
+	end := min(len(v.rows), v.offset+height)
+	lines := make([]string, 0, end-v.offset)
+	for i := v.offset; i < end; i++ {
+		line := treeRowText(v.rows[i])
+		if width > 0 {
+			line = truncateRunes(line, width)
+		}
+		if i == v.cursor {
+			line = "\x1b[7m" + line + "\x1b[0m"
+		} else {
+			line = treeRowColor(opts.colors, v.rows[i].Diff.Type)(line)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// treeRowText renders a node as an indented line: an expand marker for
+// nodes with children, the change marker, the key and either the change's
+// values or the number of changes it holds
+func treeRowText(n *TreeNode) string {
+	marker := "  "
+	if len(n.Children) > 0 {
+		marker = "▸ "
+		if n.Expanded {
+			marker = "▾ "
+		}
+	}
+
+	sign := map[DiffType]string{DiffTypeAdded: "+", DiffTypeRemoved: "-", DiffTypeModified: "~", DiffTypeEqual: " "}[n.Diff.Type]
+	text := strings.Repeat("  ", n.Depth-1) + marker + sign + " " + n.Key
+	if len(n.Children) > 0 {
+		return text + fmt.Sprintf(" (%d change(s))", n.Diff.Count())
+	}
+
+	switch n.Diff.Type {
+	case DiffTypeAdded:
+		return text + ": " + dotValue(n.Diff.Value2)
+	case DiffTypeRemoved, DiffTypeEqual:
+		return text + ": " + dotValue(n.Diff.Value1)
+	default:
+		return text + ": " + dotLeafValues(n.Diff)
+	}
+}
+
+func treeRowColor(colors *renderer, diffType DiffType) func(...interface{}) string {
+	switch diffType {
+	case DiffTypeAdded:
+		return colors.green
+	case DiffTypeRemoved:
+		return colors.red
+	case DiffTypeModified:
+		return colors.yellow
+	default:
+		return colors.gray
+	}
+}
+
+// truncateRunes cuts s to at most width runes, marking the cut with "…"
+func truncateRunes(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
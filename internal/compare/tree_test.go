@@ -0,0 +1,155 @@
+package compare
+
+import (
+	"strings"
+	"testing"
+)
+
+func treeTestDiff() *Diff {
+	return &Diff{
+		Type: DiffTypeModified,
+		Children: map[string]*Diff{
+			"machineType": {Path: "machineType", Type: DiffTypeModified, Value1: "n1", Value2: "n2"},
+			"disks": {
+				Path: "disks",
+				Type: DiffTypeModified,
+				Children: map[string]*Diff{
+					"[10]": {Path: "disks[10]", Type: DiffTypeAdded, Value2: "d10"},
+					"[2]": {
+						Path: "disks[2]",
+						Type: DiffTypeModified,
+						Children: map[string]*Diff{
+							"type": {Path: "disks[2].type", Type: DiffTypeModified, Value1: "pd-standard", Value2: "pd-ssd"},
+						},
+					},
+				},
+			},
+			"labels": {
+				Path: "labels",
+				Type: DiffTypeModified,
+				Children: map[string]*Diff{
+					"env": {Path: "labels.env", Type: DiffTypeRemoved, Value1: "dev"},
+				},
+			},
+		},
+	}
+}
+
+// rowKeys returns the keys of the visible rows
+func rowKeys(v *TreeView) []string {
+	var keys []string
+	for _, n := range v.Rows() {
+		keys = append(keys, n.Key)
+	}
+	return keys
+}
+
+func TestBuildTree(t *testing.T) {
+	root := BuildTree(treeTestDiff())
+	if !root.Expanded || len(root.Children) != 3 {
+		t.Fatalf("Expected an expanded root with 3 children, got %+v", root)
+	}
+
+	disks := root.Children[0]
+	if disks.Key != "disks" || disks.Expanded || disks.Depth != 1 {
+		t.Errorf("Expected collapsed disks at depth 1, got %+v", disks)
+	}
+	if disks.Children[0].Key != "[2]" || disks.Children[1].Key != "[10]" {
+		t.Errorf("Expected array children in index order, got %s, %s", disks.Children[0].Key, disks.Children[1].Key)
+	}
+
+	diskType := disks.Children[0].Children[0]
+	if diskType.Parent != disks.Children[0] || diskType.Depth != 3 || !diskType.IsChange() {
+		t.Errorf("Unexpected leaf node %+v", diskType)
+	}
+	if disks.IsChange() {
+		t.Error("Expected a node with children not to be a change")
+	}
+}
+
+func TestTreeView_ExpandCollapse(t *testing.T) {
+	v := NewTreeView(treeTestDiff())
+	if got := strings.Join(rowKeys(v), ","); got != "disks,labels,machineType" {
+		t.Fatalf("Expected top-level rows, got %s", got)
+	}
+
+	v.Expand()
+	if got := strings.Join(rowKeys(v), ","); got != "disks,[2],[10],labels,machineType" {
+		t.Errorf("Expected disks expanded, got %s", got)
+	}
+
+	// Expanding an expanded node moves into it; collapsing a leaf selects
+	// its parent
+	v.Expand()
+	if v.Selected().Key != "[2]" {
+		t.Errorf("Expected [2] selected, got %s", v.Selected().Key)
+	}
+	v.Collapse()
+	if v.Selected().Key != "disks" {
+		t.Errorf("Expected disks selected, got %s", v.Selected().Key)
+	}
+	v.Collapse()
+	if len(v.Rows()) != 3 {
+		t.Errorf("Expected disks collapsed, got %v", rowKeys(v))
+	}
+
+	v.SetExpanded(true)
+	if len(v.Rows()) != 7 {
+		t.Errorf("Expected all 7 nodes visible, got %v", rowKeys(v))
+	}
+	v.SetExpanded(false)
+	if len(v.Rows()) != 3 {
+		t.Errorf("Expected only top-level rows, got %v", rowKeys(v))
+	}
+}
+
+func TestTreeView_JumpBetweenChanges(t *testing.T) {
+	v := NewTreeView(treeTestDiff())
+
+	var paths []string
+	for v.NextChange() {
+		paths = append(paths, v.Selected().Diff.Path)
+	}
+	want := "disks[2].type,disks[10],labels.env,machineType"
+	if got := strings.Join(paths, ","); got != want {
+		t.Errorf("Expected changes %s, got %s", want, got)
+	}
+
+	if !v.PrevChange() || v.Selected().Diff.Path != "labels.env" {
+		t.Errorf("Expected PrevChange to select labels.env, got %s", v.Selected().Diff.Path)
+	}
+}
+
+func TestTreeView_Render(t *testing.T) {
+	v := NewTreeView(treeTestDiff())
+	v.SetExpanded(true)
+	v.Move(100)
+
+	opts := OutputOptions{Color: ColorNever}
+	lines := v.Render(0, 3, opts)
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d: %q", len(lines), lines)
+	}
+	// Scrolled down to keep the last row, machineType, selected and visible
+	if lines[2] != "\x1b[7m  ~ machineType: \"n1\" -> \"n2\"\x1b[0m" {
+		t.Errorf("Unexpected selected row %q", lines[2])
+	}
+	if lines[0] != "▾ ~ labels (1 change(s))" {
+		t.Errorf("Unexpected parent row %q", lines[0])
+	}
+
+	if got := v.Render(10, 3, opts)[1]; got != "    - env…" {
+		t.Errorf("Expected a truncated row, got %q", got)
+	}
+}
+
+func TestTreeView_Empty(t *testing.T) {
+	v := NewTreeView(&Diff{Type: DiffTypeEqual})
+	if v.Selected() != nil || v.NextChange() {
+		t.Error("Expected nothing to select in an empty tree")
+	}
+	lines := v.Render(80, 10, OutputOptions{Color: ColorNever})
+	if len(lines) != 1 || !strings.Contains(lines[0], "No differences") {
+		t.Errorf("Unexpected empty render %q", lines)
+	}
+}
@@ -0,0 +1,85 @@
+package compare
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// minWrapWidth is the narrowest space a value is wrapped into; below it the
+// value is printed unwrapped rather than as a column of fragments
+const minWrapWidth = 20
+
+// ansiPattern matches the SGR escape sequences used for colors
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// visibleLen returns the number of columns s occupies on screen, not
+// counting ANSI color codes
+func visibleLen(s string) int {
+	return utf8.RuneCountInString(ansiPattern.ReplaceAllString(s, ""))
+}
+
+// wrapLine splits s into pieces of at most firstWidth visible columns for the
+// first piece and restWidth for the rest. It breaks after a comma or at a space
+// when one is available, and never inside an ANSI escape sequence.
+func wrapLine(s string, firstWidth, restWidth int) []string {
+	var pieces []string
+	width := firstWidth
+	for visibleLen(s) > width {
+		cut := breakPoint(s, width)
+		pieces = append(pieces, strings.TrimRight(s[:cut], " "))
+		s = strings.TrimLeft(s[cut:], " ")
+		width = restWidth
+	}
+	return append(pieces, s)
+}
+
+// breakPoint returns the byte offset at which to split s so the first part
+// fills at most width visible columns. Breaks that would leave the line less
+// than half full are skipped in favour of a hard break.
+func breakPoint(s string, width int) int {
+	columns, hard, soft := 0, len(s), 0
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			if loc := ansiPattern.FindStringIndex(s[i:]); loc != nil && loc[0] == 0 {
+				i += loc[1]
+				continue
+			}
+		}
+		if columns == width {
+			hard = i
+			break
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		columns++
+		if (r == ',' || r == ' ') && columns > width/2 {
+			soft = i
+		}
+	}
+	if soft > 0 {
+		return soft
+	}
+	return hard
+}
+
+// writeWrapped writes text colored by colorFunc after a prefix of leadWidth
+// columns already on the line, wrapping it to opts.Width. Continuation lines
+// start with indent. A zero Width disables wrapping.
+func writeWrapped(w io.Writer, opts OutputOptions, leadWidth int, indent, text string, colorFunc func(...interface{}) string) {
+	firstWidth := opts.Width - leadWidth
+	restWidth := opts.Width - visibleLen(indent)
+	if opts.Width <= 0 || firstWidth < minWrapWidth || restWidth < minWrapWidth {
+		fmt.Fprintf(w, "%s\n", colorFunc(text))
+		return
+	}
+
+	for i, piece := range wrapLine(text, firstWidth, restWidth) {
+		if i > 0 {
+			fmt.Fprint(w, indent)
+		}
+		fmt.Fprintf(w, "%s\n", colorFunc(piece))
+	}
+}
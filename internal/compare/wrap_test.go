@@ -0,0 +1,85 @@
+package compare
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestWrapLine_BreaksAtCommas(t *testing.T) {
+	line := `["10.0.0.1/32","10.0.0.2/32","10.0.0.3/32","10.0.0.4/32"]`
+
+	pieces := wrapLine(line, 30, 30)
+
+	if len(pieces) < 2 {
+		t.Fatalf("Expected the line to wrap, got %q", pieces)
+	}
+	for _, piece := range pieces {
+		if visibleLen(piece) > 30 {
+			t.Errorf("Piece %q is wider than 30 columns", piece)
+		}
+	}
+	if !strings.HasSuffix(pieces[0], ",") {
+		t.Errorf("Expected the first piece to end at a comma, got %q", pieces[0])
+	}
+	if strings.Join(pieces, "") != line {
+		t.Errorf("Expected pieces to reassemble the line, got %q", pieces)
+	}
+}
+
+func TestWrapLine_IgnoresANSICodes(t *testing.T) {
+	line := "\x1b[31m" + strings.Repeat("x", 30) + "\x1b[0m"
+
+	if got := visibleLen(line); got != 30 {
+		t.Errorf("visibleLen() = %d, want 30", got)
+	}
+	if pieces := wrapLine(line, 30, 30); len(pieces) != 1 {
+		t.Errorf("Expected a colored line of exactly the width not to wrap, got %q", pieces)
+	}
+
+	pieces := wrapLine("\x1b[31m"+strings.Repeat("x", 40)+"\x1b[0m", 30, 30)
+	if len(pieces) != 2 || visibleLen(pieces[0]) != 30 || !strings.HasPrefix(pieces[0], "\x1b[31m") {
+		t.Errorf("Expected a hard break after 30 visible columns, got %q", pieces)
+	}
+}
+
+func TestPrintValue_WrapsToWidth(t *testing.T) {
+	original := color.NoColor
+	color.NoColor = false
+	t.Cleanup(func() { color.NoColor = original })
+
+	value := strings.Repeat("word ", 30)
+	var buf bytes.Buffer
+	opts := OutputOptions{Width: 60}.withColors()
+	printValue(&buf, opts, "    ", value, opts.colors.red)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) < 3 {
+		t.Fatalf("Expected the value to wrap over several lines, got:\n%s", buf.String())
+	}
+	for i, line := range lines {
+		// The first line follows the caller's 4-column marker
+		width := visibleLen(line)
+		if i == 0 {
+			width += 4
+		}
+		if width > 60 {
+			t.Errorf("Line %d is %d columns wide: %q", i, width, line)
+		}
+		if i > 0 && !strings.HasPrefix(line, "      ") {
+			t.Errorf("Expected continuation line %d to be indented, got %q", i, line)
+		}
+	}
+}
+
+func TestPrintValue_NoWidthNoWrap(t *testing.T) {
+	var buf bytes.Buffer
+	opts := OutputOptions{}.withColors()
+	printValue(&buf, opts, "    ", strings.Repeat("word ", 30), opts.colors.red)
+
+	if strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("Expected no wrapping without a width, got:\n%s", buf.String())
+	}
+}
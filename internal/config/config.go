@@ -1,7 +1,10 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -18,10 +21,188 @@ type Config struct {
 	// Supports nested paths like "metadata.creationTimestamp"
 	IgnoreFields []string `yaml:"ignore_fields"`
 
+	// IgnoreUnderPath is a list of parent paths whose descendants are all
+	// ignored, e.g. "healthChecks" ignores healthChecks[0].port but not a
+	// top-level port. Entries may be globs (e.g. "*Checks").
+	IgnoreUnderPath []string `yaml:"ignore_under_path"`
+
+	// Unignored lists paths compared despite the ignore rules, with
+	// everything beneath them. It is set per run by Unignore.
+	Unignored []string `yaml:"-"`
+
 	// IgnorePatterns is a list of regex patterns for fields to ignore
 	IgnorePatterns []string `yaml:"ignore_patterns"`
+
+	// StrictEnv makes Load fail when an IgnoreFields or IgnorePatterns entry
+	// uses an unset environment variable, instead of dropping the entry
+	StrictEnv bool `yaml:"strict_env"`
+
+	// OnlyFields restricts comparison to these field paths (and everything
+	// beneath them) when non-empty
+	OnlyFields []string `yaml:"only_fields"`
+
+	// CoerceStringBooleans treats "true"/"false" strings (case-insensitive) as
+	// equal to the corresponding real booleans
+	CoerceStringBooleans bool `yaml:"coerce_string_booleans"`
+
+	// NormalizeJSONStrings compares string values that both hold valid JSON
+	// by their canonical encoding, ignoring whitespace and key order
+	NormalizeJSONStrings bool `yaml:"normalize_json_strings"`
+
+	// SemverPaths are globs, matched against a field's name or full path, of
+	// fields holding semantic versions. Their values compare equal when they
+	// name the same version, so 1.2 equals 1.2.0.
+	SemverPaths []string `yaml:"semver_paths"`
+
+	// QuantityPaths are globs, matched like SemverPaths, of fields holding
+	// resource quantities such as 1Gi or 500m. Their values compare equal
+	// when they are the same amount, so 1Gi equals 1024Mi.
+	QuantityPaths []string `yaml:"quantity_paths"`
+
+	// CaseInsensitiveValues compares string values ignoring case, so enums
+	// returned as RUNNING by one API and running by another are equal
+	CaseInsensitiveValues bool `yaml:"case_insensitive_values"`
+
+	// CaseInsensitiveValuePaths, when non-empty, limits CaseInsensitiveValues
+	// to fields matching these globs (by field name or full path)
+	CaseInsensitiveValuePaths []string `yaml:"case_insensitive_value_paths"`
+
+	// URLCanonicalize compares URL values by their canonical form, ignoring
+	// http versus https, the case of the scheme and host, trailing slashes
+	// and the order of query parameters
+	URLCanonicalize bool `yaml:"url_canonicalize"`
+
+	// NormalizeURLPaths, when non-empty, limits URLCanonicalize to fields
+	// matching these globs (by field name or full path)
+	NormalizeURLPaths []string `yaml:"normalize_url_paths"`
+
+	// IgnoreEmptyString treats an empty string like a missing field: "" on
+	// one side and no field on the other are equal, and fields empty on both
+	// sides are left out of context and include-equal output
+	IgnoreEmptyString bool `yaml:"ignore_empty_string"`
+
+	// StructureOnly compares only the shape of resources: leaves of the same
+	// type are always equal, so only added/removed keys and type changes show
+	StructureOnly bool `yaml:"structure_only"`
+
+	// ShowContext keeps unchanged sibling leaves of changed fields in the diff
+	// so they can be rendered as context
+	ShowContext bool `yaml:"show_context"`
+
+	// IncludeEqual keeps every equal field in the diff tree, not just those
+	// next to a change, so the tree describes both resources in full
+	IncludeEqual bool `yaml:"include_equal"`
+
+	// NormalizeMapToList lists paths of maps keyed by generated IDs whose
+	// values are compared as a list, ignoring the keys. An entry may name an
+	// inner field to order the values by, as "path:field".
+	NormalizeMapToList []string `yaml:"normalize_map_to_list"`
+
+	// KeyValueArrays lists paths of arrays of {key, value} objects, like
+	// metadata.items, that are compared as a map from key to value. An entry
+	// may name other fields as "path:keyField:valueField". metadata.items is
+	// always included.
+	KeyValueArrays []string `yaml:"key_value_arrays"`
+
+	// EntryArrayPaths maps paths of arrays of named entries, like
+	// [{name: a, ...}], to their name field. Such an array is compared as a
+	// map from name to the rest of the entry, so it lines up with an API
+	// that models the same data as a map of maps, {a: {...}}.
+	EntryArrayPaths map[string]string `yaml:"entry_array_paths"`
+
+	// FieldMigrations move fields from the paths an older API version uses
+	// to the newer paths, in order, before comparing, so resources fetched
+	// at different API versions line up. Unlike KeyAliases they may relocate
+	// nested fields, e.g. from: scheduling.preemptible, to: provisioning.spot.
+	FieldMigrations []FieldMigration `yaml:"field_migrations"`
+
+	// KeyAliases maps alternate object key names to the name they are
+	// compared under, e.g. self_link: selfLink, so resources spelling a field
+	// differently line up. It applies to keys at every level.
+	KeyAliases map[string]string `yaml:"key_aliases"`
+
+	// ArrayKeys maps array paths to the fields that identify their elements,
+	// e.g. allowed: [IPProtocol, "ports[0]"]. Elements are paired across
+	// reordering when all key fields are equal. A field is a dotted path that
+	// may index into arrays.
+	ArrayKeys map[string][]string `yaml:"array_keys"`
+
+	// ArraySimilarityThreshold enables order-insensitive array comparison when
+	// greater than zero. Elements are paired with their most similar
+	// counterpart if the fraction of shared fields is at least this value
+	// (0-1); unpaired elements are reported as added or removed.
+	ArraySimilarityThreshold float64 `yaml:"array_similarity_threshold"`
+
+	// ArrayMode is how changed arrays are reported: "granular" (the default)
+	// reports each changed element, "whole" reports the entire array as one
+	// modified value
+	ArrayMode string `yaml:"array_mode"`
+
+	// MaxArrayElements skips element-wise comparison of arrays longer than
+	// this and reports only their lengths. 0 means unlimited.
+	MaxArrayElements int `yaml:"max_array_elements"`
+
+	// AllowedDelta maps a field name or path glob to the largest change of
+	// its numeric value that is not reported, for quotas and counters that
+	// fluctuate within acceptable bounds (e.g. usage: 10)
+	AllowedDelta map[string]float64 `yaml:"allowed_delta"`
+
+	// UnitFields maps a field name or path glob to a unit suffix shown after
+	// its numeric values in diff output (e.g. diskSizeGb: GB). Comparison and
+	// json output are unaffected.
+	UnitFields map[string]string `yaml:"unit_fields"`
+
+	// RiskWeights maps a field name or path glob to the risk of changing it.
+	// When set, diff output shows a risk score summing the weight of every
+	// changed field, counting 1 for fields no glob matches.
+	RiskWeights map[string]int `yaml:"risk_weights"`
+
+	// Colors overrides the colors of diff output, mapping a role (added,
+	// removed, modified, field, header) to a color name such as blue,
+	// bright-red or bold
+	Colors map[string]string `yaml:"colors"`
+
+	// DeprecatedFields are globs of deprecated fields (matched against the
+	// field name, full path, or path without array indices); changes to them
+	// are annotated "(deprecated)" in diff output
+	DeprecatedFields []string `yaml:"deprecated_fields"`
+
+	// Sections maps a section name (e.g. "Networking") to glob patterns of
+	// top-level fields that belong to it, used by --group-by=section
+	Sections map[string][]string `yaml:"sections"`
+
+	// Schema maps a field path to its declared type (string, integer, number
+	// or boolean). Values at these paths are coerced to that type before they
+	// are compared, so e.g. "5" and 5 are equal for an integer field.
+	Schema map[string]string `yaml:"schema"`
+
+	// Transforms maps a field name or path glob to built-in transforms, such
+	// as trim, lower or basename, applied in order to both string values
+	// before they are compared (e.g. machineType: [basename, lower])
+	Transforms map[string][]string `yaml:"transforms"`
+
+	// EnumMaps maps a field name or path glob to the canonical names of its
+	// enum values, for APIs that report an enum as a number on one side and
+	// a string on the other (e.g. status: {"2": RUNNING}). Values are
+	// translated before comparing; unmapped values compare as usual.
+	EnumMaps map[string]map[string]string `yaml:"enum_maps"`
+}
+
+// FieldMigration moves the field at the dotted path From to the path To
+type FieldMigration struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
 }
 
+// Array modes accepted by ArrayMode
+const (
+	ArrayModeGranular = "granular"
+	ArrayModeWhole    = "whole"
+)
+
+// SchemaTypes are the field types a schema may declare
+var SchemaTypes = []string{"string", "integer", "number", "boolean"}
+
 // Default returns the default configuration
 func Default() *Config {
 	return &Config{
@@ -62,17 +243,115 @@ func Load(path string) (*Config, error) {
 
 	// Merge with defaults if empty
 	if len(cfg.IgnoreFields) == 0 && len(cfg.IgnorePatterns) == 0 {
-		return Default(), nil
+		defaults := Default()
+		cfg.IgnoreFields = defaults.IgnoreFields
+		cfg.IgnorePatterns = defaults.IgnorePatterns
+	}
+
+	if err := cfg.expandEnv(); err != nil {
+		return nil, err
 	}
 
 	return &cfg, nil
 }
 
-// ShouldIgnore checks if a field should be ignored based on config
+// expandEnv expands $VAR and ${VAR} references in the ignore entries, so a
+// shared config can hold e.g. labels.${ENV_LABEL}. Entries using an unset
+// variable are dropped, or rejected when StrictEnv is set.
+func (c *Config) expandEnv() error {
+	var err error
+	if c.IgnoreFields, err = expandEnvEntries(c.IgnoreFields, "ignore_fields", c.StrictEnv); err != nil {
+		return err
+	}
+	c.IgnorePatterns, err = expandEnvEntries(c.IgnorePatterns, "ignore_patterns", c.StrictEnv)
+	return err
+}
+
+func expandEnvEntries(entries []string, key string, strict bool) ([]string, error) {
+	var expanded []string
+	for _, entry := range entries {
+		var unset []string
+		value := os.Expand(entry, func(name string) string {
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				unset = append(unset, name)
+			}
+			return value
+		})
+
+		if len(unset) > 0 {
+			if strict {
+				return nil, fmt.Errorf("%s entry %q uses unset environment variable %s (strict_env)", key, entry, unset[0])
+			}
+			continue
+		}
+		expanded = append(expanded, value)
+	}
+	return expanded, nil
+}
+
+// LoadSchema loads a JSON-Schema-like type map from a file, in the form
+// {"properties": {"<field path>": {"type": "<type>"}}}
+func LoadSchema(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var schema struct {
+		Properties map[string]struct {
+			Type string `json:"type"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]string, len(schema.Properties))
+	for fieldPath, property := range schema.Properties {
+		if !isSchemaType(property.Type) {
+			return nil, fmt.Errorf("field %q has unsupported type %q (expected one of: %s)",
+				fieldPath, property.Type, strings.Join(SchemaTypes, ", "))
+		}
+		types[fieldPath] = property.Type
+	}
+
+	return types, nil
+}
+
+func isSchemaType(t string) bool {
+	for _, schemaType := range SchemaTypes {
+		if t == schemaType {
+			return true
+		}
+	}
+	return false
+}
+
+// ShouldIgnore checks if a field should be ignored based on config.
+// IgnoreFields entries match the field itself and IgnoreUnderPath entries
+// match one of its parents, both as PathMatcher patterns. A field at or
+// beneath an Unignored path is never ignored.
 func (c *Config) ShouldIgnore(fieldPath string) bool {
-	// Check exact matches
+	tokens, err := SplitPath(fieldPath)
+	if err != nil {
+		return false
+	}
+
+	for _, path := range c.Unignored {
+		if m := cachedMatcher(path); m != nil && (matchTokens(m.tokens, tokens) || m.matchAncestor(tokens)) {
+			return false
+		}
+	}
+
 	for _, field := range c.IgnoreFields {
-		if field == fieldPath {
+		if m := cachedMatcher(field); m != nil && matchTokens(m.tokens, tokens) {
+			return true
+		}
+	}
+
+	for _, pattern := range c.IgnoreUnderPath {
+		if m := cachedMatcher(pattern); m != nil && m.matchAncestor(tokens) {
 			return true
 		}
 	}
@@ -82,3 +361,34 @@ func (c *Config) ShouldIgnore(fieldPath string) bool {
 
 	return false
 }
+
+// Unignore adds an exception so fieldPath and everything beneath it are
+// compared again. The ignore rules themselves stay in place, so a broad glob
+// such as labels.* or an ignore_under_path parent keeps hiding the fields
+// around it.
+func (c *Config) Unignore(fieldPath string) {
+	c.Unignored = append(c.Unignored, fieldPath)
+}
+
+// IsAllowed checks if a field is covered by OnlyFields. A field is allowed if
+// it is one of the listed paths, lies beneath one, or is an ancestor of one
+// (so comparison can descend to it). Entries are PathMatcher patterns. An
+// empty OnlyFields allows everything.
+func (c *Config) IsAllowed(fieldPath string) bool {
+	if len(c.OnlyFields) == 0 {
+		return true
+	}
+
+	tokens, err := SplitPath(fieldPath)
+	if err != nil {
+		return false
+	}
+	for _, field := range c.OnlyFields {
+		m := cachedMatcher(field)
+		if m != nil && (matchTokens(m.tokens, tokens) || m.matchAncestor(tokens) || matchTokenPrefix(m.tokens, tokens)) {
+			return true
+		}
+	}
+
+	return false
+}
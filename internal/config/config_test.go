@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -173,6 +175,36 @@ func TestShouldIgnore(t *testing.T) {
 	}
 }
 
+func TestShouldIgnore_UnderPath(t *testing.T) {
+	cfg := &Config{
+		IgnoreUnderPath: []string{"healthChecks", "*Probes"},
+	}
+
+	tests := []struct {
+		name     string
+		field    string
+		expected bool
+	}{
+		{"direct child", "healthChecks.port", true},
+		{"array element field", "healthChecks[0].port", true},
+		{"deep descendant", "healthChecks[1].tcp.port", true},
+		{"parent itself", "healthChecks", false},
+		{"sibling with same name", "port", false},
+		{"shared prefix only", "healthChecksEnabled.port", false},
+		{"glob parent", "livenessProbes[0].port", true},
+		{"glob does not match parent itself", "livenessProbes", false},
+		{"quoted key is not split", `"healthChecks.v1".port`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := cfg.ShouldIgnore(tt.field); result != tt.expected {
+				t.Errorf("ShouldIgnore(%q) = %v, want %v", tt.field, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestShouldIgnore_EmptyConfig(t *testing.T) {
 	cfg := &Config{
 		IgnoreFields: []string{},
@@ -182,3 +214,326 @@ func TestShouldIgnore_EmptyConfig(t *testing.T) {
 		t.Error("Empty config should not ignore any fields")
 	}
 }
+
+func TestUnignore(t *testing.T) {
+	cfg := &Config{
+		IgnoreFields:    []string{"id", "fingerprint", "labels.*", "etag"},
+		IgnoreUnderPath: []string{"metadata", "status"},
+		IgnorePatterns:  []string{".*Fingerprint$", ".*Timestamp$"},
+	}
+
+	cfg.Unignore("fingerprint")
+	cfg.Unignore("labels.env")
+	cfg.Unignore("metadata.items")
+
+	for _, field := range []string{"fingerprint", "labels.env", "metadata.items", "metadata.items[0].key"} {
+		if cfg.ShouldIgnore(field) {
+			t.Errorf("Expected un-ignored %s to be compared", field)
+		}
+	}
+	for _, field := range []string{"id", "etag", "status.state"} {
+		if !cfg.ShouldIgnore(field) {
+			t.Errorf("Expected %s to stay ignored", field)
+		}
+	}
+}
+
+func TestUnignore_KeepsBroadGlob(t *testing.T) {
+	cfg := &Config{IgnoreFields: []string{"labels.*"}}
+
+	cfg.Unignore("labels.env")
+
+	if cfg.ShouldIgnore("labels.env") {
+		t.Error("Expected labels.env to be compared")
+	}
+	if !cfg.ShouldIgnore("labels.team") {
+		t.Error("Expected labels.team to stay ignored by labels.*")
+	}
+	if want := []string{"labels.*"}; !reflect.DeepEqual(cfg.IgnoreFields, want) {
+		t.Errorf("Expected IgnoreFields to stay %v, got %v", want, cfg.IgnoreFields)
+	}
+}
+
+func TestUnignore_KeepsAncestorRule(t *testing.T) {
+	cfg := &Config{IgnoreUnderPath: []string{"metadata"}}
+
+	cfg.Unignore("metadata.items")
+
+	if cfg.ShouldIgnore("metadata.items") || cfg.ShouldIgnore("metadata.items[1].value") {
+		t.Error("Expected metadata.items and its children to be compared")
+	}
+	if !cfg.ShouldIgnore("metadata.fingerprint") {
+		t.Error("Expected metadata.fingerprint to stay ignored under metadata")
+	}
+}
+
+func TestLoad_SettingsWithoutIgnoreRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "settings.yaml")
+
+	configContent := `coerce_string_booleans: true
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if !cfg.CoerceStringBooleans {
+		t.Error("Expected coerce_string_booleans to be preserved")
+	}
+
+	if len(cfg.IgnoreFields) == 0 {
+		t.Error("Expected default ignore fields to be merged in")
+	}
+}
+
+func TestIsAllowed(t *testing.T) {
+	cfg := &Config{
+		OnlyFields: []string{"machineType", "scheduling.preemptible", "disks"},
+	}
+
+	tests := []struct {
+		name     string
+		field    string
+		expected bool
+	}{
+		{"exact match", "machineType", true},
+		{"ancestor of allowed", "scheduling", true},
+		{"nested exact", "scheduling.preemptible", true},
+		{"sibling of allowed", "scheduling.automaticRestart", false},
+		{"beneath allowed", "disks[0].diskSizeGb", true},
+		{"not listed", "name", false},
+		{"shared prefix only", "machineTypeLabel", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := cfg.IsAllowed(tt.field); result != tt.expected {
+				t.Errorf("IsAllowed(%q) = %v, want %v", tt.field, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsAllowed_EmptyAllowsAll(t *testing.T) {
+	cfg := &Config{}
+
+	if !cfg.IsAllowed("anything.at.all") {
+		t.Error("Empty OnlyFields should allow every field")
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	schemaPath := filepath.Join(tmpDir, "schema.json")
+
+	schemaContent := `{
+  "properties": {
+    "diskSizeGb": {"type": "integer"},
+    "scheduling.preemptible": {"type": "boolean"}
+  }
+}`
+	if err := os.WriteFile(schemaPath, []byte(schemaContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp schema: %v", err)
+	}
+
+	schema, err := LoadSchema(schemaPath)
+	if err != nil {
+		t.Fatalf("LoadSchema failed: %v", err)
+	}
+
+	if schema["diskSizeGb"] != "integer" {
+		t.Errorf("Expected diskSizeGb to be integer, got %q", schema["diskSizeGb"])
+	}
+	if schema["scheduling.preemptible"] != "boolean" {
+		t.Errorf("Expected scheduling.preemptible to be boolean, got %q", schema["scheduling.preemptible"])
+	}
+}
+
+func TestLoadSchema_UnsupportedType(t *testing.T) {
+	tmpDir := t.TempDir()
+	schemaPath := filepath.Join(tmpDir, "schema.json")
+
+	if err := os.WriteFile(schemaPath, []byte(`{"properties": {"tags": {"type": "array"}}}`), 0644); err != nil {
+		t.Fatalf("Failed to create temp schema: %v", err)
+	}
+
+	if _, err := LoadSchema(schemaPath); err == nil {
+		t.Error("Expected error for unsupported schema type")
+	}
+}
+
+func TestValidate_InvalidRegex(t *testing.T) {
+	data := []byte(`ignore_patterns:
+  - ".*Timestamp$"
+  - "(unclosed"
+`)
+
+	problems, err := Validate(data)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	if len(problems) != 1 {
+		t.Fatalf("Expected 1 problem, got %v", problems)
+	}
+	if problems[0].Line != 3 || !strings.Contains(problems[0].Message, "not a valid regex") {
+		t.Errorf("Expected invalid regex on line 3, got %+v", problems[0])
+	}
+}
+
+func TestValidate_InvalidGlob(t *testing.T) {
+	data := []byte(`ignore_fields:
+  - "labels[0"
+only_fields:
+  - machineType
+  - 'metadata."unclosed'
+ignore_under_path:
+  - "disks[*"
+`)
+
+	problems, err := Validate(data)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	var lines []int
+	for _, problem := range problems {
+		if strings.Contains(problem.Message, "not a valid glob") {
+			lines = append(lines, problem.Line)
+		}
+	}
+	if !reflect.DeepEqual(lines, []int{2, 5, 7}) {
+		t.Errorf("Expected invalid globs on lines 2, 5 and 7, got %+v", problems)
+	}
+}
+
+func TestValidate_DuplicateField(t *testing.T) {
+	data := []byte(`ignore_fields:
+  - id
+  - etag
+  - id
+`)
+
+	problems, err := Validate(data)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	if len(problems) != 1 {
+		t.Fatalf("Expected 1 problem, got %v", problems)
+	}
+	if problems[0].Line != 4 || !strings.Contains(problems[0].Message, "duplicates line 2") {
+		t.Errorf("Expected duplicate on line 4, got %+v", problems[0])
+	}
+}
+
+func TestValidate_ShadowedField(t *testing.T) {
+	data := []byte(`ignore_fields:
+  - healthChecks.port
+  - port
+ignore_under_path:
+  - "health*"
+`)
+
+	problems, err := Validate(data)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	if len(problems) != 1 {
+		t.Fatalf("Expected 1 problem, got %v", problems)
+	}
+	if problems[0].Line != 2 || !strings.Contains(problems[0].Message, `covered by ignore_under_path "health*"`) {
+		t.Errorf("Expected shadowed entry on line 2, got %+v", problems[0])
+	}
+}
+
+func TestValidate_FieldCoveredByGlob(t *testing.T) {
+	data := []byte(`ignore_fields:
+  - labels.*
+  - labels.env
+  - metadata.labels
+`)
+
+	problems, err := Validate(data)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	if len(problems) != 1 {
+		t.Fatalf("Expected 1 problem, got %v", problems)
+	}
+	if problems[0].Line != 3 || !strings.Contains(problems[0].Message, `covered by ignore_fields "labels.*" (line 2)`) {
+		t.Errorf("Expected covered entry on line 3, got %+v", problems[0])
+	}
+}
+
+func TestValidate_CleanConfig(t *testing.T) {
+	data := []byte(`ignore_fields:
+  - id
+ignore_patterns:
+  - ".*Fingerprint$"
+`)
+
+	problems, err := Validate(data)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("Expected no problems, got %v", problems)
+	}
+}
+
+func TestLoad_ExpandsEnvInIgnoreRules(t *testing.T) {
+	t.Setenv("GCDIFF_ENV_LABEL", "staging")
+	os.Unsetenv("GCDIFF_UNSET_LABEL")
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `ignore_fields:
+  - labels.${GCDIFF_ENV_LABEL}
+  - labels.${GCDIFF_UNSET_LABEL}
+  - id
+ignore_patterns:
+  - ^metadata\.$GCDIFF_ENV_LABEL.*$
+  - ^tags\.${GCDIFF_UNSET_LABEL}$
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	// Entries using the unset variable are dropped
+	if want := []string{"labels.staging", "id"}; !reflect.DeepEqual(cfg.IgnoreFields, want) {
+		t.Errorf("Expected ignore fields %v, got %v", want, cfg.IgnoreFields)
+	}
+	if want := []string{`^metadata\.staging.*$`}; !reflect.DeepEqual(cfg.IgnorePatterns, want) {
+		t.Errorf("Expected ignore patterns %v, got %v", want, cfg.IgnorePatterns)
+	}
+}
+
+func TestLoad_StrictEnvRejectsUnsetVariable(t *testing.T) {
+	os.Unsetenv("GCDIFF_UNSET_LABEL")
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `strict_env: true
+ignore_fields:
+  - labels.${GCDIFF_UNSET_LABEL}
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create temp config: %v", err)
+	}
+
+	_, err := Load(configPath)
+	if err == nil || !strings.Contains(err.Error(), "GCDIFF_UNSET_LABEL") {
+		t.Errorf("Expected an unset variable error, got %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PathMatcher matches field paths such as disks[0].type against a pattern
+// written in the same syntax. Within a segment or index, * matches any run
+// of characters and ? any one character, so [*] matches any array index; a
+// ** segment matches any number of segments. Keys containing separators are
+// quoted, e.g. labels."app.kubernetes.io/*".
+//
+// A bare pattern without separators, like *Version, also matches by field
+// name through MatchField.
+type PathMatcher struct {
+	tokens []patternToken
+}
+
+// patternToken is one segment or array index of a pattern
+type patternToken struct {
+	index bool
+	// deep is a ** segment
+	deep bool
+	re   *regexp.Regexp
+}
+
+// PathToken is one segment or array index of a field path, as split by
+// SplitPath
+type PathToken struct {
+	// Index marks an array index; Text is then the text between the brackets
+	Index bool
+
+	// Text is the segment's key, unquoted, or the index text
+	Text string
+}
+
+// NewPathMatcher compiles a path pattern
+func NewPathMatcher(pattern string) (*PathMatcher, error) {
+	tokens, err := SplitPath(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path pattern %q: %w", pattern, err)
+	}
+
+	m := &PathMatcher{}
+	for _, token := range tokens {
+		if !token.Index && token.Text == "**" {
+			m.tokens = append(m.tokens, patternToken{deep: true})
+			continue
+		}
+		m.tokens = append(m.tokens, patternToken{index: token.Index, re: globToRegexp(token.Text)})
+	}
+	return m, nil
+}
+
+// Match reports whether the pattern matches the whole of fieldPath
+func (m *PathMatcher) Match(fieldPath string) bool {
+	tokens, err := SplitPath(fieldPath)
+	return err == nil && matchTokens(m.tokens, tokens)
+}
+
+// MatchAncestor reports whether the pattern matches a parent of fieldPath,
+// so that fieldPath lies beneath a matched path
+func (m *PathMatcher) MatchAncestor(fieldPath string) bool {
+	tokens, err := SplitPath(fieldPath)
+	return err == nil && m.matchAncestor(tokens)
+}
+
+func (m *PathMatcher) matchAncestor(tokens []PathToken) bool {
+	for i := 1; i < len(tokens); i++ {
+		if matchTokens(m.tokens, tokens[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchDescendant reports whether the pattern could match a path beneath
+// fieldPath, so comparison must descend into it to reach a matched path
+func (m *PathMatcher) MatchDescendant(fieldPath string) bool {
+	tokens, err := SplitPath(fieldPath)
+	return err == nil && matchTokenPrefix(m.tokens, tokens)
+}
+
+// MatchField reports whether the pattern matches a field's name (the last
+// segment, ignoring array indices), its full path, or its path with numeric
+// array indices removed, so "networkInterfaces.network" matches
+// networkInterfaces[0].network
+func (m *PathMatcher) MatchField(fieldPath string) bool {
+	tokens, err := SplitPath(fieldPath)
+	if err != nil {
+		return false
+	}
+	if matchTokens(m.tokens, tokens) {
+		return true
+	}
+
+	var names []PathToken
+	for _, token := range tokens {
+		if !token.Index || !isNumericIndex(token.Text) {
+			names = append(names, token)
+		}
+	}
+	if matchTokens(m.tokens, names) {
+		return true
+	}
+
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if !tokens[i].Index {
+			return matchTokens(m.tokens, tokens[i:i+1])
+		}
+	}
+	return false
+}
+
+// isNumericIndex reports whether an index token names an array position,
+// including the removed:N form given to unmatched elements of the first array
+func isNumericIndex(text string) bool {
+	_, err := strconv.Atoi(strings.TrimPrefix(text, "removed:"))
+	return err == nil
+}
+
+// matchTokens reports whether the pattern tokens match all of tokens
+func matchTokens(pattern []patternToken, tokens []PathToken) bool {
+	for len(pattern) > 0 {
+		if pattern[0].deep {
+			for i := 0; i <= len(tokens); i++ {
+				if matchTokens(pattern[1:], tokens[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(tokens) == 0 || !pattern[0].matches(tokens[0]) {
+			return false
+		}
+		pattern, tokens = pattern[1:], tokens[1:]
+	}
+	return len(tokens) == 0
+}
+
+// matchTokenPrefix reports whether tokens match the start of the pattern,
+// leaving more of it to match beneath them
+func matchTokenPrefix(pattern []patternToken, tokens []PathToken) bool {
+	for len(tokens) > 0 {
+		if len(pattern) == 0 {
+			return false
+		}
+		if pattern[0].deep {
+			return true
+		}
+		if !pattern[0].matches(tokens[0]) {
+			return false
+		}
+		pattern, tokens = pattern[1:], tokens[1:]
+	}
+	return len(pattern) > 0
+}
+
+func (t patternToken) matches(token PathToken) bool {
+	return t.index == token.Index && t.re.MatchString(token.Text)
+}
+
+// SplitPath splits a path into its segments and array indices, e.g.
+// disks[0].type into disks, [0] and type. Quoted segments are unquoted and
+// index tokens hold the text between the brackets.
+func SplitPath(fieldPath string) ([]PathToken, error) {
+	var tokens []PathToken
+	for s := fieldPath; s != ""; {
+		switch {
+		case s[0] == '[':
+			end := strings.IndexByte(s, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed [ in %q", fieldPath)
+			}
+			tokens = append(tokens, PathToken{Index: true, Text: s[1:end]})
+			s = s[end+1:]
+		case s[0] == '"':
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("unterminated quoted key in %q", fieldPath)
+			}
+			key, _ := strconv.Unquote(quoted)
+			tokens = append(tokens, PathToken{Text: key})
+			s = s[len(quoted):]
+		default:
+			end := strings.IndexAny(s, ".[")
+			if end == -1 {
+				end = len(s)
+			}
+			tokens = append(tokens, PathToken{Text: s[:end]})
+			s = s[end:]
+		}
+		s = strings.TrimPrefix(s, ".")
+	}
+	return tokens, nil
+}
+
+// globToRegexp converts a glob over one path segment into an anchored
+// regular expression. Unlike path.Match, / is an ordinary character, so
+// globs work on keys like app.kubernetes.io/name.
+func globToRegexp(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, c := range glob {
+		switch c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// matchers caches compiled patterns, since config rules are matched against
+// every compared field
+var matchers sync.Map
+
+// cachedMatcher returns the compiled matcher for pattern, or nil if pattern
+// is invalid
+func cachedMatcher(pattern string) *PathMatcher {
+	if m, ok := matchers.Load(pattern); ok {
+		return m.(*PathMatcher)
+	}
+	m, _ := NewPathMatcher(pattern)
+	matchers.Store(pattern, m)
+	return m
+}
+
+// MatchPath reports whether pattern matches the whole of fieldPath. Invalid
+// patterns match nothing.
+func MatchPath(pattern, fieldPath string) bool {
+	m := cachedMatcher(pattern)
+	return m != nil && m.Match(fieldPath)
+}
+
+// MatchAncestor reports whether pattern matches a parent of fieldPath.
+// Invalid patterns match nothing.
+func MatchAncestor(pattern, fieldPath string) bool {
+	m := cachedMatcher(pattern)
+	return m != nil && m.MatchAncestor(fieldPath)
+}
+
+// MatchField reports whether pattern matches fieldPath as described by
+// PathMatcher.MatchField. Invalid patterns match nothing.
+func MatchField(pattern, fieldPath string) bool {
+	m := cachedMatcher(pattern)
+	return m != nil && m.MatchField(fieldPath)
+}
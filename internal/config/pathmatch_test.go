@@ -0,0 +1,176 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathMatcher_Match(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		// Exact paths
+		{"machineType", "machineType", true},
+		{"scheduling.preemptible", "scheduling.preemptible", true},
+		{"disks[0].type", "disks[0].type", true},
+		{"disks[0].type", "disks[1].type", false},
+		{"disks", "disks[0]", false},
+		{"labels", "labelsEnabled", false},
+
+		// Globs within a segment
+		{"*Version", "nodeVersion", true},
+		{"labels.*", "labels.env", true},
+		{"labels.*", "labels", false},
+		{"labels.*", "labels.env.extra", false},
+		{"label?", "labels", true},
+		{"*", "a.b", false},
+
+		// ** spans any number of segments
+		{"**.port", "port", true},
+		{"**.port", "backends[0].healthCheck.port", true},
+		{"spec.**", "spec", true},
+		{"spec.**", "spec.template.containers[0]", true},
+		{"spec.**.image", "spec.template.containers[0].image", true},
+		{"spec.**.image", "status.image", false},
+
+		// Index wildcards
+		{"disks[*].type", "disks[3].type", true},
+		{"disks[*].type", "disks.type", false},
+		{"disks[*]", "disks[12]", true},
+		{"bindings[role=*]", "bindings[role=roles/viewer]", true},
+		{"disks.*", "disks[0]", false},
+
+		// Quoted keys
+		{`labels."app.kubernetes.io/name"`, `labels."app.kubernetes.io/name"`, true},
+		{`labels."app.kubernetes.io/*"`, `labels."app.kubernetes.io/name"`, true},
+		{`labels.app`, `labels."app.kubernetes.io/name"`, false},
+	}
+
+	for _, tt := range tests {
+		m, err := NewPathMatcher(tt.pattern)
+		if err != nil {
+			t.Fatalf("NewPathMatcher(%q) failed: %v", tt.pattern, err)
+		}
+		if got := m.Match(tt.path); got != tt.want {
+			t.Errorf("%q.Match(%q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPathMatcher_AncestorAndDescendant(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		path       string
+		ancestor   bool
+		descendant bool
+	}{
+		{"healthChecks", "healthChecks[0].port", true, false},
+		{"healthChecks", "healthChecks", false, false},
+		{"healthChecks", "healthChecksEnabled.port", false, false},
+		{"*Probes", "livenessProbes[0].port", true, false},
+		{"disks[*]", "disks[2].type", true, false},
+		{"scheduling.preemptible", "scheduling", false, true},
+		{"disks[0].type", "disks", false, true},
+		{"disks[*].type", "disks[4]", false, true},
+		{"spec.**.image", "spec.template", false, true},
+		{"spec.**", "spec.template.image", true, true},
+		{"labels.env", "metadata", false, false},
+	}
+
+	for _, tt := range tests {
+		m, _ := NewPathMatcher(tt.pattern)
+		if got := m.MatchAncestor(tt.path); got != tt.ancestor {
+			t.Errorf("%q.MatchAncestor(%q) = %v, want %v", tt.pattern, tt.path, got, tt.ancestor)
+		}
+		if got := m.MatchDescendant(tt.path); got != tt.descendant {
+			t.Errorf("%q.MatchDescendant(%q) = %v, want %v", tt.pattern, tt.path, got, tt.descendant)
+		}
+	}
+}
+
+func TestPathMatcher_MatchField(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"diskSizeGb", "disks[0].diskSizeGb", true},
+		{"*Version", "nodePools[1].nodeVersion", true},
+		{"networkInterfaces.network", "networkInterfaces[0].network", true},
+		{"disks[0].type", "disks[0].type", true},
+		{"tags", "tags[3]", true},
+		{"type", "disks[0].typeName", false},
+		{"labels.*", "metadata.labels.env", false},
+		{"**.labels.*", "metadata.labels.env", true},
+	}
+
+	for _, tt := range tests {
+		if got := MatchField(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("MatchField(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNewPathMatcher_Invalid(t *testing.T) {
+	for _, pattern := range []string{"disks[0", `labels."unterminated`} {
+		if _, err := NewPathMatcher(pattern); err == nil {
+			t.Errorf("Expected an error for %q", pattern)
+		}
+		if MatchPath(pattern, "disks[0]") {
+			t.Errorf("Expected invalid pattern %q to match nothing", pattern)
+		}
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+	tokens, err := SplitPath(`metadata.annotations."app.kubernetes.io/name".items[0]`)
+	if err != nil {
+		t.Fatalf("SplitPath failed: %v", err)
+	}
+	expected := []PathToken{
+		{Text: "metadata"},
+		{Text: "annotations"},
+		{Text: "app.kubernetes.io/name"},
+		{Text: "items"},
+		{Index: true, Text: "0"},
+	}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected %v, got %v", expected, tokens)
+	}
+}
+
+func TestConfig_RulesShareMatcher(t *testing.T) {
+	cfg := &Config{
+		IgnoreFields:    []string{"disks[*].index"},
+		IgnoreUnderPath: []string{"**.status"},
+		OnlyFields:      []string{"disks[*].type", "spec.**.image"},
+	}
+
+	for path, want := range map[string]bool{
+		"disks[2].index":             true,
+		"disks[2].type":              false,
+		"spec.status.ready":          true,
+		"spec.template.status.ready": true,
+		"status":                     false,
+	} {
+		if got := cfg.ShouldIgnore(path); got != want {
+			t.Errorf("ShouldIgnore(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	for path, want := range map[string]bool{
+		"disks":                           true,
+		"disks[1]":                        true,
+		"disks[1].type":                   true,
+		"disks[1].size":                   false,
+		"spec.containers[0].image":        true,
+		"spec.containers[0].image.digest": true,
+		"metadata":                        false,
+	} {
+		if got := cfg.IsAllowed(path); got != want {
+			t.Errorf("IsAllowed(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
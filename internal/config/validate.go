@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is an issue found while validating a config file
+type Problem struct {
+	// Line is the 1-based line of the offending entry, or 0 if unknown
+	Line    int
+	Message string
+}
+
+// validatedLists are the list settings checked for duplicate entries
+var validatedLists = []string{"ignore_fields", "ignore_patterns", "ignore_under_path", "only_fields"}
+
+// globbedLists are the list settings whose entries are path globs
+var globbedLists = []string{"ignore_fields", "ignore_under_path", "only_fields"}
+
+// Validate checks the contents of a config file for rules that are invalid
+// or have no effect: regex patterns and path globs that don't compile,
+// duplicate list entries, and ignore_fields entries already covered by
+// ignore_under_path or by a broader ignore_fields glob. Problems are
+// returned in line order; an error is returned only if the YAML itself
+// can't be parsed.
+func Validate(data []byte) ([]Problem, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := root.Decode(&cfg); err != nil {
+		return nil, err
+	}
+
+	lists := listEntries(&root)
+	var problems []Problem
+
+	for _, key := range validatedLists {
+		firstLine := make(map[string]int)
+		for _, entry := range lists[key] {
+			if line, seen := firstLine[entry.Value]; seen {
+				problems = append(problems, Problem{
+					Line:    entry.Line,
+					Message: fmt.Sprintf("%s entry %q duplicates line %d", key, entry.Value, line),
+				})
+				continue
+			}
+			firstLine[entry.Value] = entry.Line
+		}
+	}
+
+	for _, entry := range lists["ignore_patterns"] {
+		if _, err := regexp.Compile(entry.Value); err != nil {
+			problems = append(problems, Problem{
+				Line:    entry.Line,
+				Message: fmt.Sprintf("ignore_patterns entry %q is not a valid regex: %v", entry.Value, err),
+			})
+		}
+	}
+
+	for _, key := range globbedLists {
+		for _, entry := range lists[key] {
+			if _, err := NewPathMatcher(entry.Value); err != nil {
+				problems = append(problems, Problem{
+					Line:    entry.Line,
+					Message: fmt.Sprintf("%s entry %q is not a valid glob: %v", key, entry.Value, err),
+				})
+			}
+		}
+	}
+
+	for _, field := range lists["ignore_fields"] {
+		if problem, ok := shadowedField(field, lists); ok {
+			problems = append(problems, problem)
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+	return problems, nil
+}
+
+// shadowedField reports the ignore_fields entry field as redundant when an
+// ignore_under_path entry or another ignore_fields glob already covers it
+func shadowedField(field *yaml.Node, lists map[string][]*yaml.Node) (Problem, bool) {
+	for _, parent := range lists["ignore_under_path"] {
+		shadow := &Config{IgnoreUnderPath: []string{parent.Value}}
+		if shadow.ShouldIgnore(field.Value) {
+			return Problem{
+				Line: field.Line,
+				Message: fmt.Sprintf("ignore_fields entry %q is already covered by ignore_under_path %q (line %d)",
+					field.Value, parent.Value, parent.Line),
+			}, true
+		}
+	}
+
+	for _, other := range lists["ignore_fields"] {
+		if other.Value != field.Value && MatchPath(other.Value, field.Value) {
+			return Problem{
+				Line: field.Line,
+				Message: fmt.Sprintf("ignore_fields entry %q is already covered by ignore_fields %q (line %d)",
+					field.Value, other.Value, other.Line),
+			}, true
+		}
+	}
+	return Problem{}, false
+}
+
+// listEntries returns the scalar items of each top-level sequence setting,
+// keyed by setting name
+func listEntries(root *yaml.Node) map[string][]*yaml.Node {
+	entries := make(map[string][]*yaml.Node)
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return entries
+	}
+
+	mapping := root.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return entries
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		if value.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, item := range value.Content {
+			if item.Kind == yaml.ScalarNode {
+				entries[key.Value] = append(entries[key.Value], item)
+			}
+		}
+	}
+	return entries
+}
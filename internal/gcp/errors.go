@@ -0,0 +1,40 @@
+package gcp
+
+import (
+	"errors"
+	"strings"
+)
+
+// Sentinel errors returned (wrapped) by ResourceFetcher so callers can
+// distinguish failure modes with errors.Is
+var (
+	// ErrResourceNotFound means gcloud reported that the resource does not exist
+	ErrResourceNotFound = errors.New("resource not found")
+
+	// ErrPermissionDenied means the active credentials cannot read the resource
+	ErrPermissionDenied = errors.New("permission denied")
+
+	// ErrInvalidJSON means gcloud succeeded but its output could not be parsed
+	ErrInvalidJSON = errors.New("invalid JSON output")
+)
+
+// classifyGcloudError maps gcloud's error output to a sentinel error, or nil
+// if the failure is not recognized
+func classifyGcloudError(output string) error {
+	lower := strings.ToLower(output)
+
+	switch {
+	case strings.Contains(output, "NOT_FOUND"),
+		strings.Contains(lower, "was not found"),
+		strings.Contains(lower, "could not be found"),
+		strings.Contains(lower, "(404)"):
+		return ErrResourceNotFound
+	case strings.Contains(output, "PERMISSION_DENIED"),
+		strings.Contains(lower, "does not have permission"),
+		strings.Contains(lower, "permission denied"),
+		strings.Contains(lower, "(403)"):
+		return ErrPermissionDenied
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ParentScopeFlags are the gcloud flags that scope a resource to an
+// organization or folder instead of a project
+var ParentScopeFlags = []string{"organization", "folder"}
+
+// ResourceSpec identifies one resource to fetch
+type ResourceSpec struct {
+	// Type is the gcloud resource path, e.g. "compute instances"
+	Type string
+
+	// Name is the resource name, or a file path for FileFetcher
+	Name string
+
+	// Project is the resource's project; it is unused when Flags name an
+	// organization or folder
+	Project string
+
+	// Flags holds the location, configuration and parent scope flags, keyed
+	// by gcloud flag name without the leading dashes
+	Flags map[string]string
+
+	// IAMPolicy selects the resource's IAM policy instead of the resource
+	IAMPolicy bool
+
+	// URL is where URLFetcher downloads the resource's JSON
+	URL string
+}
+
+// HasParentScope reports whether flags scope a resource to an organization
+// or folder, in which case gcloud is not passed --project
+func HasParentScope(flags map[string]string) bool {
+	for _, key := range ParentScopeFlags {
+		if flags[key] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Scope names the parent the resource lives under, e.g. "folder/123" or
+// "project/my-proj"
+func (s ResourceSpec) Scope() string {
+	for _, key := range ParentScopeFlags {
+		if value := s.Flags[key]; value != "" {
+			return key + "/" + value
+		}
+	}
+	return "project/" + s.Project
+}
+
+// Command returns the gcloud arguments that describe the resource, or get
+// its IAM policy when IAMPolicy is set
+func (s ResourceSpec) Command() string {
+	verb := "describe"
+	if s.IAMPolicy {
+		verb = "get-iam-policy"
+	}
+	parts := []string{s.Type, verb, s.Name}
+
+	if s.Project != "" && !HasParentScope(s.Flags) {
+		parts = append(parts, "--project="+s.Project)
+	}
+
+	parts = append(parts, formatFlags(s.Flags)...)
+
+	return strings.Join(parts, " ")
+}
+
+// formatFlags renders flags as --key=value arguments in sorted key order so
+// generated commands are deterministic
+func formatFlags(flags map[string]string) []string {
+	keys := make([]string, 0, len(flags))
+	for key := range flags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if value := flags[key]; value != "" {
+			args = append(args, fmt.Sprintf("--%s=%s", key, value))
+		}
+	}
+	return args
+}
+
+// Fetcher is a backend that retrieves resources for comparison. Both
+// resources are fetched concurrently, so implementations must be safe for
+// concurrent use.
+type Fetcher interface {
+	// Fetch returns the resource named by spec
+	Fetch(ctx context.Context, spec ResourceSpec) (map[string]interface{}, error)
+
+	// Describe says how spec would be fetched, for logs and --dry-run
+	Describe(spec ResourceSpec) string
+}
+
+// GcloudFetcher fetches resources by running gcloud describe and
+// get-iam-policy commands
+type GcloudFetcher struct {
+	*ResourceFetcher
+}
+
+// Fetch runs the gcloud command for spec and parses its output
+func (f *GcloudFetcher) Fetch(ctx context.Context, spec ResourceSpec) (map[string]interface{}, error) {
+	return f.FetchResourceGeneric(ctx, spec.Command())
+}
+
+// Describe returns the gcloud command line that fetches spec
+func (f *GcloudFetcher) Describe(spec ResourceSpec) string {
+	return f.binary + " " + spec.Command()
+}
+
+// StdinName is the resource name that makes FileFetcher read standard input
+const StdinName = "-"
+
+// FileFetcher reads resources from JSON or YAML files, such as saved gcloud
+// output. The resource name is the file path, or StdinName to read Stdin.
+type FileFetcher struct {
+	Stdin io.Reader
+}
+
+// Fetch reads and parses the file named by spec
+func (f *FileFetcher) Fetch(_ context.Context, spec ResourceSpec) (map[string]interface{}, error) {
+	if spec.IAMPolicy {
+		return nil, fmt.Errorf("IAM policies cannot be read from files")
+	}
+
+	var data []byte
+	var err error
+	if spec.Name == StdinName {
+		data, err = io.ReadAll(f.Stdin)
+	} else {
+		data, err = os.ReadFile(spec.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource: %w", err)
+	}
+
+	return parseResourceOutput(data)
+}
+
+// Describe names the file spec is read from
+func (f *FileFetcher) Describe(spec ResourceSpec) string {
+	if spec.Name == StdinName {
+		return "read stdin"
+	}
+	return "read " + spec.Name
+}
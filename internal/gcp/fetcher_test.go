@@ -0,0 +1,108 @@
+package gcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResourceSpec_Command(t *testing.T) {
+	tests := []struct {
+		name string
+		spec ResourceSpec
+		want string
+	}{
+		{
+			"project and flags",
+			ResourceSpec{Type: "compute instances", Name: "vm-1", Project: "proj", Flags: map[string]string{"zone": "us-central1-a"}},
+			"compute instances describe vm-1 --project=proj --zone=us-central1-a",
+		},
+		{
+			"iam policy",
+			ResourceSpec{Type: "pubsub topics", Name: "t", Project: "proj", IAMPolicy: true},
+			"pubsub topics get-iam-policy t --project=proj",
+		},
+		{
+			"organization omits project",
+			ResourceSpec{Type: "resource-manager org-policies", Name: "c", Project: "proj", Flags: map[string]string{"organization": "123"}},
+			"resource-manager org-policies describe c --organization=123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.spec.Command(); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResourceSpec_Scope(t *testing.T) {
+	if got := (ResourceSpec{Project: "p"}).Scope(); got != "project/p" {
+		t.Errorf("Expected project scope, got %q", got)
+	}
+	if got := (ResourceSpec{Project: "p", Flags: map[string]string{"folder": "9"}}).Scope(); got != "folder/9" {
+		t.Errorf("Expected folder scope, got %q", got)
+	}
+}
+
+func TestGcloudFetcher_Fetch(t *testing.T) {
+	var gotArgs []string
+	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		gotArgs = args
+		return []byte(`{"name": "vm-1"}`), nil
+	}
+	fetcher := &GcloudFetcher{NewResourceFetcherWithRunner(runner)}
+	spec := ResourceSpec{Type: "compute instances", Name: "vm-1", Project: "proj"}
+
+	result, err := fetcher.Fetch(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if result["name"] != "vm-1" {
+		t.Errorf("Unexpected resource: %v", result)
+	}
+	if strings.Join(gotArgs, " ") != "compute instances describe vm-1 --project=proj --format=json" {
+		t.Errorf("Unexpected gcloud arguments: %v", gotArgs)
+	}
+	if got := fetcher.Describe(spec); got != "gcloud compute instances describe vm-1 --project=proj" {
+		t.Errorf("Unexpected description: %q", got)
+	}
+}
+
+func TestFileFetcher_Fetch(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "vm.json")
+	yamlPath := filepath.Join(dir, "vm.yaml")
+	if err := os.WriteFile(jsonPath, []byte(`{"machineType": "n1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(yamlPath, []byte("machineType: n2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fetcher := &FileFetcher{Stdin: strings.NewReader(`{"machineType": "n3"}`)}
+
+	for name, want := range map[string]string{jsonPath: "n1", yamlPath: "n2", StdinName: "n3"} {
+		result, err := fetcher.Fetch(context.Background(), ResourceSpec{Name: name})
+		if err != nil {
+			t.Fatalf("Fetch(%s) failed: %v", name, err)
+		}
+		if result["machineType"] != want {
+			t.Errorf("Fetch(%s): expected machineType %q, got %v", name, want, result["machineType"])
+		}
+	}
+}
+
+func TestFileFetcher_Errors(t *testing.T) {
+	fetcher := &FileFetcher{}
+
+	if _, err := fetcher.Fetch(context.Background(), ResourceSpec{Name: filepath.Join(t.TempDir(), "missing.json")}); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+	if _, err := fetcher.Fetch(context.Background(), ResourceSpec{Name: "a.json", IAMPolicy: true}); err == nil {
+		t.Error("Expected an error for an IAM policy")
+	}
+}
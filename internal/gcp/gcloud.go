@@ -6,14 +6,48 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
+// ListItemsKey is the synthetic key under which top-level array output is wrapped
+const ListItemsKey = "items"
+
+// DefaultBinary is the gcloud executable used unless SetBinary is called
+const DefaultBinary = "gcloud"
+
+// CommandRunner executes an external command and returns its combined output
+type CommandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
 // ResourceFetcher fetches GCP resources using gcloud CLI
-type ResourceFetcher struct{}
+type ResourceFetcher struct {
+	runner CommandRunner
+	binary string
+}
 
-// NewResourceFetcher creates a new ResourceFetcher
+// NewResourceFetcher creates a new ResourceFetcher that shells out to gcloud
 func NewResourceFetcher() *ResourceFetcher {
-	return &ResourceFetcher{}
+	return NewResourceFetcherWithRunner(execRunner)
+}
+
+// NewResourceFetcherWithRunner creates a new ResourceFetcher that executes
+// gcloud commands through the given runner
+func NewResourceFetcherWithRunner(runner CommandRunner) *ResourceFetcher {
+	return &ResourceFetcher{runner: runner, binary: DefaultBinary}
+}
+
+// SetBinary sets the gcloud executable to run, e.g. an absolute path when
+// gcloud isn't on PATH. An empty path restores DefaultBinary.
+func (f *ResourceFetcher) SetBinary(path string) {
+	if path == "" {
+		path = DefaultBinary
+	}
+	f.binary = path
+}
+
+func execRunner(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
 }
 
 // FetchResourceGeneric fetches any resource using a generic gcloud command
@@ -39,17 +73,108 @@ func (f *ResourceFetcher) FetchResourceGeneric(ctx context.Context, gcloudComman
 	}
 
 	// Execute gcloud command
-	cmd := exec.CommandContext(ctx, "gcloud", parts...)
-	output, err := cmd.CombinedOutput()
+	output, err := f.runner(ctx, f.binary, parts...)
 	if err != nil {
+		if kind := classifyGcloudError(string(output)); kind != nil {
+			return nil, fmt.Errorf("%w: gcloud command failed: %w\nOutput: %s", kind, err, string(output))
+		}
 		return nil, fmt.Errorf("gcloud command failed: %w\nOutput: %s", err, string(output))
 	}
 
-	// Parse JSON output
-	var result map[string]interface{}
-	if err := json.Unmarshal(output, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse gcloud output: %w\nOutput: %s", err, string(output))
+	return parseResourceOutput(output)
+}
+
+// DefaultProject returns the project set in the named gcloud configuration,
+// or the active one when configuration is "", or "" when none is set
+func (f *ResourceFetcher) DefaultProject(ctx context.Context, configuration string) (string, error) {
+	args := []string{"config", "get-value", "project"}
+	if configuration != "" {
+		args = append(args, "--configuration="+configuration)
+	}
+	output, err := f.runner(ctx, f.binary, args...)
+	if err != nil {
+		return "", fmt.Errorf("gcloud config get-value project failed: %w\nOutput: %s", err, string(output))
 	}
 
-	return result, nil
+	// The output may include notices such as the active configuration's name;
+	// the project ID is the last line without spaces
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "(unset)" {
+			return "", nil
+		}
+		if line != "" && !strings.ContainsAny(line, " \t") {
+			return line, nil
+		}
+	}
+	return "", nil
+}
+
+// parseResourceOutput parses gcloud output into an object. Commands such as
+// list or --flatten describe return a top-level array, which is wrapped under
+// ListItemsKey so it can be compared like any other resource. Output that
+// isn't JSON is parsed as YAML, for commands that ignore --format=json.
+func parseResourceOutput(output []byte) (map[string]interface{}, error) {
+	var parsed interface{}
+	if jsonErr := json.Unmarshal(output, &parsed); jsonErr != nil {
+		var fromYAML interface{}
+		if yaml.Unmarshal(output, &fromYAML) != nil || !isCollection(fromYAML) {
+			return nil, fmt.Errorf("%w: failed to parse gcloud output: %w\nOutput: %s", ErrInvalidJSON, jsonErr, string(output))
+		}
+		parsed = normalizeYAML(fromYAML)
+	}
+
+	switch v := parsed.(type) {
+	case map[string]interface{}:
+		return v, nil
+	case []interface{}:
+		return map[string]interface{}{ListItemsKey: v}, nil
+	default:
+		return nil, fmt.Errorf("%w: unexpected gcloud output: expected a JSON object or array\nOutput: %s", ErrInvalidJSON, string(output))
+	}
+}
+
+// isCollection reports whether v is a decoded YAML mapping or sequence. Any
+// other text parses as a YAML scalar, so scalars don't count as YAML output.
+func isCollection(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, map[interface{}]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+// normalizeYAML converts decoded YAML into the shapes encoding/json produces,
+// recursively: maps with non-string keys get string keys, integers become
+// float64 and timestamps become RFC 3339 strings
+func normalizeYAML(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for key, value := range x {
+			x[key] = normalizeYAML(value)
+		}
+		return x
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for key, value := range x {
+			m[fmt.Sprint(key)] = normalizeYAML(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range x {
+			x[i] = normalizeYAML(value)
+		}
+		return x
+	case int:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	default:
+		return v
+	}
 }
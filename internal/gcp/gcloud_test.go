@@ -0,0 +1,282 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func staticRunner(output string) CommandRunner {
+	return func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte(output), nil
+	}
+}
+
+func TestFetchResourceGeneric_Object(t *testing.T) {
+	fetcher := NewResourceFetcherWithRunner(staticRunner(`{"name": "vm-1", "status": "RUNNING"}`))
+
+	result, err := fetcher.FetchResourceGeneric(context.Background(), "compute instances describe vm-1")
+	if err != nil {
+		t.Fatalf("FetchResourceGeneric failed: %v", err)
+	}
+
+	if result["name"] != "vm-1" {
+		t.Errorf("Expected name 'vm-1', got %v", result["name"])
+	}
+}
+
+func TestFetchResourceGeneric_TopLevelArray(t *testing.T) {
+	fetcher := NewResourceFetcherWithRunner(staticRunner(`[{"name": "rule-1"}, {"name": "rule-2"}]`))
+
+	result, err := fetcher.FetchResourceGeneric(context.Background(), "compute firewall-rules list")
+	if err != nil {
+		t.Fatalf("FetchResourceGeneric should not error on top-level array, got: %v", err)
+	}
+
+	items, ok := result[ListItemsKey].([]interface{})
+	if !ok {
+		t.Fatalf("Expected array wrapped under %q, got %v", ListItemsKey, result)
+	}
+
+	if len(items) != 2 {
+		t.Errorf("Expected 2 items, got %d", len(items))
+	}
+}
+
+func TestFetchResourceGeneric_ScalarOutput(t *testing.T) {
+	fetcher := NewResourceFetcherWithRunner(staticRunner(`"just a string"`))
+
+	_, err := fetcher.FetchResourceGeneric(context.Background(), "compute instances describe vm-1")
+	if err == nil {
+		t.Fatal("Expected error for scalar gcloud output")
+	}
+
+	if !strings.Contains(err.Error(), "unexpected gcloud output") {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+}
+
+func TestFetchResourceGeneric_AddsJSONFormat(t *testing.T) {
+	var gotArgs []string
+	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		gotArgs = args
+		return []byte(`{}`), nil
+	}
+	fetcher := NewResourceFetcherWithRunner(runner)
+
+	if _, err := fetcher.FetchResourceGeneric(context.Background(), "compute instances describe vm-1"); err != nil {
+		t.Fatalf("FetchResourceGeneric failed: %v", err)
+	}
+
+	if len(gotArgs) == 0 || gotArgs[len(gotArgs)-1] != "--format=json" {
+		t.Errorf("Expected --format=json to be appended, got %v", gotArgs)
+	}
+}
+
+func failingRunner(output string) CommandRunner {
+	return func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte(output), errors.New("exit status 1")
+	}
+}
+
+func TestFetchResourceGeneric_ClassifiesErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		runner   CommandRunner
+		expected error
+	}{
+		{
+			"not found",
+			failingRunner("ERROR: (gcloud.compute.instances.describe) Could not fetch resource:\n - The resource 'projects/p/zones/z/instances/vm-1' was not found\n"),
+			ErrResourceNotFound,
+		},
+		{
+			"not found status",
+			failingRunner("ERROR: (gcloud.run.services.describe) NOT_FOUND: Resource 'svc' does not exist."),
+			ErrResourceNotFound,
+		},
+		{
+			"permission denied",
+			failingRunner("ERROR: (gcloud.compute.instances.describe) Could not fetch resource:\n - Required 'compute.instances.get' permission for 'projects/p/zones/z/instances/vm-1' (403)\n"),
+			ErrPermissionDenied,
+		},
+		{
+			"permission denied status",
+			failingRunner("ERROR: (gcloud.pubsub.topics.describe) PERMISSION_DENIED: User not authorized to perform this action."),
+			ErrPermissionDenied,
+		},
+		{
+			"invalid json",
+			staticRunner("not json"),
+			ErrInvalidJSON,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := NewResourceFetcherWithRunner(tt.runner)
+			_, err := fetcher.FetchResourceGeneric(context.Background(), "compute instances describe vm-1")
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected error to match %v, got: %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestFetchResourceGeneric_UnclassifiedError(t *testing.T) {
+	fetcher := NewResourceFetcherWithRunner(failingRunner("ERROR: something unexpected"))
+
+	_, err := fetcher.FetchResourceGeneric(context.Background(), "compute instances describe vm-1")
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+
+	for _, sentinel := range []error{ErrResourceNotFound, ErrPermissionDenied, ErrInvalidJSON} {
+		if errors.Is(err, sentinel) {
+			t.Errorf("Unrecognized failure should not match %v", sentinel)
+		}
+	}
+}
+
+func TestFetchResourceGeneric_Binary(t *testing.T) {
+	var names []string
+	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		names = append(names, name)
+		return []byte("{}"), nil
+	}
+	fetcher := NewResourceFetcherWithRunner(runner)
+
+	if _, err := fetcher.FetchResourceGeneric(context.Background(), "compute instances describe vm-1"); err != nil {
+		t.Fatalf("FetchResourceGeneric failed: %v", err)
+	}
+	fetcher.SetBinary("/opt/google-cloud-sdk/bin/gcloud")
+	if _, err := fetcher.FetchResourceGeneric(context.Background(), "compute instances describe vm-1"); err != nil {
+		t.Fatalf("FetchResourceGeneric failed: %v", err)
+	}
+
+	if len(names) != 2 || names[0] != DefaultBinary || names[1] != "/opt/google-cloud-sdk/bin/gcloud" {
+		t.Errorf("Expected default then configured binary, got %v", names)
+	}
+}
+
+func TestFetchResourceGeneric_YAMLOutput(t *testing.T) {
+	output := `name: vm-1
+status: RUNNING
+diskSizeGb: 10
+labels:
+  env: prod
+tags:
+  - http
+  - https
+`
+	fetcher := NewResourceFetcherWithRunner(staticRunner(output))
+
+	result, err := fetcher.FetchResourceGeneric(context.Background(), "compute instances describe vm-1")
+	if err != nil {
+		t.Fatalf("FetchResourceGeneric should parse YAML output, got: %v", err)
+	}
+
+	if result["name"] != "vm-1" || result["status"] != "RUNNING" {
+		t.Errorf("Unexpected top-level fields: %v", result)
+	}
+	if labels, ok := result["labels"].(map[string]interface{}); !ok || labels["env"] != "prod" {
+		t.Errorf("Expected labels decoded as a map, got %#v", result["labels"])
+	}
+	if tags, ok := result["tags"].([]interface{}); !ok || len(tags) != 2 {
+		t.Errorf("Expected tags decoded as a list, got %#v", result["tags"])
+	}
+}
+
+func TestFetchResourceGeneric_YAMLNonStringKeys(t *testing.T) {
+	fetcher := NewResourceFetcherWithRunner(staticRunner("ports:\n  80: http\n  443: https\n"))
+
+	result, err := fetcher.FetchResourceGeneric(context.Background(), "compute instances describe vm-1")
+	if err != nil {
+		t.Fatalf("FetchResourceGeneric failed: %v", err)
+	}
+
+	ports, ok := result["ports"].(map[string]interface{})
+	if !ok || ports["443"] != "https" {
+		t.Errorf("Expected numeric keys converted to strings, got %#v", result["ports"])
+	}
+}
+
+func TestFetchResourceGeneric_YAMLScalarsMatchJSON(t *testing.T) {
+	yamlOutput := "diskSizeGb: 100\ncpuUtilization: 0.6\ncreationTimestamp: 2024-01-02T03:04:05Z\n"
+	jsonOutput := `{"diskSizeGb": 100, "cpuUtilization": 0.6, "creationTimestamp": "2024-01-02T03:04:05Z"}`
+
+	fromYAML, err := NewResourceFetcherWithRunner(staticRunner(yamlOutput)).FetchResourceGeneric(context.Background(), "compute disks describe d-1")
+	if err != nil {
+		t.Fatalf("FetchResourceGeneric failed: %v", err)
+	}
+	fromJSON, err := NewResourceFetcherWithRunner(staticRunner(jsonOutput)).FetchResourceGeneric(context.Background(), "compute disks describe d-1")
+	if err != nil {
+		t.Fatalf("FetchResourceGeneric failed: %v", err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Errorf("Expected YAML output decoded like JSON\nYAML: %#v\nJSON: %#v", fromYAML, fromJSON)
+	}
+}
+
+func TestFetchResourceGeneric_UnparseableOutput(t *testing.T) {
+	fetcher := NewResourceFetcherWithRunner(staticRunner("Listed 0 items."))
+
+	_, err := fetcher.FetchResourceGeneric(context.Background(), "compute instances describe vm-1")
+	if !errors.Is(err, ErrInvalidJSON) {
+		t.Fatalf("Expected ErrInvalidJSON, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Output: Listed 0 items.") {
+		t.Errorf("Expected the raw output in the error, got: %v", err)
+	}
+}
+
+func TestDefaultProject_Configuration(t *testing.T) {
+	var gotArgs []string
+	fetcher := NewResourceFetcherWithRunner(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		gotArgs = args
+		return []byte("work-project\n"), nil
+	})
+
+	if _, err := fetcher.DefaultProject(context.Background(), "work"); err != nil {
+		t.Fatalf("DefaultProject failed: %v", err)
+	}
+	if strings.Join(gotArgs, " ") != "config get-value project --configuration=work" {
+		t.Errorf("Expected the configuration to be passed, got args: %v", gotArgs)
+	}
+}
+
+func TestDefaultProject(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"set", "my-project\n", "my-project"},
+		{"with notice", "Your active configuration is: [work]\nmy-project\n", "my-project"},
+		{"unset", "(unset)\n", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotArgs []string
+			fetcher := NewResourceFetcherWithRunner(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+				gotArgs = args
+				return []byte(tt.output), nil
+			})
+
+			got, err := fetcher.DefaultProject(context.Background(), "")
+			if err != nil {
+				t.Fatalf("DefaultProject failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DefaultProject() = %q, want %q", got, tt.want)
+			}
+			if strings.Join(gotArgs, " ") != "config get-value project" {
+				t.Errorf("Unexpected gcloud args: %v", gotArgs)
+			}
+		})
+	}
+}
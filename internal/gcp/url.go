@@ -0,0 +1,78 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultURLTimeout is the usual URLFetcher timeout
+const DefaultURLTimeout = 30 * time.Second
+
+// maxErrorBodyLen is how much of an error response body is quoted in the
+// returned error
+const maxErrorBodyLen = 200
+
+// URLFetcher downloads resource snapshots, such as saved gcloud JSON output,
+// from the URL of each spec
+type URLFetcher struct {
+	// Timeout is how long a download may take, including reading the
+	// response. Zero or less disables the timeout.
+	Timeout time.Duration
+}
+
+// Fetch downloads spec.URL. Any status other than 2xx is an error.
+func (f *URLFetcher) Fetch(ctx context.Context, spec ResourceSpec) (map[string]interface{}, error) {
+	if spec.IAMPolicy {
+		return nil, fmt.Errorf("IAM policies cannot be read from URLs")
+	}
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+	url := spec.URL
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", url, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s failed reading the response: %w", url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("GET %s failed: HTTP %s%s", url, resp.Status, errorBodySnippet(body))
+	}
+
+	return parseResourceOutput(body)
+}
+
+// Describe returns the request that downloads spec
+func (f *URLFetcher) Describe(spec ResourceSpec) string {
+	return "GET " + spec.URL
+}
+
+// errorBodySnippet quotes the start of an error response body, which often
+// explains the status, or returns "" for an empty body
+func errorBodySnippet(body []byte) string {
+	text := strings.TrimSpace(string(body))
+	if text == "" {
+		return ""
+	}
+	if len(text) > maxErrorBodyLen {
+		text = text[:maxErrorBodyLen] + "..."
+	}
+	return ": " + text
+}
@@ -0,0 +1,79 @@
+package gcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLFetcher_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/snapshots/vm-1.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "vm-1", "machineType": "n1-standard-2"}`))
+	}))
+	defer server.Close()
+
+	fetcher := &URLFetcher{Timeout: DefaultURLTimeout}
+	spec := ResourceSpec{Type: "compute instances", Name: "vm-1", URL: server.URL + "/snapshots/vm-1.json"}
+	if got := fetcher.Describe(spec); got != "GET "+spec.URL {
+		t.Errorf("Unexpected description %q", got)
+	}
+	resource, err := fetcher.Fetch(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if resource["machineType"] != "n1-standard-2" {
+		t.Errorf("Unexpected resource: %v", resource)
+	}
+}
+
+func TestURLFetcher_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "snapshot expired", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	fetcher := &URLFetcher{}
+	_, err := fetcher.Fetch(context.Background(), ResourceSpec{URL: server.URL + "/vm-1.json"})
+	if err == nil {
+		t.Fatal("Expected an error for a 403 response")
+	}
+	for _, want := range []string{"HTTP 403 Forbidden", "snapshot expired"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got %v", want, err)
+		}
+	}
+}
+
+func TestURLFetcher_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	fetcher := &URLFetcher{Timeout: 50 * time.Millisecond}
+	_, err := fetcher.Fetch(context.Background(), ResourceSpec{URL: server.URL})
+	if err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+}
+
+func TestURLFetcher_RejectsIAMPolicy(t *testing.T) {
+	fetcher := &URLFetcher{}
+	_, err := fetcher.Fetch(context.Background(), ResourceSpec{URL: "http://example.invalid", IAMPolicy: true})
+	if err == nil || !strings.Contains(err.Error(), "IAM policies") {
+		t.Errorf("Expected IAM policies to be rejected, got %v", err)
+	}
+}